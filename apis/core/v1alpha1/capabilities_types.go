@@ -149,7 +149,7 @@ type Result struct {
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
-//+kubebuilder:unservedversion
+//+kubebuilder:deprecatedversion:warning="core.tanzu.vmware.com/v1alpha1 Capability is deprecated, use core.tanzu.vmware.com/v1alpha2 Capability"
 
 // Capability is the Schema for the capabilities API
 type Capability struct {
@@ -0,0 +1,247 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	corev1alpha2 "github.com/vmware-tanzu/tanzu-framework/apis/core/v1alpha2"
+)
+
+// ConversionDataAnnotation is the annotation that holds the v1alpha2 spec and status of a Capability converted to
+// v1alpha1, so that the queries, results and fields v1alpha1 cannot represent are restored when the Capability is
+// converted back.
+const ConversionDataAnnotation = "core.tanzu.vmware.com/conversion-data"
+
+// conversionData is the content of the ConversionDataAnnotation annotation.
+// +kubebuilder:object:generate=false
+type conversionData struct {
+	Spec   corev1alpha2.CapabilitySpec   `json:"spec"`
+	Status corev1alpha2.CapabilityStatus `json:"status"`
+}
+
+// ConvertTo converts this Capability to the hub version, v1alpha2.
+func (src *Capability) ConvertTo(dstRaw conversion.Hub) error {
+	dst, ok := dstRaw.(*corev1alpha2.Capability)
+	if !ok {
+		return fmt.Errorf("unexpected hub type %T", dstRaw)
+	}
+
+	restored := &conversionData{}
+	if data, ok := src.Annotations[ConversionDataAnnotation]; ok {
+		if err := json.Unmarshal([]byte(data), restored); err != nil {
+			return fmt.Errorf("failed to unmarshal annotation %s: %w", ConversionDataAnnotation, err)
+		}
+	}
+
+	src.ObjectMeta.DeepCopyInto(&dst.ObjectMeta)
+	delete(dst.Annotations, ConversionDataAnnotation)
+	if len(dst.Annotations) == 0 {
+		dst.Annotations = nil
+	}
+
+	dst.Spec = corev1alpha2.CapabilitySpec{
+		ServiceAccountName:   src.Spec.ServiceAccountName,
+		ReevaluationInterval: restored.Spec.ReevaluationInterval,
+	}
+	for i := range src.Spec.Queries {
+		dst.Spec.Queries = append(dst.Spec.Queries, convertQueryTo(&src.Spec.Queries[i], restoredQuery(&restored.Spec, src.Spec.Queries[i].Name)))
+	}
+
+	dst.Status = corev1alpha2.CapabilityStatus{
+		ObservedGeneration: restored.Status.ObservedGeneration,
+		LastEvaluationTime: restored.Status.LastEvaluationTime,
+		Conditions:         restored.Status.Conditions,
+	}
+	for i := range src.Status.Results {
+		dst.Status.Results = append(dst.Status.Results, convertResultTo(&src.Status.Results[i], restoredResult(&restored.Status, src.Status.Results[i].Name)))
+	}
+	return nil
+}
+
+// ConvertFrom converts the hub version, v1alpha2, to this Capability. The v1alpha2 spec and status are kept in the
+// ConversionDataAnnotation annotation, since v1alpha1 only has GVR, Object and PartialSchema queries, and no
+// conditions.
+func (dst *Capability) ConvertFrom(srcRaw conversion.Hub) error {
+	src, ok := srcRaw.(*corev1alpha2.Capability)
+	if !ok {
+		return fmt.Errorf("unexpected hub type %T", srcRaw)
+	}
+
+	data, err := json.Marshal(&conversionData{Spec: src.Spec, Status: src.Status})
+	if err != nil {
+		return fmt.Errorf("failed to marshal the v1alpha2 spec and status: %w", err)
+	}
+	src.ObjectMeta.DeepCopyInto(&dst.ObjectMeta)
+	if dst.Annotations == nil {
+		dst.Annotations = make(map[string]string)
+	}
+	dst.Annotations[ConversionDataAnnotation] = string(data)
+
+	dst.Spec = CapabilitySpec{ServiceAccountName: src.Spec.ServiceAccountName}
+	for i := range src.Spec.Queries {
+		q := &src.Spec.Queries[i]
+		query := Query{Name: q.Name}
+		for _, gvr := range q.GroupVersionResources {
+			query.GroupVersionResources = append(query.GroupVersionResources, QueryGVR{
+				Name:     gvr.Name,
+				Group:    gvr.Group,
+				Versions: gvr.Versions,
+				Resource: gvr.Resource,
+			})
+		}
+		for j := range q.Objects {
+			query.Objects = append(query.Objects, QueryObject{
+				Name:               q.Objects[j].Name,
+				ObjectReference:    q.Objects[j].ObjectReference,
+				WithAnnotations:    q.Objects[j].WithAnnotations,
+				WithoutAnnotations: q.Objects[j].WithoutAnnotations,
+			})
+		}
+		for _, schema := range q.PartialSchemas {
+			query.PartialSchemas = append(query.PartialSchemas, QueryPartialSchema{
+				Name:          schema.Name,
+				PartialSchema: schema.PartialSchema,
+			})
+		}
+		dst.Spec.Queries = append(dst.Spec.Queries, query)
+	}
+
+	dst.Status = CapabilityStatus{}
+	for i := range src.Status.Results {
+		r := &src.Status.Results[i]
+		dst.Status.Results = append(dst.Status.Results, Result{
+			Name:                  r.Name,
+			GroupVersionResources: convertQueryResultsFrom(r.GroupVersionResources),
+			Objects:               convertQueryResultsFrom(r.Objects),
+			PartialSchemas:        convertQueryResultsFrom(r.PartialSchemas),
+		})
+	}
+	return nil
+}
+
+// restoredQuery returns the query with the name in the restored v1alpha2 spec, or nil if there is none.
+func restoredQuery(restored *corev1alpha2.CapabilitySpec, name string) *corev1alpha2.Query {
+	for i := range restored.Queries {
+		if restored.Queries[i].Name == name {
+			return &restored.Queries[i]
+		}
+	}
+	return nil
+}
+
+// convertQueryTo converts a query to v1alpha2. The queries and fields that v1alpha1 cannot represent are taken from
+// the restored query, if any.
+func convertQueryTo(src *Query, restored *corev1alpha2.Query) corev1alpha2.Query {
+	dst := corev1alpha2.Query{}
+	if restored != nil {
+		restored.DeepCopyInto(&dst)
+	}
+	dst.Name = src.Name
+
+	dst.GroupVersionResources = nil
+	for _, gvr := range src.GroupVersionResources {
+		dst.GroupVersionResources = append(dst.GroupVersionResources, corev1alpha2.QueryGVR{
+			Name:     gvr.Name,
+			Group:    gvr.Group,
+			Versions: gvr.Versions,
+			Resource: gvr.Resource,
+		})
+	}
+
+	objects := dst.Objects
+	dst.Objects = nil
+	for i := range src.Objects {
+		o := corev1alpha2.QueryObject{}
+		for j := range objects {
+			if objects[j].Name == src.Objects[i].Name {
+				o = objects[j]
+			}
+		}
+		o.Name = src.Objects[i].Name
+		o.ObjectReference = src.Objects[i].ObjectReference
+		o.WithAnnotations = src.Objects[i].WithAnnotations
+		o.WithoutAnnotations = src.Objects[i].WithoutAnnotations
+		dst.Objects = append(dst.Objects, o)
+	}
+
+	schemas := dst.PartialSchemas
+	dst.PartialSchemas = nil
+	for _, schema := range src.PartialSchemas {
+		s := corev1alpha2.QueryPartialSchema{}
+		for j := range schemas {
+			if schemas[j].Name == schema.Name {
+				s = schemas[j]
+			}
+		}
+		s.Name = schema.Name
+		s.PartialSchema = schema.PartialSchema
+		dst.PartialSchemas = append(dst.PartialSchemas, s)
+	}
+	return dst
+}
+
+// restoredResult returns the result with the name in the restored v1alpha2 status, or nil if there is none.
+func restoredResult(restored *corev1alpha2.CapabilityStatus, name string) *corev1alpha2.Result {
+	for i := range restored.Results {
+		if restored.Results[i].Name == name {
+			return &restored.Results[i]
+		}
+	}
+	return nil
+}
+
+// convertResultTo converts a result to v1alpha2. The results and fields that v1alpha1 cannot represent are taken from
+// the restored result, if any.
+func convertResultTo(src *Result, restored *corev1alpha2.Result) corev1alpha2.Result {
+	dst := corev1alpha2.Result{}
+	if restored != nil {
+		restored.DeepCopyInto(&dst)
+	}
+	dst.Name = src.Name
+	dst.GroupVersionResources = convertQueryResultsTo(src.GroupVersionResources, dst.GroupVersionResources)
+	dst.Objects = convertQueryResultsTo(src.Objects, dst.Objects)
+	dst.PartialSchemas = convertQueryResultsTo(src.PartialSchemas, dst.PartialSchemas)
+	return dst
+}
+
+// convertQueryResultsTo converts query results to v1alpha2. The details of a restored query result, e.g. its error
+// class, are kept when the result did not change in v1alpha1.
+func convertQueryResultsTo(results []QueryResult, restored []corev1alpha2.QueryResult) []corev1alpha2.QueryResult {
+	var converted []corev1alpha2.QueryResult
+	for _, r := range results {
+		c := corev1alpha2.QueryResult{
+			Name:           r.Name,
+			Found:          r.Found,
+			Error:          r.Error,
+			ErrorDetail:    r.ErrorDetail,
+			NotFoundReason: r.NotFoundReason,
+		}
+		for i := range restored {
+			if restored[i].Name == r.Name && restored[i].Found == r.Found && restored[i].Error == r.Error &&
+				restored[i].ErrorDetail == r.ErrorDetail && restored[i].NotFoundReason == r.NotFoundReason {
+				c = restored[i]
+			}
+		}
+		converted = append(converted, c)
+	}
+	return converted
+}
+
+func convertQueryResultsFrom(results []corev1alpha2.QueryResult) []QueryResult {
+	var converted []QueryResult
+	for i := range results {
+		converted = append(converted, QueryResult{
+			Name:           results[i].Name,
+			Found:          results[i].Found,
+			Error:          results[i].Error,
+			ErrorDetail:    results[i].ErrorDetail,
+			NotFoundReason: results[i].NotFoundReason,
+		})
+	}
+	return converted
+}
@@ -0,0 +1,123 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	corev1alpha2 "github.com/vmware-tanzu/tanzu-framework/apis/core/v1alpha2"
+)
+
+func TestCapabilityConversionRoundTrip(t *testing.T) {
+	minCount := int32(2)
+	evaluated := metav1.NewTime(time.Date(2023, time.March, 1, 12, 0, 0, 0, time.UTC))
+	hub := &corev1alpha2.Capability{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "tkg", Annotations: map[string]string{"owner": "tkg"}},
+		Spec: corev1alpha2.CapabilitySpec{
+			ServiceAccountName:   "capabilities",
+			ReevaluationInterval: &metav1.Duration{Duration: 5 * time.Minute},
+			Queries: []corev1alpha2.Query{{
+				Name:                  "cluster",
+				GroupVersionResources: []corev1alpha2.QueryGVR{{Name: "tkr", Group: "run.tanzu.vmware.com", Versions: []string{"v1alpha3"}, Resource: "tanzukubernetesreleases"}},
+				Objects: []corev1alpha2.QueryObject{{
+					Name:            "nodes",
+					ObjectReference: corev1.ObjectReference{APIVersion: "v1", Kind: "Node"},
+					MinCount:        &minCount,
+					WithAnnotations: map[string]string{"cluster": "tkg"},
+				}},
+				PartialSchemas: []corev1alpha2.QueryPartialSchema{{Name: "schema", PartialSchema: "type: object", Definition: "io.k8s.api.core.v1.Pod"}},
+				ServerVersions: []corev1alpha2.QueryServerVersion{{Name: "version", Constraint: ">=1.24"}},
+			}},
+		},
+		Status: corev1alpha2.CapabilityStatus{
+			Results: []corev1alpha2.Result{{
+				Name:      "cluster",
+				Satisfied: true,
+				Objects: []corev1alpha2.QueryResult{{
+					Name:            "nodes",
+					Found:           true,
+					ObjectReference: &corev1.ObjectReference{APIVersion: "v1", Kind: "Node"},
+					Duration:        &metav1.Duration{Duration: time.Second},
+				}},
+				ServerVersions: []corev1alpha2.QueryResult{{Name: "version", Found: true}},
+			}},
+			ObservedGeneration: 3,
+			LastEvaluationTime: &evaluated,
+			Conditions: []metav1.Condition{{
+				Type:               corev1alpha2.CapabilityReadyCondition,
+				Status:             metav1.ConditionTrue,
+				ObservedGeneration: 3,
+				LastTransitionTime: evaluated,
+				Reason:             corev1alpha2.CapabilityQueriesSatisfiedReason,
+				Message:            "all queries are satisfied",
+			}},
+		},
+	}
+
+	spoke := &Capability{}
+	if err := spoke.ConvertFrom(hub.DeepCopy()); err != nil {
+		t.Fatal(err)
+	}
+	if got := spoke.Spec.Queries[0].Objects[0]; got.Name != "nodes" || got.WithAnnotations["cluster"] != "tkg" {
+		t.Errorf("unexpected v1alpha1 object query: %+v", got)
+	}
+	if got := spoke.Status.Results[0].Objects; len(got) != 1 || !got[0].Found {
+		t.Errorf("unexpected v1alpha1 results: %+v", got)
+	}
+
+	t.Run("restores the v1alpha2 spec and status", func(t *testing.T) {
+		got := &corev1alpha2.Capability{}
+		if err := spoke.DeepCopy().ConvertTo(got); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(hub.ObjectMeta, got.ObjectMeta); diff != "" {
+			t.Errorf("unexpected metadata (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff(hub.Spec, got.Spec); diff != "" {
+			t.Errorf("unexpected spec (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff(hub.Status, got.Status); diff != "" {
+			t.Errorf("unexpected status (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("drops the details of results changed in v1alpha1", func(t *testing.T) {
+		changed := spoke.DeepCopy()
+		changed.Status.Results[0].Objects[0].Found = false
+
+		got := &corev1alpha2.Capability{}
+		if err := changed.ConvertTo(got); err != nil {
+			t.Fatal(err)
+		}
+		want := hub.Status.DeepCopy()
+		want.Results[0].Objects[0] = corev1alpha2.QueryResult{Name: "nodes"}
+		if diff := cmp.Diff(*want, got.Status); diff != "" {
+			t.Errorf("unexpected status (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("keeps the changes made in v1alpha1", func(t *testing.T) {
+		changed := spoke.DeepCopy()
+		changed.Spec.Queries[0].Objects[0].WithAnnotations = map[string]string{"cluster": "workload"}
+		changed.Spec.Queries[0].PartialSchemas = nil
+		changed.Spec.Queries = append(changed.Spec.Queries, Query{Name: "new", GroupVersionResources: []QueryGVR{{Name: "apps", Group: "apps"}}})
+
+		got := &corev1alpha2.Capability{}
+		if err := changed.ConvertTo(got); err != nil {
+			t.Fatal(err)
+		}
+		want := hub.Spec.DeepCopy()
+		want.Queries[0].Objects[0].WithAnnotations = map[string]string{"cluster": "workload"}
+		want.Queries[0].PartialSchemas = nil
+		want.Queries = append(want.Queries, corev1alpha2.Query{Name: "new", GroupVersionResources: []corev1alpha2.QueryGVR{{Name: "apps", Group: "apps"}}})
+		if diff := cmp.Diff(*want, got.Spec); diff != "" {
+			t.Errorf("unexpected spec (-want +got):\n%s", diff)
+		}
+	})
+}
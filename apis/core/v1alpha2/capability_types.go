@@ -21,9 +21,15 @@ type CapabilitySpec struct {
 	// +listType=map
 	// +listMapKey=name
 	Queries []Query `json:"queries"`
+	// ReevaluationInterval is the interval at which the queries are evaluated again. Queries are always evaluated
+	// again when the objects they query, CustomResourceDefinitions or APIServices change; the interval also catches
+	// changes that are not observed, e.g. of the server version or of OpenAPI schemas.
+	// When this field is not specified, queries are only evaluated again on changes.
+	// +optional
+	ReevaluationInterval *metav1.Duration `json:"reevaluationInterval,omitempty"`
 }
 
-// Query is a logical grouping of GVR, Object and PartialSchema queries.
+// Query is a logical grouping of GVR, Object, PartialSchema, ServerVersion, StatusCondition and Access queries.
 type Query struct {
 	// Name is the unique name of the query.
 	// +kubebuilder:validation:Required
@@ -44,6 +50,112 @@ type Query struct {
 	// +listMapKey=name
 	// +optional
 	PartialSchemas []QueryPartialSchema `json:"partialSchemas,omitempty"`
+	// ServerVersions evaluates a slice of ServerVersion queries.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	ServerVersions []QueryServerVersion `json:"serverVersions,omitempty"`
+	// StatusConditions evaluates a slice of StatusCondition queries.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	StatusConditions []QueryStatusCondition `json:"statusConditions,omitempty"`
+	// AccessChecks evaluates a slice of Access queries.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	AccessChecks []QueryAccess `json:"accessChecks,omitempty"`
+	// APIServices evaluates a slice of APIService queries.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	APIServices []QueryAPIService `json:"apiServices,omitempty"`
+	// CustomResourceDefinitions evaluates a slice of CustomResourceDefinition queries.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	CustomResourceDefinitions []QueryCustomResourceDefinition `json:"customResourceDefinitions,omitempty"`
+	// Nodes evaluates a slice of Nodes queries.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Nodes []QueryNodes `json:"nodes,omitempty"`
+	// DataKeys evaluates a slice of DataKey queries.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	DataKeys []QueryDataKey `json:"dataKeys,omitempty"`
+	// AnyOf evaluates a slice of AnyOf queries. Each succeeds if at least one of its queries succeeds,
+	// e.g. one of several versions of an API exists.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	AnyOf []QueryCombination `json:"anyOf,omitempty"`
+	// Not evaluates a slice of Not queries. Each succeeds only if none of its queries succeed,
+	// e.g. a namespace must not exist.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Not []QueryCombination `json:"not,omitempty"`
+}
+
+// QueryCombination is a named set of GVR, Object, PartialSchema, ServerVersion, StatusCondition and Access queries
+// that are combined by a boolean operator.
+type QueryCombination struct {
+	// Name is the unique name of the query.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength:=1
+	Name string `json:"name"`
+	// GroupVersionResources is a slice of GVR queries.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	GroupVersionResources []QueryGVR `json:"groupVersionResources,omitempty"`
+	// Objects is a slice of Object queries.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Objects []QueryObject `json:"objects,omitempty"`
+	// PartialSchemas is a slice of PartialSchema queries.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	PartialSchemas []QueryPartialSchema `json:"partialSchemas,omitempty"`
+	// ServerVersions is a slice of ServerVersion queries.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	ServerVersions []QueryServerVersion `json:"serverVersions,omitempty"`
+	// StatusConditions is a slice of StatusCondition queries.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	StatusConditions []QueryStatusCondition `json:"statusConditions,omitempty"`
+	// AccessChecks is a slice of Access queries.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	AccessChecks []QueryAccess `json:"accessChecks,omitempty"`
+	// APIServices is a slice of APIService queries.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	APIServices []QueryAPIService `json:"apiServices,omitempty"`
+	// CustomResourceDefinitions is a slice of CustomResourceDefinition queries.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	CustomResourceDefinitions []QueryCustomResourceDefinition `json:"customResourceDefinitions,omitempty"`
+	// Nodes is a slice of Nodes queries.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Nodes []QueryNodes `json:"nodes,omitempty"`
+	// DataKeys is a slice of DataKey queries.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	DataKeys []QueryDataKey `json:"dataKeys,omitempty"`
 }
 
 // QueryObject represents any runtime.Object that could exist in a cluster with the ability to check for annotations.
@@ -53,8 +165,25 @@ type QueryObject struct {
 	// +kubebuilder:validation:MinLength:=1
 	Name string `json:"name"`
 	// ObjectReference is the ObjectReference to check for in the cluster.
+	// When the name is empty or any of LabelSelector, FieldSelector, MinCount or MaxCount is specified,
+	// the objects of the kind are counted instead, in the namespace of the reference or in all namespaces
+	// when it is empty. Only the objects that satisfy the annotations and field predicates are counted.
 	// +kubebuilder:validation:Required
 	ObjectReference corev1.ObjectReference `json:"objectReference"`
+	// LabelSelector selects the objects to count by their labels.
+	// +optional
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+	// FieldSelector selects the objects to count by their fields, e.g. "metadata.name=default".
+	// +optional
+	FieldSelector string `json:"fieldSelector,omitempty"`
+	// MinCount is the minimum number of objects that must match. Defaults to 1 when objects are counted.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MinCount *int32 `json:"minCount,omitempty"`
+	// MaxCount is the maximum number of objects that may match. When this field is not specified, there is no maximum.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxCount *int32 `json:"maxCount,omitempty"`
 	// WithAnnotations are the annotations whose presence is checked in the object.
 	// The query succeeds only if all the annotations specified exists.
 	// +optional
@@ -63,6 +192,53 @@ type QueryObject struct {
 	// The query succeeds only if all the annotations specified do not exist.
 	// +optional
 	WithoutAnnotations map[string]string `json:"withoutAnnotations,omitempty"`
+	// WithFieldPredicates are assertions on the fields of the object, e.g. spec or status.
+	// The query succeeds only if all the predicates specified are satisfied.
+	// +optional
+	WithFieldPredicates []FieldPredicate `json:"withFieldPredicates,omitempty"`
+}
+
+// FieldPredicateOperator is the operator used by a FieldPredicate.
+type FieldPredicateOperator string
+
+const (
+	// FieldPredicateExists matches when the path resolves to at least one value.
+	FieldPredicateExists = FieldPredicateOperator("Exists")
+	// FieldPredicateDoesNotExist matches when the path does not resolve to any value.
+	FieldPredicateDoesNotExist = FieldPredicateOperator("DoesNotExist")
+	// FieldPredicateEquals matches when a value at the path equals the single operand.
+	FieldPredicateEquals = FieldPredicateOperator("Equals")
+	// FieldPredicateNotEquals matches when no value at the path equals the single operand.
+	FieldPredicateNotEquals = FieldPredicateOperator("NotEquals")
+	// FieldPredicateIn matches when a value at the path equals one of the operands.
+	FieldPredicateIn = FieldPredicateOperator("In")
+	// FieldPredicateNotIn matches when no value at the path equals any of the operands.
+	FieldPredicateNotIn = FieldPredicateOperator("NotIn")
+	// FieldPredicateGreaterThan matches when a numeric value at the path is greater than the single operand.
+	FieldPredicateGreaterThan = FieldPredicateOperator("GreaterThan")
+	// FieldPredicateGreaterThanOrEqual matches when a numeric value at the path is greater than or equal to the single operand.
+	FieldPredicateGreaterThanOrEqual = FieldPredicateOperator("GreaterThanOrEqual")
+	// FieldPredicateLessThan matches when a numeric value at the path is less than the single operand.
+	FieldPredicateLessThan = FieldPredicateOperator("LessThan")
+	// FieldPredicateLessThanOrEqual matches when a numeric value at the path is less than or equal to the single operand.
+	FieldPredicateLessThanOrEqual = FieldPredicateOperator("LessThanOrEqual")
+)
+
+// FieldPredicate asserts on the value found at a JSONPath in an object.
+type FieldPredicate struct {
+	// Path is the JSONPath of the field in the object, e.g. "spec.replicas" or "{.status.phase}".
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength:=1
+	Path string `json:"path"`
+	// Operator is the comparison applied to the value(s) found at Path.
+	// Ordering operators compare numerically.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=Exists;DoesNotExist;Equals;NotEquals;In;NotIn;GreaterThan;GreaterThanOrEqual;LessThan;LessThanOrEqual
+	Operator FieldPredicateOperator `json:"operator"`
+	// Values are the operands of the operator. Exists and DoesNotExist take no values,
+	// In and NotIn take one or more values and all other operators take exactly one value.
+	// +optional
+	Values []string `json:"values,omitempty"`
 }
 
 // QueryGVR queries for an API group with the optional ability to check for API versions and resource.
@@ -90,19 +266,302 @@ type QueryPartialSchema struct {
 	// +kubebuilder:validation:MinLength:=1
 	Name string `json:"name"`
 	// PartialSchema is the partial OpenAPI schema that will be matched in a cluster.
+	// It is YAML or JSON and is matched as a subset of an OpenAPI definition.
 	// +kubebuilder:validation:Required
 	// +kubebuilder:validation:MinLength:=1
 	PartialSchema string `json:"partialSchema"`
+	// Definition is the name of the OpenAPI definition to match against, e.g. "io.k8s.api.apps.v1.Deployment".
+	// When this field is not specified, the partial schema can match any definition.
+	// +optional
+	Definition string `json:"definition,omitempty"`
+	// OpenAPIVersion is the version of the OpenAPI documents to match against.
+	// When this field is not specified, the OpenAPI v2 document is used.
+	// +kubebuilder:validation:Enum=v2;v3
+	// +optional
+	OpenAPIVersion OpenAPIVersion `json:"openAPIVersion,omitempty"`
+	// OpenAPIV3Paths restricts the OpenAPI v3 documents that are searched, e.g. "apis/apps/v1".
+	// When this field is not specified, all OpenAPI v3 documents are searched.
+	// +optional
+	OpenAPIV3Paths []string `json:"openAPIV3Paths,omitempty"`
+}
+
+// QueryServerVersion queries for the Kubernetes version of the cluster.
+type QueryServerVersion struct {
+	// Name is the unique name of the query.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength:=1
+	Name string `json:"name"`
+	// Constraint is the semver constraint the Kubernetes version must satisfy, e.g. ">=1.24, <1.29".
+	// Comparisons separated by a comma must all be satisfied and alternatives are separated by "||".
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength:=1
+	Constraint string `json:"constraint"`
 }
 
+// QueryStatusCondition queries for a condition in the status.conditions of an object, e.g. Ready=True.
+type QueryStatusCondition struct {
+	// Name is the unique name of the query.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength:=1
+	Name string `json:"name"`
+	// ObjectReference is the ObjectReference of the object whose condition is checked.
+	// +kubebuilder:validation:Required
+	ObjectReference corev1.ObjectReference `json:"objectReference"`
+	// Type is the type of the condition, e.g. "Ready" or "Available".
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength:=1
+	Type string `json:"type"`
+	// Status is the status the condition must have. When this field is not specified, the status must be True.
+	// +kubebuilder:validation:Enum=True;False;Unknown
+	// +optional
+	Status metav1.ConditionStatus `json:"status,omitempty"`
+	// MinDuration is the duration for which the condition must have had the status,
+	// according to its lastTransitionTime, e.g. "5m".
+	// +optional
+	MinDuration *metav1.Duration `json:"minDuration,omitempty"`
+}
+
+// QueryAccess queries whether the service account of the Capability is allowed to perform an action on a resource.
+type QueryAccess struct {
+	// Name is the unique name of the query.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength:=1
+	Name string `json:"name"`
+	// Verb is the API verb, e.g. "get", "list" or "create".
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength:=1
+	Verb string `json:"verb"`
+	// Group is the API group of the resource. The empty string is the core API group.
+	// +optional
+	Group string `json:"group,omitempty"`
+	// Version is the API version of the resource.
+	// +optional
+	Version string `json:"version,omitempty"`
+	// Resource is the API resource, e.g. "secrets".
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength:=1
+	Resource string `json:"resource"`
+	// Subresource is the subresource of the resource, e.g. "status".
+	// +optional
+	Subresource string `json:"subresource,omitempty"`
+	// ResourceName is the name of the object. When this field is not specified, the access to all objects is checked.
+	// +optional
+	ResourceName string `json:"resourceName,omitempty"`
+	// Namespace is the namespace of the resource. When this field is not specified,
+	// the access in all namespaces is checked for namespaced resources.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// QueryAPIService queries for the availability of the APIService that serves a group version. Unlike a GVR query,
+// which succeeds when the group version is listed in discovery, it fails when the APIService is not Available,
+// e.g. because the extension API server of an aggregated API is down.
+type QueryAPIService struct {
+	// Name is the unique name of the query.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength:=1
+	Name string `json:"name"`
+	// Group is the API group served by the APIService. The empty string is the core API group.
+	// +optional
+	Group string `json:"group,omitempty"`
+	// Version is the API version served by the APIService.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength:=1
+	Version string `json:"version"`
+}
+
+// QueryCustomResourceDefinition queries for the state of a CustomResourceDefinition: that it is Established, which
+// versions are served and deprecated, which version is the storage version and whether objects may still be stored in
+// other versions. Unlike a GVR query, it tells whether a storage version migration is pending before switching APIs.
+type QueryCustomResourceDefinition struct {
+	// Name is the unique name of the query.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength:=1
+	Name string `json:"name"`
+	// CRDName is the name of the CustomResourceDefinition, e.g. clusters.cluster.x-k8s.io.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength:=1
+	CRDName string `json:"crdName"`
+	// ServedVersions are the versions that must be served.
+	// +optional
+	ServedVersions []string `json:"servedVersions,omitempty"`
+	// StorageVersion is the version that must be the storage version.
+	// +optional
+	StorageVersion string `json:"storageVersion,omitempty"`
+	// DeprecatedVersions are the versions that must be marked deprecated.
+	// +optional
+	DeprecatedVersions []string `json:"deprecatedVersions,omitempty"`
+	// NotDeprecatedVersions are the versions that must not be marked deprecated.
+	// +optional
+	NotDeprecatedVersions []string `json:"notDeprecatedVersions,omitempty"`
+	// NoPendingStorageMigration requires status.storedVersions to list only the storage version, i.e. no objects
+	// remain stored in a previous storage version.
+	// +optional
+	NoPendingStorageMigration bool `json:"noPendingStorageMigration,omitempty"`
+}
+
+// QueryNodes queries for the topology of the nodes of a cluster, e.g. that all nodes are amd64, or that there is a
+// Windows node pool.
+type QueryNodes struct {
+	// Name is the unique name of the query.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength:=1
+	Name string `json:"name"`
+	// LabelSelector restricts the query to the nodes that match it, e.g. a node pool.
+	// +optional
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+	// Match is how many of the nodes must satisfy the constraints: all of them, or any of them.
+	// When this field is not specified, all the nodes must satisfy the constraints.
+	// +kubebuilder:validation:Enum=all;any
+	// +optional
+	Match NodeMatch `json:"match,omitempty"`
+	// MinCount is the minimum number of nodes that must satisfy the constraints. It defaults to one.
+	// +kubebuilder:validation:Minimum:=0
+	// +optional
+	MinCount *int32 `json:"minCount,omitempty"`
+	// MaxCount is the maximum number of nodes that may satisfy the constraints.
+	// +kubebuilder:validation:Minimum:=0
+	// +optional
+	MaxCount *int32 `json:"maxCount,omitempty"`
+	// RequiredLabels must match the labels of the nodes, e.g. a DoesNotExist requirement on nvidia.com/gpu.present for
+	// nodes without GPUs.
+	// +optional
+	RequiredLabels *metav1.LabelSelector `json:"requiredLabels,omitempty"`
+	// Architectures are the allowed architectures of the nodes, e.g. amd64 or arm64.
+	// +optional
+	Architectures []string `json:"architectures,omitempty"`
+	// OperatingSystems are the allowed operating systems of the nodes, e.g. linux or windows.
+	// +optional
+	OperatingSystems []string `json:"operatingSystems,omitempty"`
+	// ContainerRuntimes are the allowed container runtimes of the nodes, e.g. containerd.
+	// +optional
+	ContainerRuntimes []string `json:"containerRuntimes,omitempty"`
+	// Taints are the taints the nodes must have. An empty effect matches any effect.
+	// +optional
+	Taints []NodeTaint `json:"taints,omitempty"`
+	// WithoutTaints are the taints the nodes must not have. An empty effect matches any effect.
+	// +optional
+	WithoutTaints []NodeTaint `json:"withoutTaints,omitempty"`
+}
+
+// NodeMatch is how many of the nodes of a Nodes query must satisfy its constraints.
+type NodeMatch string
+
+const (
+	// NodeMatchAll requires all the nodes to satisfy the constraints.
+	NodeMatchAll = NodeMatch("all")
+	// NodeMatchAny requires any node to satisfy the constraints.
+	NodeMatchAny = NodeMatch("any")
+)
+
+// NodeTaint is a taint of a node, matched by key and effect.
+type NodeTaint struct {
+	// Key is the key of the taint.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength:=1
+	Key string `json:"key"`
+	// Effect is the effect of the taint. When this field is not specified, any effect matches.
+	// +kubebuilder:validation:Enum=NoSchedule;PreferNoSchedule;NoExecute
+	// +optional
+	Effect corev1.TaintEffect `json:"effect,omitempty"`
+}
+
+// QueryDataKey queries for a key in the data of a ConfigMap or a Secret, e.g. the cluster type in the metadata.yaml key
+// of the tkg-metadata ConfigMap. Without a value or a regular expression, the key, or the path into its value, must
+// exist.
+type QueryDataKey struct {
+	// Name is the unique name of the query.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength:=1
+	Name string `json:"name"`
+	// Kind is the kind of the object holding the data, ConfigMap or Secret.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=ConfigMap;Secret
+	Kind DataKind `json:"kind"`
+	// Namespace is the namespace of the object.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength:=1
+	Namespace string `json:"namespace"`
+	// ObjectName is the name of the object.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength:=1
+	ObjectName string `json:"objectName"`
+	// Key is the key in the data of the object. The binary data of ConfigMaps is also looked up.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength:=1
+	Key string `json:"key"`
+	// Path is a JSONPath into the value of the key, which must then be a YAML or JSON document, e.g. "cluster.type".
+	// +optional
+	Path string `json:"path,omitempty"`
+	// Value must equal the value of the key, or a value found at Path.
+	// +optional
+	Value *string `json:"value,omitempty"`
+	// Regex is a regular expression that must match the value of the key, or a value found at Path.
+	// +optional
+	Regex string `json:"regex,omitempty"`
+}
+
+// DataKind is the kind of the object holding the data of a DataKey query.
+type DataKind string
+
+const (
+	// DataKindConfigMap is the kind of the ConfigMaps.
+	DataKindConfigMap = DataKind("ConfigMap")
+	// DataKindSecret is the kind of the Secrets.
+	DataKindSecret = DataKind("Secret")
+)
+
+// OpenAPIVersion is the version of the OpenAPI documents served by a cluster.
+type OpenAPIVersion string
+
+const (
+	// OpenAPIV2 is the OpenAPI v2 document served at /openapi/v2.
+	OpenAPIV2 = OpenAPIVersion("v2")
+	// OpenAPIV3 is the set of OpenAPI v3 documents served at /openapi/v3.
+	OpenAPIV3 = OpenAPIVersion("v3")
+)
+
 // CapabilityStatus defines the observed state of Capability
 type CapabilityStatus struct {
 	// Results represents the results of all the queries specified in the spec.
 	// +listType=map
 	// +listMapKey=name
 	Results []Result `json:"results"`
+	// ObservedGeneration is the generation of the spec the results were evaluated for.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// LastEvaluationTime is when the queries were last evaluated.
+	// +optional
+	LastEvaluationTime *metav1.Time `json:"lastEvaluationTime,omitempty"`
+	// Conditions are the Ready and Degraded conditions of the Capability.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
+const (
+	// CapabilityReadyCondition is True when all the queries of a Capability are satisfied.
+	CapabilityReadyCondition = "Ready"
+	// CapabilityDegradedCondition is True when some queries of a Capability could not be evaluated, e.g. because the
+	// service account is not allowed to run them.
+	CapabilityDegradedCondition = "Degraded"
+)
+
+const (
+	// CapabilityQueriesSatisfiedReason is the reason of the Ready condition when all the queries are satisfied.
+	CapabilityQueriesSatisfiedReason = "QueriesSatisfied"
+	// CapabilityQueriesUnsatisfiedReason is the reason of the Ready condition when some queries are not satisfied.
+	CapabilityQueriesUnsatisfiedReason = "QueriesUnsatisfied"
+	// CapabilityQueriesEvaluatedReason is the reason of the Degraded condition when all the queries were evaluated.
+	CapabilityQueriesEvaluatedReason = "QueriesEvaluated"
+	// CapabilityQueryErrorsReason is the reason of the Degraded condition when some queries failed to be evaluated.
+	CapabilityQueryErrorsReason = "QueryErrors"
+	// CapabilityEvaluationFailedReason is the reason of the conditions when no query could be evaluated, e.g. because
+	// the service account of the Capability does not exist.
+	CapabilityEvaluationFailedReason = "EvaluationFailed"
+)
+
 // QueryResult represents the result of a single query.
 type QueryResult struct {
 	// Name is the name of the query in spec whose result this struct represents.
@@ -118,18 +577,63 @@ type QueryResult struct {
 	// ErrorDetail represents the error detail, if an error occurred.
 	// +optional
 	ErrorDetail string `json:"errorDetail,omitempty"`
+	// ErrorClass classifies the error, if an error occurred.
+	// +optional
+	ErrorClass QueryErrorClass `json:"errorClass,omitempty"`
 	// NotFoundReason provides the reason if the query condition fails.
 	// This is non-empty when Found is false.
 	// +optional
 	NotFoundReason string `json:"notFoundReason,omitempty"`
+	// UnmatchedGVRs lists the group versions and group version resources that were not found, for GVR queries.
+	// +optional
+	UnmatchedGVRs []string `json:"unmatchedGVRs,omitempty"`
+	// UnmatchedAnnotations lists the keys of the annotations that were missing, unexpected or had a different value,
+	// for Object queries.
+	// +optional
+	UnmatchedAnnotations []string `json:"unmatchedAnnotations,omitempty"`
+	// UnmatchedPredicates lists the field predicates that did not match, for Object queries.
+	// +optional
+	UnmatchedPredicates []string `json:"unmatchedPredicates,omitempty"`
+	// UnmatchedField is the first field of the partial schema that did not match, for PartialSchema queries.
+	// +optional
+	UnmatchedField string `json:"unmatchedField,omitempty"`
+	// ObjectReference is the object that was looked up, for Object, StatusCondition, APIService,
+	// CustomResourceDefinition and DataKey queries.
+	// +optional
+	ObjectReference *corev1.ObjectReference `json:"objectReference,omitempty"`
+	// Duration is how long the query took to evaluate.
+	// +optional
+	Duration *metav1.Duration `json:"duration,omitempty"`
 }
 
+// QueryErrorClass classifies the error of a query.
+// +kubebuilder:validation:Enum=NotFound;Forbidden;Unauthorized;Timeout;Canceled;Unknown
+type QueryErrorClass string
+
+const (
+	// QueryErrorClassNotFound means the resource or kind of the query is not served by the cluster.
+	QueryErrorClassNotFound QueryErrorClass = "NotFound"
+	// QueryErrorClassForbidden means the service account is not allowed to evaluate the query.
+	QueryErrorClassForbidden QueryErrorClass = "Forbidden"
+	// QueryErrorClassUnauthorized means the service account could not be authenticated.
+	QueryErrorClassUnauthorized QueryErrorClass = "Unauthorized"
+	// QueryErrorClassTimeout means the query did not complete in time.
+	QueryErrorClassTimeout QueryErrorClass = "Timeout"
+	// QueryErrorClassCanceled means the query was canceled.
+	QueryErrorClassCanceled QueryErrorClass = "Canceled"
+	// QueryErrorClassUnknown is any other error.
+	QueryErrorClassUnknown QueryErrorClass = "Unknown"
+)
+
 // Result represents the results of queries in Query.
 type Result struct {
 	// Name is the unique name of the query.
 	// +kubebuilder:validation:Required
 	// +kubebuilder:validation:MinLength:=1
 	Name string `json:"name"`
+	// Satisfied is true if all the queries of the query succeeded, i.e. they were found without errors.
+	// +optional
+	Satisfied bool `json:"satisfied"`
 	// GroupVersionResources represents results of GVR queries in spec.
 	// +listType=map
 	// +listMapKey=name
@@ -145,11 +649,62 @@ type Result struct {
 	// +listMapKey=name
 	// +optional
 	PartialSchemas []QueryResult `json:"partialSchemas,omitempty"`
+	// ServerVersions represents results of ServerVersion queries in spec.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	ServerVersions []QueryResult `json:"serverVersions,omitempty"`
+	// StatusConditions represents results of StatusCondition queries in spec.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	StatusConditions []QueryResult `json:"statusConditions,omitempty"`
+	// AccessChecks represents results of Access queries in spec.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	AccessChecks []QueryResult `json:"accessChecks,omitempty"`
+	// APIServices represents results of APIService queries in spec.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	APIServices []QueryResult `json:"apiServices,omitempty"`
+	// CustomResourceDefinitions represents results of CustomResourceDefinition queries in spec.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	CustomResourceDefinitions []QueryResult `json:"customResourceDefinitions,omitempty"`
+	// Nodes represents results of Nodes queries in spec.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Nodes []QueryResult `json:"nodes,omitempty"`
+	// DataKeys represents results of DataKey queries in spec. The values of Secrets are redacted.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	DataKeys []QueryResult `json:"dataKeys,omitempty"`
+	// AnyOf represents results of AnyOf queries in spec.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	AnyOf []QueryResult `json:"anyOf,omitempty"`
+	// Not represents results of Not queries in spec.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Not []QueryResult `json:"not,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
 //+kubebuilder:storageversion
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Degraded",type=string,JSONPath=`.status.conditions[?(@.type=="Degraded")].status`
+//+kubebuilder:printcolumn:name="Queries",priority=1,type=string,JSONPath=`.status.results[*].name`
+//+kubebuilder:printcolumn:name="Satisfied",priority=1,type=string,JSONPath=`.status.results[*].satisfied`
+//+kubebuilder:printcolumn:name="Last Evaluated",type=date,JSONPath=`.status.lastEvaluationTime`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // Capability is the Schema for the capabilities API
 type Capability struct {
@@ -69,6 +69,10 @@ type ReadinessProviderCondition struct {
 	// ResourceExistenceCondition is the condition that checks for the presence of a certain resource in the cluster
 	//+kubebuilder:validation:Optional
 	ResourceExistenceCondition *ResourceExistenceCondition `json:"resourceExistenceCondition"`
+
+	// ResourceStatusCondition is the condition that checks for a status condition of a certain resource in the cluster
+	//+kubebuilder:validation:Optional
+	ResourceStatusCondition *ResourceStatusCondition `json:"resourceStatusCondition,omitempty"`
 }
 
 // ResourceExistenceCondition is a type of readiness provider condition that checks for existence of given resource
@@ -89,6 +93,36 @@ type ResourceExistenceCondition struct {
 	Name      string  `json:"name"`
 }
 
+// ResourceStatusCondition is a type of readiness provider condition that checks for a condition in the
+// status.conditions of given resource, e.g. Ready=True
+type ResourceStatusCondition struct {
+	// APIVersion is the API version of the resource that is being checked.
+	// This should be provided in <group>/<version> format.
+	APIVersion string `json:"apiVersion"`
+
+	// Kind is the API kind of the resource that is being checked
+	Kind string `json:"kind"`
+
+	// Namespace is the namespace of the resource that is being checked; if the Namespace is nil,
+	// the resource is assumed to be cluster scoped.
+	//+kubebuilder:validation:Optional
+	Namespace *string `json:"namespace"`
+	Name      string  `json:"name"`
+
+	// Type is the type of the condition, e.g. "Ready" or "Available"
+	Type string `json:"type"`
+
+	// Status is the status the condition must have. When this field is not specified, the status must be True.
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Enum=True;False;Unknown
+	Status metav1.ConditionStatus `json:"status,omitempty"`
+
+	// MinDuration is the duration for which the condition must have had the status, e.g. "5m".
+	// The readiness condition is in progress until then.
+	//+kubebuilder:validation:Optional
+	MinDuration *metav1.Duration `json:"minDuration,omitempty"`
+}
+
 // ReadinessProviderStatus defines the observed state of ReadinessProvider
 type ReadinessProviderStatus struct {
 	// State is the computed state of the provider. The state will be success if all the conditions pass;
@@ -127,7 +127,7 @@ func (r *ReadinessProvider) validateObject(ctx context.Context, k8sClient client
 
 	// Validate conditions
 	for _, condition := range r.Spec.Conditions {
-		if condition.ResourceExistenceCondition == nil {
+		if (condition.ResourceExistenceCondition == nil) == (condition.ResourceStatusCondition == nil) {
 			allErrors = append(
 				allErrors,
 				field.Invalid(
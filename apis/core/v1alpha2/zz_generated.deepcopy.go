@@ -9,6 +9,8 @@
 package v1alpha2
 
 import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -81,6 +83,11 @@ func (in *CapabilitySpec) DeepCopyInto(out *CapabilitySpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ReevaluationInterval != nil {
+		in, out := &in.ReevaluationInterval, &out.ReevaluationInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CapabilitySpec.
@@ -103,6 +110,17 @@ func (in *CapabilityStatus) DeepCopyInto(out *CapabilityStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.LastEvaluationTime != nil {
+		in, out := &in.LastEvaluationTime, &out.LastEvaluationTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CapabilityStatus.
@@ -368,6 +386,41 @@ func (in *FeatureStatus) DeepCopy() *FeatureStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FieldPredicate) DeepCopyInto(out *FieldPredicate) {
+	*out = *in
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FieldPredicate.
+func (in *FieldPredicate) DeepCopy() *FieldPredicate {
+	if in == nil {
+		return nil
+	}
+	out := new(FieldPredicate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeTaint) DeepCopyInto(out *NodeTaint) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeTaint.
+func (in *NodeTaint) DeepCopy() *NodeTaint {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeTaint)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Policy) DeepCopyInto(out *Policy) {
 	*out = *in
@@ -418,8 +471,67 @@ func (in *Query) DeepCopyInto(out *Query) {
 	if in.PartialSchemas != nil {
 		in, out := &in.PartialSchemas, &out.PartialSchemas
 		*out = make([]QueryPartialSchema, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ServerVersions != nil {
+		in, out := &in.ServerVersions, &out.ServerVersions
+		*out = make([]QueryServerVersion, len(*in))
+		copy(*out, *in)
+	}
+	if in.StatusConditions != nil {
+		in, out := &in.StatusConditions, &out.StatusConditions
+		*out = make([]QueryStatusCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AccessChecks != nil {
+		in, out := &in.AccessChecks, &out.AccessChecks
+		*out = make([]QueryAccess, len(*in))
+		copy(*out, *in)
+	}
+	if in.APIServices != nil {
+		in, out := &in.APIServices, &out.APIServices
+		*out = make([]QueryAPIService, len(*in))
 		copy(*out, *in)
 	}
+	if in.CustomResourceDefinitions != nil {
+		in, out := &in.CustomResourceDefinitions, &out.CustomResourceDefinitions
+		*out = make([]QueryCustomResourceDefinition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Nodes != nil {
+		in, out := &in.Nodes, &out.Nodes
+		*out = make([]QueryNodes, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DataKeys != nil {
+		in, out := &in.DataKeys, &out.DataKeys
+		*out = make([]QueryDataKey, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AnyOf != nil {
+		in, out := &in.AnyOf, &out.AnyOf
+		*out = make([]QueryCombination, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Not != nil {
+		in, out := &in.Not, &out.Not
+		*out = make([]QueryCombination, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Query.
@@ -432,6 +544,165 @@ func (in *Query) DeepCopy() *Query {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QueryAPIService) DeepCopyInto(out *QueryAPIService) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QueryAPIService.
+func (in *QueryAPIService) DeepCopy() *QueryAPIService {
+	if in == nil {
+		return nil
+	}
+	out := new(QueryAPIService)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QueryAccess) DeepCopyInto(out *QueryAccess) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QueryAccess.
+func (in *QueryAccess) DeepCopy() *QueryAccess {
+	if in == nil {
+		return nil
+	}
+	out := new(QueryAccess)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QueryCombination) DeepCopyInto(out *QueryCombination) {
+	*out = *in
+	if in.GroupVersionResources != nil {
+		in, out := &in.GroupVersionResources, &out.GroupVersionResources
+		*out = make([]QueryGVR, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Objects != nil {
+		in, out := &in.Objects, &out.Objects
+		*out = make([]QueryObject, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PartialSchemas != nil {
+		in, out := &in.PartialSchemas, &out.PartialSchemas
+		*out = make([]QueryPartialSchema, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ServerVersions != nil {
+		in, out := &in.ServerVersions, &out.ServerVersions
+		*out = make([]QueryServerVersion, len(*in))
+		copy(*out, *in)
+	}
+	if in.StatusConditions != nil {
+		in, out := &in.StatusConditions, &out.StatusConditions
+		*out = make([]QueryStatusCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AccessChecks != nil {
+		in, out := &in.AccessChecks, &out.AccessChecks
+		*out = make([]QueryAccess, len(*in))
+		copy(*out, *in)
+	}
+	if in.APIServices != nil {
+		in, out := &in.APIServices, &out.APIServices
+		*out = make([]QueryAPIService, len(*in))
+		copy(*out, *in)
+	}
+	if in.CustomResourceDefinitions != nil {
+		in, out := &in.CustomResourceDefinitions, &out.CustomResourceDefinitions
+		*out = make([]QueryCustomResourceDefinition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Nodes != nil {
+		in, out := &in.Nodes, &out.Nodes
+		*out = make([]QueryNodes, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DataKeys != nil {
+		in, out := &in.DataKeys, &out.DataKeys
+		*out = make([]QueryDataKey, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QueryCombination.
+func (in *QueryCombination) DeepCopy() *QueryCombination {
+	if in == nil {
+		return nil
+	}
+	out := new(QueryCombination)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QueryCustomResourceDefinition) DeepCopyInto(out *QueryCustomResourceDefinition) {
+	*out = *in
+	if in.ServedVersions != nil {
+		in, out := &in.ServedVersions, &out.ServedVersions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DeprecatedVersions != nil {
+		in, out := &in.DeprecatedVersions, &out.DeprecatedVersions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NotDeprecatedVersions != nil {
+		in, out := &in.NotDeprecatedVersions, &out.NotDeprecatedVersions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QueryCustomResourceDefinition.
+func (in *QueryCustomResourceDefinition) DeepCopy() *QueryCustomResourceDefinition {
+	if in == nil {
+		return nil
+	}
+	out := new(QueryCustomResourceDefinition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QueryDataKey) DeepCopyInto(out *QueryDataKey) {
+	*out = *in
+	if in.Value != nil {
+		in, out := &in.Value, &out.Value
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QueryDataKey.
+func (in *QueryDataKey) DeepCopy() *QueryDataKey {
+	if in == nil {
+		return nil
+	}
+	out := new(QueryDataKey)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *QueryGVR) DeepCopyInto(out *QueryGVR) {
 	*out = *in
@@ -452,10 +723,85 @@ func (in *QueryGVR) DeepCopy() *QueryGVR {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QueryNodes) DeepCopyInto(out *QueryNodes) {
+	*out = *in
+	if in.LabelSelector != nil {
+		in, out := &in.LabelSelector, &out.LabelSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MinCount != nil {
+		in, out := &in.MinCount, &out.MinCount
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxCount != nil {
+		in, out := &in.MaxCount, &out.MaxCount
+		*out = new(int32)
+		**out = **in
+	}
+	if in.RequiredLabels != nil {
+		in, out := &in.RequiredLabels, &out.RequiredLabels
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Architectures != nil {
+		in, out := &in.Architectures, &out.Architectures
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.OperatingSystems != nil {
+		in, out := &in.OperatingSystems, &out.OperatingSystems
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ContainerRuntimes != nil {
+		in, out := &in.ContainerRuntimes, &out.ContainerRuntimes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Taints != nil {
+		in, out := &in.Taints, &out.Taints
+		*out = make([]NodeTaint, len(*in))
+		copy(*out, *in)
+	}
+	if in.WithoutTaints != nil {
+		in, out := &in.WithoutTaints, &out.WithoutTaints
+		*out = make([]NodeTaint, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QueryNodes.
+func (in *QueryNodes) DeepCopy() *QueryNodes {
+	if in == nil {
+		return nil
+	}
+	out := new(QueryNodes)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *QueryObject) DeepCopyInto(out *QueryObject) {
 	*out = *in
 	out.ObjectReference = in.ObjectReference
+	if in.LabelSelector != nil {
+		in, out := &in.LabelSelector, &out.LabelSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MinCount != nil {
+		in, out := &in.MinCount, &out.MinCount
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxCount != nil {
+		in, out := &in.MaxCount, &out.MaxCount
+		*out = new(int32)
+		**out = **in
+	}
 	if in.WithAnnotations != nil {
 		in, out := &in.WithAnnotations, &out.WithAnnotations
 		*out = make(map[string]string, len(*in))
@@ -470,6 +816,13 @@ func (in *QueryObject) DeepCopyInto(out *QueryObject) {
 			(*out)[key] = val
 		}
 	}
+	if in.WithFieldPredicates != nil {
+		in, out := &in.WithFieldPredicates, &out.WithFieldPredicates
+		*out = make([]FieldPredicate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QueryObject.
@@ -485,6 +838,11 @@ func (in *QueryObject) DeepCopy() *QueryObject {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *QueryPartialSchema) DeepCopyInto(out *QueryPartialSchema) {
 	*out = *in
+	if in.OpenAPIV3Paths != nil {
+		in, out := &in.OpenAPIV3Paths, &out.OpenAPIV3Paths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QueryPartialSchema.
@@ -500,6 +858,31 @@ func (in *QueryPartialSchema) DeepCopy() *QueryPartialSchema {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *QueryResult) DeepCopyInto(out *QueryResult) {
 	*out = *in
+	if in.UnmatchedGVRs != nil {
+		in, out := &in.UnmatchedGVRs, &out.UnmatchedGVRs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.UnmatchedAnnotations != nil {
+		in, out := &in.UnmatchedAnnotations, &out.UnmatchedAnnotations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.UnmatchedPredicates != nil {
+		in, out := &in.UnmatchedPredicates, &out.UnmatchedPredicates
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ObjectReference != nil {
+		in, out := &in.ObjectReference, &out.ObjectReference
+		*out = new(corev1.ObjectReference)
+		**out = **in
+	}
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(v1.Duration)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QueryResult.
@@ -512,6 +895,42 @@ func (in *QueryResult) DeepCopy() *QueryResult {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QueryServerVersion) DeepCopyInto(out *QueryServerVersion) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QueryServerVersion.
+func (in *QueryServerVersion) DeepCopy() *QueryServerVersion {
+	if in == nil {
+		return nil
+	}
+	out := new(QueryServerVersion)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QueryStatusCondition) DeepCopyInto(out *QueryStatusCondition) {
+	*out = *in
+	out.ObjectReference = in.ObjectReference
+	if in.MinDuration != nil {
+		in, out := &in.MinDuration, &out.MinDuration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QueryStatusCondition.
+func (in *QueryStatusCondition) DeepCopy() *QueryStatusCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(QueryStatusCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Readiness) DeepCopyInto(out *Readiness) {
 	*out = *in
@@ -621,6 +1040,11 @@ func (in *ReadinessProviderCondition) DeepCopyInto(out *ReadinessProviderConditi
 		*out = new(ResourceExistenceCondition)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ResourceStatusCondition != nil {
+		in, out := &in.ResourceStatusCondition, &out.ResourceStatusCondition
+		*out = new(ResourceStatusCondition)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReadinessProviderCondition.
@@ -779,23 +1203,117 @@ func (in *ResourceExistenceCondition) DeepCopy() *ResourceExistenceCondition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceStatusCondition) DeepCopyInto(out *ResourceStatusCondition) {
+	*out = *in
+	if in.Namespace != nil {
+		in, out := &in.Namespace, &out.Namespace
+		*out = new(string)
+		**out = **in
+	}
+	if in.MinDuration != nil {
+		in, out := &in.MinDuration, &out.MinDuration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceStatusCondition.
+func (in *ResourceStatusCondition) DeepCopy() *ResourceStatusCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceStatusCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Result) DeepCopyInto(out *Result) {
 	*out = *in
 	if in.GroupVersionResources != nil {
 		in, out := &in.GroupVersionResources, &out.GroupVersionResources
 		*out = make([]QueryResult, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	if in.Objects != nil {
 		in, out := &in.Objects, &out.Objects
 		*out = make([]QueryResult, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	if in.PartialSchemas != nil {
 		in, out := &in.PartialSchemas, &out.PartialSchemas
 		*out = make([]QueryResult, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ServerVersions != nil {
+		in, out := &in.ServerVersions, &out.ServerVersions
+		*out = make([]QueryResult, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.StatusConditions != nil {
+		in, out := &in.StatusConditions, &out.StatusConditions
+		*out = make([]QueryResult, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AccessChecks != nil {
+		in, out := &in.AccessChecks, &out.AccessChecks
+		*out = make([]QueryResult, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.APIServices != nil {
+		in, out := &in.APIServices, &out.APIServices
+		*out = make([]QueryResult, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.CustomResourceDefinitions != nil {
+		in, out := &in.CustomResourceDefinitions, &out.CustomResourceDefinitions
+		*out = make([]QueryResult, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Nodes != nil {
+		in, out := &in.Nodes, &out.Nodes
+		*out = make([]QueryResult, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DataKeys != nil {
+		in, out := &in.DataKeys, &out.DataKeys
+		*out = make([]QueryResult, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AnyOf != nil {
+		in, out := &in.AnyOf, &out.AnyOf
+		*out = make([]QueryResult, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Not != nil {
+		in, out := &in.Not, &out.Not
+		*out = make([]QueryResult, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 }
 
@@ -0,0 +1,228 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package discovery
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/restmapper"
+)
+
+// DefaultDiscoveryCacheTTL is the default duration for which a ClusterQueryClient caches discovery information,
+// REST mappings and OpenAPI definitions.
+const DefaultDiscoveryCacheTTL = 30 * time.Second
+
+// minMapperResetInterval is the minimum interval at which the REST mapper drops the cached discovery information when a
+// kind is not found, so that queries of kinds that are not served do not discover the cluster again every time.
+const minMapperResetInterval = 5 * time.Second
+
+// openAPIV2CacheKey is the key of the OpenAPI v2 definitions in the OpenAPI cache. OpenAPI v3 definitions are keyed by
+// the server relative URL of their document, which includes the document hash.
+const openAPIV2CacheKey = "v2"
+
+// ClusterQueryClientOption configures a ClusterQueryClient.
+type ClusterQueryClientOption func(*clusterQueryClientConfig)
+
+// WithDiscoveryCacheTTL sets how long discovery information, REST mappings and OpenAPI definitions are cached before
+// they are fetched from the API server again. A TTL of zero or less disables caching.
+func WithDiscoveryCacheTTL(ttl time.Duration) ClusterQueryClientOption {
+	return func(c *clusterQueryClientConfig) {
+		c.cacheTTL = ttl
+	}
+}
+
+// withClock sets the function used to read the current time, for tests.
+func withClock(now func() time.Time) ClusterQueryClientOption {
+	return func(c *clusterQueryClientConfig) {
+		c.now = now
+	}
+}
+
+// discovery returns the discovery client used by query targets, which is cached unless caching is disabled.
+func (c *clusterQueryClientConfig) discovery() discovery.DiscoveryInterface {
+	if c.cacheTTL <= 0 {
+		return c.discoveryClientset
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.expireLocked()
+	return c.cachedDiscovery
+}
+
+// restMapper returns a REST mapper over the cached discovery information. The mappings are built on first use and kept
+// until the cache expires or is invalidated, but the cluster is discovered again when a kind is not found, e.g. because
+// its CRD was installed since the discovery information was cached.
+func (c *clusterQueryClientConfig) restMapper() (meta.RESTMapper, error) {
+	if c.cacheTTL <= 0 {
+		groupResources, err := restmapper.GetAPIGroupResources(c.discoveryClientset)
+		if err != nil {
+			return nil, err
+		}
+		return restmapper.NewDiscoveryRESTMapper(groupResources), nil
+	}
+	mapper, err := c.cachedMapper()
+	if err != nil {
+		return nil, err
+	}
+	return &resettingRESTMapper{RESTMapper: mapper, config: c}, nil
+}
+
+// cachedMapper returns the REST mapper built from the cached discovery information, building it if needed. The mapper
+// is built without holding c.mu, so that discovering the cluster does not block the other queries of the client, and
+// is only kept if the cached discovery information was not dropped in the meantime.
+func (c *clusterQueryClientConfig) cachedMapper() (meta.RESTMapper, error) {
+	c.mu.Lock()
+	c.expireLocked()
+	mapper, cachedDiscovery, generation := c.mapper, c.cachedDiscovery, c.generation
+	c.mu.Unlock()
+	if mapper != nil {
+		return mapper, nil
+	}
+
+	groupResources, err := restmapper.GetAPIGroupResources(cachedDiscovery)
+	if err != nil {
+		return nil, err
+	}
+	mapper = restmapper.NewDiscoveryRESTMapper(groupResources)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation != generation {
+		return mapper, nil
+	}
+	// Another query may have built the mapper from the same discovery information.
+	if c.mapper == nil {
+		c.mapper = mapper
+	}
+	return c.mapper, nil
+}
+
+// resetMapper drops the cached discovery information and REST mappings, so that the cluster is discovered again, and
+// returns whether it did. It does so at most once every minMapperResetInterval.
+func (c *clusterQueryClientConfig) resetMapper() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	if now.Sub(c.mapperReset) < minMapperResetInterval {
+		return false
+	}
+	c.mapperReset = now
+	c.cachedDiscovery.Invalidate()
+	c.mapper = nil
+	c.generation++
+	return true
+}
+
+// resettingRESTMapper is a REST mapper over the cached discovery information, which discovers the cluster again and
+// tries once more when a kind or resource is not found.
+type resettingRESTMapper struct {
+	meta.RESTMapper
+	config *clusterQueryClientConfig
+}
+
+// retry calls f again with a REST mapper over newly discovered information if it returns a no match error.
+func (m *resettingRESTMapper) retry(f func(meta.RESTMapper) error) error {
+	err := f(m.RESTMapper)
+	if !meta.IsNoMatchError(err) || !m.config.resetMapper() {
+		return err
+	}
+	mapper, mapperErr := m.config.cachedMapper()
+	if mapperErr != nil {
+		return mapperErr
+	}
+	return f(mapper)
+}
+
+// KindFor implements meta.RESTMapper.
+func (m *resettingRESTMapper) KindFor(resource schema.GroupVersionResource) (gvk schema.GroupVersionKind, err error) {
+	err = m.retry(func(mapper meta.RESTMapper) error {
+		gvk, err = mapper.KindFor(resource)
+		return err
+	})
+	return gvk, err
+}
+
+// ResourceFor implements meta.RESTMapper.
+func (m *resettingRESTMapper) ResourceFor(input schema.GroupVersionResource) (gvr schema.GroupVersionResource, err error) {
+	err = m.retry(func(mapper meta.RESTMapper) error {
+		gvr, err = mapper.ResourceFor(input)
+		return err
+	})
+	return gvr, err
+}
+
+// RESTMapping implements meta.RESTMapper.
+func (m *resettingRESTMapper) RESTMapping(gk schema.GroupKind, versions ...string) (mapping *meta.RESTMapping, err error) {
+	err = m.retry(func(mapper meta.RESTMapper) error {
+		mapping, err = mapper.RESTMapping(gk, versions...)
+		return err
+	})
+	return mapping, err
+}
+
+// openAPIDefinitions returns the OpenAPI definitions cached under key, calling load to fetch them on a miss.
+func (c *clusterQueryClientConfig) openAPIDefinitions(key string, load func() (map[string]interface{}, error)) (map[string]interface{}, error) {
+	if c.cacheTTL <= 0 {
+		return load()
+	}
+
+	c.mu.Lock()
+	c.expireLocked()
+	defs, ok := c.definitions[key]
+	c.mu.Unlock()
+	if ok {
+		return defs, nil
+	}
+
+	// Documents are loaded without holding the lock, so concurrent misses may load the same document more than once.
+	defs, err := load()
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	if c.definitions == nil {
+		c.definitions = make(map[string]map[string]interface{})
+	}
+	c.definitions[key] = defs
+	c.mu.Unlock()
+	return defs, nil
+}
+
+// invalidate drops all the cached discovery information, REST mappings and OpenAPI definitions.
+func (c *clusterQueryClientConfig) invalidate() {
+	if c.cacheTTL <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.invalidateLocked()
+}
+
+// expireLocked invalidates the cache once its TTL has passed. The caller must hold c.mu.
+func (c *clusterQueryClientConfig) expireLocked() {
+	if c.now().Before(c.expiry) {
+		return
+	}
+	c.invalidateLocked()
+}
+
+func (c *clusterQueryClientConfig) invalidateLocked() {
+	c.cachedDiscovery.Invalidate()
+	c.mapper = nil
+	c.generation++
+	c.definitions = nil
+	c.expiry = c.now().Add(c.cacheTTL)
+}
+
+// newCachedDiscovery wraps a discovery client in a memory cache, unless it already caches.
+func newCachedDiscovery(d discovery.DiscoveryInterface) discovery.CachedDiscoveryInterface {
+	if cached, ok := d.(discovery.CachedDiscoveryInterface); ok {
+		return cached
+	}
+	return memory.NewMemCacheClient(d)
+}
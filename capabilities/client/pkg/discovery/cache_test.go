@@ -0,0 +1,172 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package discovery
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	dynamicFake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestDiscoveryCache(t *testing.T) {
+	newClient := func(opts ...ClusterQueryClientOption) (*ClusterQueryClient, *fakediscovery.FakeDiscovery) {
+		c, _ := newTestClusterQueryClient(t, apiResources, nil, testObjects, opts...)
+		return c, c.config.discoveryClientset.(*fakediscovery.FakeDiscovery)
+	}
+	execute := func(c *ClusterQueryClient) {
+		t.Helper()
+		got, err := c.Query(testGVR, testObject).Execute()
+		if err != nil || !got {
+			t.Fatalf("want: true, got: %t, %v", got, err)
+		}
+	}
+
+	testCases := []struct {
+		description string
+		opts        []ClusterQueryClientOption
+		between     func(c *ClusterQueryClient, now *time.Time)
+		refetch     bool
+	}{
+		{
+			description: "discovery is cached across queries",
+			between:     func(c *ClusterQueryClient, now *time.Time) {},
+			refetch:     false,
+		},
+		{
+			description: "invalidate drops the cache",
+			between:     func(c *ClusterQueryClient, now *time.Time) { c.Invalidate() },
+			refetch:     true,
+		},
+		{
+			description: "cache expires after the TTL",
+			opts:        []ClusterQueryClientOption{WithDiscoveryCacheTTL(time.Minute)},
+			between:     func(c *ClusterQueryClient, now *time.Time) { *now = now.Add(time.Minute) },
+			refetch:     true,
+		},
+		{
+			description: "cache is kept within the TTL",
+			opts:        []ClusterQueryClientOption{WithDiscoveryCacheTTL(time.Minute)},
+			between:     func(c *ClusterQueryClient, now *time.Time) { *now = now.Add(59 * time.Second) },
+			refetch:     false,
+		},
+		{
+			description: "zero TTL disables caching",
+			opts:        []ClusterQueryClientOption{WithDiscoveryCacheTTL(0)},
+			between:     func(c *ClusterQueryClient, now *time.Time) {},
+			refetch:     true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			now := time.Now()
+			opts := append([]ClusterQueryClientOption{withClock(func() time.Time { return now })}, tc.opts...)
+			c, fakeDiscovery := newClient(opts...)
+
+			execute(c)
+			first := len(fakeDiscovery.Actions())
+			tc.between(c, &now)
+			execute(c)
+			second := len(fakeDiscovery.Actions()) - first
+
+			if refetched := second != 0; refetched != tc.refetch {
+				t.Errorf("want refetch: %t, got %d discovery calls after %d", tc.refetch, second, first)
+			}
+		})
+	}
+}
+
+func TestRESTMapperDiscoversNewKinds(t *testing.T) {
+	configMaps := &metav1.APIResourceList{
+		GroupVersion: "v1",
+		APIResources: []metav1.APIResource{{Name: "configmaps", Kind: "ConfigMap", Version: "v1", Namespaced: true}},
+	}
+	now := time.Now()
+	c, _ := newTestClusterQueryClient(t, []*metav1.APIResourceList{configMaps}, nil, testObjects, withClock(func() time.Time { return now }))
+	fakeDiscovery := c.config.discoveryClientset.(*fakediscovery.FakeDiscovery)
+
+	execute := func() (bool, error) {
+		return c.Query(Object("carpObj", &carp)).Execute()
+	}
+	if _, err := execute(); err == nil || !strings.Contains(err.Error(), "no matches for kind") {
+		t.Fatalf("want a no match error before the kind is served, got: %v", err)
+	}
+
+	// The CRD of the kind is installed.
+	fakeDiscovery.Resources = append([]*metav1.APIResourceList{configMaps}, apiResources...)
+	now = now.Add(time.Second)
+	if _, err := execute(); err == nil || !strings.Contains(err.Error(), "no matches for kind") {
+		t.Errorf("want the cluster not to be discovered again within %s, got: %v", minMapperResetInterval, err)
+	}
+	now = now.Add(minMapperResetInterval)
+	if got, err := execute(); err != nil || !got {
+		t.Errorf("want the new kind to be discovered before the cache expires, got: %t, %v", got, err)
+	}
+}
+
+// blockingDiscovery is a discovery client whose first request for the API groups waits until it is released.
+type blockingDiscovery struct {
+	*fakediscovery.FakeDiscovery
+	once    sync.Once
+	started chan struct{}
+	release chan struct{}
+}
+
+func (d *blockingDiscovery) ServerGroups() (*metav1.APIGroupList, error) {
+	d.once.Do(func() {
+		close(d.started)
+		<-d.release
+	})
+	return d.FakeDiscovery.ServerGroups()
+}
+
+func TestRESTMapperIsBuiltOutsideTheCacheLock(t *testing.T) {
+	d := &blockingDiscovery{
+		FakeDiscovery: &fakediscovery.FakeDiscovery{Fake: &k8stesting.Fake{Resources: apiResources}},
+		started:       make(chan struct{}),
+		release:       make(chan struct{}),
+	}
+	c, err := NewClusterQueryClient(dynamicFake.NewSimpleDynamicClient(testScheme), d)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mapperErr := make(chan error, 1)
+	go func() {
+		_, err := c.config.restMapper()
+		mapperErr <- err
+	}()
+	<-d.started
+
+	done := make(chan struct{})
+	go func() {
+		c.config.discovery()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("want the cached discovery client while the REST mapper is built")
+	}
+
+	// The cached discovery information is dropped while the REST mapper is built.
+	c.config.mu.Lock()
+	c.config.generation++
+	c.config.mu.Unlock()
+	close(d.release)
+	if err := <-mapperErr; err != nil {
+		t.Fatal(err)
+	}
+	c.config.mu.Lock()
+	defer c.config.mu.Unlock()
+	if c.config.mapper != nil {
+		t.Error("want the REST mapper built before the cache was invalidated not to be kept")
+	}
+}
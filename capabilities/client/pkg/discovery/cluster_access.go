@@ -0,0 +1,136 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package discovery
+
+import (
+	"context"
+	"fmt"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// selfSubjectAccessReviews is the resource SelfSubjectAccessReviews are created with.
+var selfSubjectAccessReviews = authorizationv1.SchemeGroupVersion.WithResource("selfsubjectaccessreviews")
+
+// Access returns a query target that checks whether the client is allowed to perform the verb on the resource, e.g.
+// Access("list-secrets", "list", corev1.SchemeGroupVersion.WithResource("secrets"), "tkg-system"). An empty namespace
+// checks the access in all namespaces for namespaced resources. The check issues a SelfSubjectAccessReview, so it
+// reflects the permissions of the identity of the client, e.g. the service account of a Capability.
+func Access(name, verb string, gvr schema.GroupVersionResource, namespace string) *QueryAccess {
+	return &QueryAccess{
+		name:      name,
+		verb:      verb,
+		gvr:       gvr,
+		namespace: namespace,
+	}
+}
+
+// QueryAccess allows for querying whether an action on a resource is allowed.
+type QueryAccess struct {
+	name         string
+	verb         string
+	gvr          schema.GroupVersionResource
+	namespace    string
+	subresource  string
+	resourceName string
+
+	deniedReason string
+}
+
+// Name is the name of the query.
+func (q *QueryAccess) Name() string {
+	return q.name
+}
+
+// WithSubresource restricts the check to a subresource, e.g. "status".
+func (q *QueryAccess) WithSubresource(subresource string) *QueryAccess {
+	q.subresource = subresource
+	return q
+}
+
+// WithResourceName restricts the check to the object with the name.
+func (q *QueryAccess) WithResourceName(name string) *QueryAccess {
+	q.resourceName = name
+	return q
+}
+
+// Run the access query.
+func (q *QueryAccess) Run(config *clusterQueryClientConfig) (bool, error) {
+	return q.RunContext(context.Background(), config)
+}
+
+// RunContext runs the access query using the context for the API calls.
+func (q *QueryAccess) RunContext(ctx context.Context, config *clusterQueryClientConfig) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	q.deniedReason = ""
+
+	if err := q.validate(); err != nil {
+		return false, err
+	}
+
+	review := &authorizationv1.SelfSubjectAccessReview{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: authorizationv1.SchemeGroupVersion.String(),
+			Kind:       "SelfSubjectAccessReview",
+		},
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace:   q.namespace,
+				Verb:        q.verb,
+				Group:       q.gvr.Group,
+				Version:     q.gvr.Version,
+				Resource:    q.gvr.Resource,
+				Subresource: q.subresource,
+				Name:        q.resourceName,
+			},
+		},
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(review)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := config.dynamicClient.Resource(selfSubjectAccessReviews).Create(ctx, &unstructured.Unstructured{Object: content}, metav1.CreateOptions{})
+	if err != nil {
+		return false, fmt.Errorf("failed to create SelfSubjectAccessReview: %w", err)
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(result.UnstructuredContent(), review); err != nil {
+		return false, err
+	}
+
+	if !review.Status.Allowed {
+		q.deniedReason = review.Status.Reason
+		if review.Status.EvaluationError != "" {
+			q.deniedReason = review.Status.EvaluationError
+		}
+		return false, nil
+	}
+	return true, nil
+}
+
+// validate ensures the verb and resource are set.
+func (q *QueryAccess) validate() error {
+	if q.verb == "" {
+		return fmt.Errorf("access query %q requires a verb", q.name)
+	}
+	if q.gvr.Resource == "" {
+		return fmt.Errorf("access query %q requires a resource", q.name)
+	}
+	return nil
+}
+
+// Reason for failures, in a standard structure
+func (q *QueryAccess) Reason() string {
+	resource := q.gvr.GroupResource().String()
+	if q.subresource != "" {
+		resource += "/" + q.subresource
+	}
+	return fmt.Sprintf("method=access verb=%s resource=%s namespace=%s resourceName=%s reason=%q status=denied presence=true", q.verb, resource, q.namespace, q.resourceName, q.deniedReason)
+}
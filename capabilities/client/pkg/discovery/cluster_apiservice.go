@@ -0,0 +1,115 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package discovery
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// apiServices is the resource of the APIServices that register group versions with the aggregation layer.
+var apiServices = schema.GroupVersionResource{Group: "apiregistration.k8s.io", Version: "v1", Resource: "apiservices"}
+
+// apiServiceAvailable is the condition type of an APIService that is available.
+const apiServiceAvailable = "Available"
+
+// APIService returns a query target that checks that the APIService serving a group version is available, e.g.
+// APIService("metrics", schema.GroupVersion{Group: "metrics.k8s.io", Version: "v1beta1"}). Unlike a GVR query, which
+// only checks that the group version is listed in discovery, it fails when the extension API server behind an
+// aggregated API is unavailable.
+func APIService(name string, gv schema.GroupVersion) *QueryAPIService {
+	return &QueryAPIService{
+		name: name,
+		gv:   gv,
+	}
+}
+
+// QueryAPIService allows for querying the availability of the APIService of a group version.
+type QueryAPIService struct {
+	name string
+	gv   schema.GroupVersion
+
+	found             bool
+	observedCondition *metav1.Condition
+}
+
+// Name is the name of the query.
+func (q *QueryAPIService) Name() string {
+	return q.name
+}
+
+// Run the APIService query.
+func (q *QueryAPIService) Run(config *clusterQueryClientConfig) (bool, error) {
+	return q.RunContext(context.Background(), config)
+}
+
+// RunContext runs the APIService query using the context for the API calls.
+func (q *QueryAPIService) RunContext(ctx context.Context, config *clusterQueryClientConfig) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	q.found, q.observedCondition = false, nil
+
+	if err := q.validate(); err != nil {
+		return false, err
+	}
+
+	u, err := config.dynamicClient.Resource(apiServices).Get(ctx, q.apiServiceName(), metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get APIService %s: %w", q.apiServiceName(), err)
+	}
+	q.found = true
+
+	condition, err := findStatusCondition(u, apiServiceAvailable)
+	if err != nil {
+		return false, err
+	}
+	q.observedCondition = condition
+	return condition != nil && condition.Status == metav1.ConditionTrue, nil
+}
+
+// apiServiceName returns the name of the APIService of the group version, e.g. v1beta1.metrics.k8s.io.
+func (q *QueryAPIService) apiServiceName() string {
+	if q.gv.Group == "" {
+		return q.gv.Version
+	}
+	return q.gv.Version + "." + q.gv.Group
+}
+
+// object returns the reference of the APIService.
+func (q *QueryAPIService) object() *corev1.ObjectReference {
+	return &corev1.ObjectReference{
+		APIVersion: apiServices.GroupVersion().String(),
+		Kind:       "APIService",
+		Name:       q.apiServiceName(),
+	}
+}
+
+// validate ensures the version is set.
+func (q *QueryAPIService) validate() error {
+	if q.gv.Version == "" {
+		return fmt.Errorf("APIService query %q requires a version", q.name)
+	}
+	return nil
+}
+
+// Reason returns the status, reason and message of the Available condition of the APIService.
+func (q *QueryAPIService) Reason() string {
+	switch {
+	case !q.found:
+		return fmt.Sprintf("method=apiservice name=%s apiService=%s status=notFound presence=true", q.name, q.apiServiceName())
+	case q.observedCondition == nil:
+		return fmt.Sprintf("method=apiservice name=%s apiService=%s condition=%s status=unavailable presence=true", q.name, q.apiServiceName(), apiServiceAvailable)
+	}
+	return fmt.Sprintf("method=apiservice name=%s apiService=%s condition=%s=%s reason=%s message=%q status=unavailable presence=true",
+		q.name, q.apiServiceName(), apiServiceAvailable, q.observedCondition.Status, q.observedCondition.Reason, q.observedCondition.Message)
+}
@@ -0,0 +1,227 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// AllOf returns a query target that succeeds only if all the given targets succeed.
+func AllOf(name string, targets ...QueryTarget) *QueryAllOf {
+	return &QueryAllOf{
+		name:    name,
+		targets: targets,
+	}
+}
+
+// QueryAllOf is a query target that succeeds only if all of its targets succeed.
+type QueryAllOf struct {
+	name    string
+	targets []QueryTarget
+
+	unmatchedReasons []string
+}
+
+// Name is the name of the query.
+func (q *QueryAllOf) Name() string {
+	return q.name
+}
+
+// Run runs all the targets.
+func (q *QueryAllOf) Run(config *clusterQueryClientConfig) (bool, error) {
+	return q.RunContext(context.Background(), config)
+}
+
+// RunContext runs all the targets one after another. All the targets are run, even after one fails, so that the
+// reason lists every target that did not succeed.
+func (q *QueryAllOf) RunContext(ctx context.Context, config *clusterQueryClientConfig) (bool, error) {
+	q.unmatchedReasons = nil
+	if len(q.targets) == 0 {
+		return false, fmt.Errorf("allOf query %q requires at least one query target", q.name)
+	}
+
+	for _, t := range q.targets {
+		ok, err := t.RunContext(ctx, config)
+		if err != nil {
+			return false, fmt.Errorf("query %q failed: %w", t.Name(), err)
+		}
+		if !ok {
+			q.unmatchedReasons = append(q.unmatchedReasons, t.Reason())
+		}
+	}
+	return len(q.unmatchedReasons) == 0, nil
+}
+
+// Reason returns the reasons of all the targets that did not succeed.
+func (q *QueryAllOf) Reason() string {
+	return fmt.Sprintf("method=allOf name=%s status=unmatched reasons=[%s]", q.name, strings.Join(q.unmatchedReasons, "; "))
+}
+
+// AnyOf returns a query target that succeeds if at least one of the given targets succeeds, e.g. one of several API
+// versions of a resource exists.
+func AnyOf(name string, targets ...QueryTarget) *QueryAnyOf {
+	return &QueryAnyOf{
+		name:    name,
+		targets: targets,
+	}
+}
+
+// QueryAnyOf is a query target that succeeds if at least one of its targets succeeds.
+type QueryAnyOf struct {
+	name    string
+	targets []QueryTarget
+
+	unmatchedReasons []string
+}
+
+// Name is the name of the query.
+func (q *QueryAnyOf) Name() string {
+	return q.name
+}
+
+// Run runs the targets until one succeeds.
+func (q *QueryAnyOf) Run(config *clusterQueryClientConfig) (bool, error) {
+	return q.RunContext(context.Background(), config)
+}
+
+// RunContext runs the targets one after another until one succeeds. A target that fails with an error does not stop
+// the others; the errors are only returned when no target succeeds.
+func (q *QueryAnyOf) RunContext(ctx context.Context, config *clusterQueryClientConfig) (bool, error) {
+	q.unmatchedReasons = nil
+	if len(q.targets) == 0 {
+		return false, fmt.Errorf("anyOf query %q requires at least one query target", q.name)
+	}
+
+	var errs []error
+	for _, t := range q.targets {
+		ok, err := t.RunContext(ctx, config)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("query %q failed: %w", t.Name(), err))
+			continue
+		}
+		if ok {
+			q.unmatchedReasons = nil
+			return true, nil
+		}
+		q.unmatchedReasons = append(q.unmatchedReasons, t.Reason())
+	}
+	if len(errs) != 0 {
+		return false, kerrors.NewAggregate(errs)
+	}
+	return false, nil
+}
+
+// Reason returns the reasons of all the targets, none of which succeeded.
+func (q *QueryAnyOf) Reason() string {
+	return fmt.Sprintf("method=anyOf name=%s status=unmatched reasons=[%s]", q.name, strings.Join(q.unmatchedReasons, "; "))
+}
+
+// Not returns a query target that succeeds only if none of the given targets succeed, e.g. a namespace must not exist.
+// With a single target it is the negation of that target.
+func Not(name string, targets ...QueryTarget) *QueryNot {
+	return &QueryNot{
+		name:    name,
+		targets: targets,
+	}
+}
+
+// QueryNot is a query target that succeeds only if none of its targets succeed.
+type QueryNot struct {
+	name    string
+	targets []QueryTarget
+
+	matched []string
+}
+
+// Name is the name of the query.
+func (q *QueryNot) Name() string {
+	return q.name
+}
+
+// Run runs all the targets.
+func (q *QueryNot) Run(config *clusterQueryClientConfig) (bool, error) {
+	return q.RunContext(context.Background(), config)
+}
+
+// RunContext runs all the targets one after another so that the reason lists every target that succeeded. A target
+// whose resource or kind is not served by the cluster did not succeed, e.g. an object of a CRD that is not installed.
+func (q *QueryNot) RunContext(ctx context.Context, config *clusterQueryClientConfig) (bool, error) {
+	q.matched = nil
+	if len(q.targets) == 0 {
+		return false, fmt.Errorf("not query %q requires at least one query target", q.name)
+	}
+
+	for _, t := range q.targets {
+		ok, err := t.RunContext(ctx, config)
+		if err != nil && classifyError(err) == ErrorClassNotFound {
+			continue
+		}
+		if err != nil {
+			return false, fmt.Errorf("query %q failed: %w", t.Name(), err)
+		}
+		if ok {
+			q.matched = append(q.matched, t.Name())
+		}
+	}
+	return len(q.matched) == 0, nil
+}
+
+// Reason returns the names of the targets that succeeded.
+func (q *QueryNot) Reason() string {
+	return fmt.Sprintf("method=not name=%s matched=%v status=unmatched", q.name, q.matched)
+}
+
+// Renamed returns a query target that runs the given target under another name, e.g. to run query targets whose
+// names are only unique within a query of a Capability in a single ClusterQuery. The reason and the details of the
+// result are the ones of the target.
+func Renamed(name string, target QueryTarget) QueryTarget {
+	return &renamedTarget{name: name, target: target}
+}
+
+// renamedTarget is a query target that runs another target under another name.
+type renamedTarget struct {
+	name   string
+	target QueryTarget
+}
+
+// Name is the name of the query.
+func (q *renamedTarget) Name() string {
+	return q.name
+}
+
+// Run runs the target.
+func (q *renamedTarget) Run(config *clusterQueryClientConfig) (bool, error) {
+	return q.target.Run(config)
+}
+
+// RunContext runs the target.
+func (q *renamedTarget) RunContext(ctx context.Context, config *clusterQueryClientConfig) (bool, error) {
+	return q.target.RunContext(ctx, config)
+}
+
+// Reason returns the reason of the target.
+func (q *renamedTarget) Reason() string {
+	return q.target.Reason()
+}
+
+func (q *renamedTarget) addDetails(r *QueryResult) {
+	if d, ok := q.target.(resultDetailer); ok {
+		d.addDetails(r)
+	}
+}
+
+func (q *renamedTarget) validate() error {
+	if v, ok := q.target.(validator); ok {
+		return v.validate()
+	}
+	return nil
+}
+
+func (q *renamedTarget) watchedObjects() []watchedObject {
+	return watchedObjectsOf([]QueryTarget{q.target})
+}
@@ -0,0 +1,191 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// customResourceDefinitions is the resource of the CustomResourceDefinitions.
+var customResourceDefinitions = apiextensionsv1.SchemeGroupVersion.WithResource("customresourcedefinitions")
+
+// CustomResourceDefinition returns a query target that checks the state of a CustomResourceDefinition, e.g.
+// CustomResourceDefinition("clusters", "clusters.cluster.x-k8s.io").WithStorageVersion("v1beta1"). The CRD must be
+// Established. Unlike a GVR query, it can check which version objects are stored in, so that callers can tell whether
+// a storage version migration is pending before switching APIs.
+func CustomResourceDefinition(name, crdName string) *QueryCustomResourceDefinition {
+	return &QueryCustomResourceDefinition{
+		name:    name,
+		crdName: crdName,
+	}
+}
+
+// QueryCustomResourceDefinition allows for querying the state of a CustomResourceDefinition.
+type QueryCustomResourceDefinition struct {
+	name                      string
+	crdName                   string
+	servedVersions            []string
+	storageVersion            string
+	deprecatedVersions        []string
+	notDeprecatedVersions     []string
+	noPendingStorageMigration bool
+
+	found     bool
+	unmatched []string
+}
+
+// Name is the name of the query.
+func (q *QueryCustomResourceDefinition) Name() string {
+	return q.name
+}
+
+// WithServedVersions requires the versions to be served.
+func (q *QueryCustomResourceDefinition) WithServedVersions(versions ...string) *QueryCustomResourceDefinition {
+	q.servedVersions = append(q.servedVersions, versions...)
+	return q
+}
+
+// WithStorageVersion requires the version to be the storage version.
+func (q *QueryCustomResourceDefinition) WithStorageVersion(version string) *QueryCustomResourceDefinition {
+	q.storageVersion = version
+	return q
+}
+
+// WithDeprecatedVersions requires the versions to be marked deprecated.
+func (q *QueryCustomResourceDefinition) WithDeprecatedVersions(versions ...string) *QueryCustomResourceDefinition {
+	q.deprecatedVersions = append(q.deprecatedVersions, versions...)
+	return q
+}
+
+// WithNotDeprecatedVersions requires the versions to exist and not be marked deprecated.
+func (q *QueryCustomResourceDefinition) WithNotDeprecatedVersions(versions ...string) *QueryCustomResourceDefinition {
+	q.notDeprecatedVersions = append(q.notDeprecatedVersions, versions...)
+	return q
+}
+
+// WithNoPendingStorageMigration requires status.storedVersions to list only the storage version. Any other stored
+// version means objects may still be stored in it, and must be migrated before that version is removed.
+func (q *QueryCustomResourceDefinition) WithNoPendingStorageMigration() *QueryCustomResourceDefinition {
+	q.noPendingStorageMigration = true
+	return q
+}
+
+// Run the CustomResourceDefinition query.
+func (q *QueryCustomResourceDefinition) Run(config *clusterQueryClientConfig) (bool, error) {
+	return q.RunContext(context.Background(), config)
+}
+
+// RunContext runs the CustomResourceDefinition query using the context for the API calls.
+func (q *QueryCustomResourceDefinition) RunContext(ctx context.Context, config *clusterQueryClientConfig) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	q.found, q.unmatched = false, nil
+
+	if err := q.validate(); err != nil {
+		return false, err
+	}
+
+	u, err := config.dynamicClient.Resource(customResourceDefinitions).Get(ctx, q.crdName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get CustomResourceDefinition %s: %w", q.crdName, err)
+	}
+	q.found = true
+
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, crd); err != nil {
+		return false, fmt.Errorf("failed to convert CustomResourceDefinition %s: %w", q.crdName, err)
+	}
+	q.unmatched = q.unmatchedFields(crd)
+	return len(q.unmatched) == 0, nil
+}
+
+// unmatchedFields returns what does not match in the CRD, as key=value pairs of the observed state.
+func (q *QueryCustomResourceDefinition) unmatchedFields(crd *apiextensionsv1.CustomResourceDefinition) []string {
+	var unmatched []string
+
+	established := "Unknown"
+	for _, c := range crd.Status.Conditions {
+		if c.Type == apiextensionsv1.Established {
+			established = string(c.Status)
+		}
+	}
+	if established != string(apiextensionsv1.ConditionTrue) {
+		unmatched = append(unmatched, "established="+established)
+	}
+
+	versions := make(map[string]*apiextensionsv1.CustomResourceDefinitionVersion, len(crd.Spec.Versions))
+	storageVersion := ""
+	for i := range crd.Spec.Versions {
+		v := &crd.Spec.Versions[i]
+		versions[v.Name] = v
+		if v.Storage {
+			storageVersion = v.Name
+		}
+	}
+
+	for _, name := range q.servedVersions {
+		if v, ok := versions[name]; !ok || !v.Served {
+			unmatched = append(unmatched, "unserved="+name)
+		}
+	}
+	if q.storageVersion != "" && q.storageVersion != storageVersion {
+		unmatched = append(unmatched, "storageVersion="+storageVersion)
+	}
+	for _, name := range q.deprecatedVersions {
+		if v, ok := versions[name]; !ok || !v.Deprecated {
+			unmatched = append(unmatched, "notDeprecated="+name)
+		}
+	}
+	for _, name := range q.notDeprecatedVersions {
+		if v, ok := versions[name]; !ok {
+			unmatched = append(unmatched, "missing="+name)
+		} else if v.Deprecated {
+			unmatched = append(unmatched, "deprecated="+name)
+		}
+	}
+	if q.noPendingStorageMigration {
+		stored := crd.Status.StoredVersions
+		if len(stored) != 1 || stored[0] != storageVersion {
+			unmatched = append(unmatched, "storedVersions="+strings.Join(stored, ","))
+		}
+	}
+	return unmatched
+}
+
+// object returns the reference of the CustomResourceDefinition.
+func (q *QueryCustomResourceDefinition) object() *corev1.ObjectReference {
+	return &corev1.ObjectReference{
+		APIVersion: customResourceDefinitions.GroupVersion().String(),
+		Kind:       "CustomResourceDefinition",
+		Name:       q.crdName,
+	}
+}
+
+// validate ensures the name of the CRD is set.
+func (q *QueryCustomResourceDefinition) validate() error {
+	if q.crdName == "" {
+		return fmt.Errorf("CustomResourceDefinition query %q requires the name of a CustomResourceDefinition", q.name)
+	}
+	return nil
+}
+
+// Reason returns what did not match in the CustomResourceDefinition, e.g. "storedVersions=v1alpha4,v1beta1".
+func (q *QueryCustomResourceDefinition) Reason() string {
+	if !q.found {
+		return fmt.Sprintf("method=crd name=%s crd=%s status=notFound presence=true", q.name, q.crdName)
+	}
+	return fmt.Sprintf("method=crd name=%s crd=%s %s status=unmatched presence=true", q.name, q.crdName, strings.Join(q.unmatched, " "))
+}
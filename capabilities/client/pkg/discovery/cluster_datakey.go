@@ -0,0 +1,267 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package discovery
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// redactedValue replaces the values of Secrets in the reasons of data key queries.
+	redactedValue = "<redacted>"
+	// maxReasonValueLength is the maximum length of the observed value in the reason of a data key query.
+	maxReasonValueLength = 64
+)
+
+// DataKind is the kind of object holding the data of a data key query.
+type DataKind string
+
+const (
+	// DataKindConfigMap is the kind of the ConfigMaps.
+	DataKindConfigMap = DataKind("ConfigMap")
+	// DataKindSecret is the kind of the Secrets.
+	DataKindSecret = DataKind("Secret")
+)
+
+// ConfigMapKey returns a query target that checks a key of the data of a ConfigMap, e.g. that the metadata.yaml key
+// of the tkg-metadata ConfigMap has a cluster type with
+// ConfigMapKey("tkgType", "tkg-system-public", "tkg-metadata", "metadata.yaml").WithPath("cluster.type").
+// Without a value or a regular expression, the key (or the path into its value) must exist.
+func ConfigMapKey(name, namespace, configMapName, key string) *QueryDataKey {
+	return &QueryDataKey{
+		name:       name,
+		kind:       DataKindConfigMap,
+		namespace:  namespace,
+		objectName: configMapName,
+		key:        key,
+	}
+}
+
+// SecretKey returns a query target that checks a key of the data of a Secret, like ConfigMapKey. The values of the
+// Secret are redacted from the reason of the query.
+func SecretKey(name, namespace, secretName, key string) *QueryDataKey {
+	return &QueryDataKey{
+		name:       name,
+		kind:       DataKindSecret,
+		namespace:  namespace,
+		objectName: secretName,
+		key:        key,
+	}
+}
+
+// QueryDataKey allows for querying a key of the data of a ConfigMap or a Secret.
+type QueryDataKey struct {
+	name       string
+	kind       DataKind
+	namespace  string
+	objectName string
+	key        string
+	path       string
+	value      *string
+	regex      string
+
+	found    bool
+	keyFound bool
+	observed []string
+}
+
+// Name is the name of the query.
+func (q *QueryDataKey) Name() string {
+	return q.name
+}
+
+// WithPath checks the values found at a JSONPath in the value of the key, which must be a YAML or JSON document, e.g.
+// "cluster.type" or "{.cluster.infrastructure.provider}".
+func (q *QueryDataKey) WithPath(path string) *QueryDataKey {
+	q.path = path
+	return q
+}
+
+// WithValue requires the value of the key, or a value found at the path, to equal the value.
+func (q *QueryDataKey) WithValue(value string) *QueryDataKey {
+	q.value = &value
+	return q
+}
+
+// WithRegex requires the value of the key, or a value found at the path, to match the regular expression. The
+// expression is not anchored, use ^ and $ to match the whole value.
+func (q *QueryDataKey) WithRegex(regex string) *QueryDataKey {
+	q.regex = regex
+	return q
+}
+
+// Run the data key query.
+func (q *QueryDataKey) Run(config *clusterQueryClientConfig) (bool, error) {
+	return q.RunContext(context.Background(), config)
+}
+
+// RunContext runs the data key query using the context for the API calls.
+func (q *QueryDataKey) RunContext(ctx context.Context, config *clusterQueryClientConfig) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	q.found, q.keyFound, q.observed = false, false, nil
+
+	if err := q.validate(); err != nil {
+		return false, err
+	}
+	var re *regexp.Regexp
+	if q.regex != "" {
+		re = regexp.MustCompile(q.regex)
+	}
+
+	u, err := config.dynamicClient.Resource(q.resource()).Namespace(q.namespace).Get(ctx, q.objectName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get %s %s/%s: %w", q.kind, q.namespace, q.objectName, err)
+	}
+	q.found = true
+
+	data, ok, err := q.data(u)
+	if err != nil || !ok {
+		return false, err
+	}
+	q.keyFound = true
+
+	values := []string{data}
+	if q.path != "" {
+		if values, err = valuesAtDocumentPath(data, q.path); err != nil {
+			// Parse errors may quote the document, so they are dropped for Secrets.
+			if q.kind == DataKindSecret {
+				return false, fmt.Errorf("failed to evaluate path %q in key %s of %s %s/%s", q.path, q.key, q.kind, q.namespace, q.objectName)
+			}
+			return false, fmt.Errorf("failed to evaluate path %q in key %s of %s %s/%s: %w", q.path, q.key, q.kind, q.namespace, q.objectName, err)
+		}
+	}
+	q.observed = values
+
+	for _, v := range values {
+		if (q.value == nil || v == *q.value) && (re == nil || re.MatchString(v)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// resource returns the resource of the kind of the query.
+func (q *QueryDataKey) resource() schema.GroupVersionResource {
+	if q.kind == DataKindSecret {
+		return corev1.SchemeGroupVersion.WithResource("secrets")
+	}
+	return corev1.SchemeGroupVersion.WithResource("configmaps")
+}
+
+// data returns the value of the key in the data or binary data of a ConfigMap, or in the data of a Secret, decoded.
+func (q *QueryDataKey) data(u *unstructured.Unstructured) (string, bool, error) {
+	if q.kind == DataKindConfigMap {
+		if v, ok, _ := unstructured.NestedString(u.Object, "data", q.key); ok {
+			return v, true, nil
+		}
+	}
+	field := "data"
+	if q.kind == DataKindConfigMap {
+		field = "binaryData"
+	}
+	encoded, ok, _ := unstructured.NestedString(u.Object, field, q.key)
+	if !ok {
+		return "", false, nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to decode key %s of %s %s/%s: %w", q.key, q.kind, q.namespace, q.objectName, err)
+	}
+	return string(decoded), true, nil
+}
+
+// valuesAtDocumentPath returns the string representations of all the values found at a JSONPath in a YAML or JSON
+// document.
+func valuesAtDocumentPath(document, path string) ([]string, error) {
+	b, err := yaml.YAMLToJSON([]byte(document))
+	if err != nil {
+		return nil, err
+	}
+	var content interface{}
+	if err := json.Unmarshal(b, &content); err != nil {
+		return nil, err
+	}
+	return valuesAtJSONPath(content, path)
+}
+
+// object returns the reference of the ConfigMap or Secret.
+func (q *QueryDataKey) object() *corev1.ObjectReference {
+	return &corev1.ObjectReference{
+		APIVersion: corev1.SchemeGroupVersion.String(),
+		Kind:       string(q.kind),
+		Namespace:  q.namespace,
+		Name:       q.objectName,
+	}
+}
+
+// validate ensures the object and key are set, and the regular expression compiles.
+func (q *QueryDataKey) validate() error {
+	if q.kind != DataKindConfigMap && q.kind != DataKindSecret {
+		return fmt.Errorf("data key query %q has unknown kind %q", q.name, q.kind)
+	}
+	if q.namespace == "" || q.objectName == "" {
+		return fmt.Errorf("data key query %q requires the namespace and name of a %s", q.name, q.kind)
+	}
+	if q.key == "" {
+		return fmt.Errorf("data key query %q requires a key", q.name)
+	}
+	if q.regex != "" {
+		if _, err := regexp.Compile(q.regex); err != nil {
+			return fmt.Errorf("invalid regular expression of data key query %q: %w", q.name, err)
+		}
+	}
+	return nil
+}
+
+// observedValues returns the values observed by the last run, redacted for Secrets.
+func (q *QueryDataKey) observedValues() string {
+	if q.kind == DataKindSecret {
+		return redactedValue
+	}
+	observed := strings.Join(q.observed, ",")
+	if len(observed) > maxReasonValueLength {
+		// The value is truncated on a rune boundary, so that the reason stays valid UTF-8.
+		end := maxReasonValueLength
+		for end > 0 && !utf8.RuneStart(observed[end]) {
+			end--
+		}
+		observed = observed[:end] + "..."
+	}
+	return observed
+}
+
+// Reason returns what was observed in the data of the ConfigMap or Secret, e.g. "key=metadata.yaml path=cluster.type
+// observed=workload". The values of Secrets are redacted.
+func (q *QueryDataKey) Reason() string {
+	object := fmt.Sprintf("method=dataKey name=%s %s=%s/%s key=%s", q.name, strings.ToLower(string(q.kind)), q.namespace, q.objectName, q.key)
+	switch {
+	case !q.found:
+		return fmt.Sprintf("%s status=notFound presence=true", object)
+	case !q.keyFound:
+		return fmt.Sprintf("%s status=keyNotFound presence=true", object)
+	case q.path != "" && len(q.observed) == 0:
+		return fmt.Sprintf("%s path=%s status=pathNotFound presence=true", object, q.path)
+	case q.path != "":
+		return fmt.Sprintf("%s path=%s observed=%s status=unmatched presence=true", object, q.path, q.observedValues())
+	}
+	return fmt.Sprintf("%s observed=%s status=unmatched presence=true", object, q.observedValues())
+}
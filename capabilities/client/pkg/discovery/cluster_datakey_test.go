@@ -0,0 +1,161 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package discovery
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestDataKeyQueries(t *testing.T) {
+	metadata := "cluster:\n  name: tkg-mgmt\n  type: management\n  infrastructure:\n    provider: vsphere\n"
+	configMap := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": "tkg-metadata", "namespace": "tkg-system-public"},
+		"data":       map[string]interface{}{"metadata.yaml": metadata, "mode": "strict", "greeting": "a" + strings.Repeat("é", 40)},
+		"binaryData": map[string]interface{}{"logo": base64.StdEncoding.EncodeToString([]byte("tanzu"))},
+	}}
+	secret := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata":   map[string]interface{}{"name": "credentials", "namespace": "tkg-system"},
+		"data": map[string]interface{}{
+			"password": base64.StdEncoding.EncodeToString([]byte("hunter2")),
+			"config":   base64.StdEncoding.EncodeToString([]byte(`{"endpoint": "https://vcenter.example.com"}`)),
+		},
+	}}
+	c, _ := newTestClusterQueryClient(t, nil, nil, []runtime.Object{configMap, secret})
+
+	testCases := []struct {
+		description string
+		query       *QueryDataKey
+		want        bool
+		reason      string
+		err         string
+	}{
+		{
+			description: "key exists",
+			query:       ConfigMapKey("metadata", "tkg-system-public", "tkg-metadata", "metadata.yaml"),
+			want:        true,
+		},
+		{
+			description: "value at path",
+			query:       ConfigMapKey("management", "tkg-system-public", "tkg-metadata", "metadata.yaml").WithPath("cluster.type").WithValue("management"),
+			want:        true,
+		},
+		{
+			description: "unmatched value at path",
+			query:       ConfigMapKey("aws", "tkg-system-public", "tkg-metadata", "metadata.yaml").WithPath("{.cluster.infrastructure.provider}").WithValue("aws"),
+			want:        false,
+			reason:      "method=dataKey name=aws configmap=tkg-system-public/tkg-metadata key=metadata.yaml path={.cluster.infrastructure.provider} observed=vsphere status=unmatched presence=true",
+		},
+		{
+			description: "missing path",
+			query:       ConfigMapKey("plan", "tkg-system-public", "tkg-metadata", "metadata.yaml").WithPath("cluster.plan"),
+			want:        false,
+			reason:      "method=dataKey name=plan configmap=tkg-system-public/tkg-metadata key=metadata.yaml path=cluster.plan status=pathNotFound presence=true",
+		},
+		{
+			description: "regex",
+			query:       ConfigMapKey("mode", "tkg-system-public", "tkg-metadata", "mode").WithRegex("^(strict|lax)$"),
+			want:        true,
+		},
+		{
+			description: "unmatched value",
+			query:       ConfigMapKey("lax", "tkg-system-public", "tkg-metadata", "mode").WithValue("lax"),
+			want:        false,
+			reason:      "method=dataKey name=lax configmap=tkg-system-public/tkg-metadata key=mode observed=strict status=unmatched presence=true",
+		},
+		{
+			description: "long values are truncated",
+			query:       ConfigMapKey("whole", "tkg-system-public", "tkg-metadata", "metadata.yaml").WithValue("cluster: {}"),
+			want:        false,
+			reason: "method=dataKey name=whole configmap=tkg-system-public/tkg-metadata key=metadata.yaml observed=" + metadata[:maxReasonValueLength] +
+				"... status=unmatched presence=true",
+		},
+		{
+			description: "long values are truncated on a rune boundary",
+			query:       ConfigMapKey("greeting", "tkg-system-public", "tkg-metadata", "greeting").WithValue("hello"),
+			want:        false,
+			reason: "method=dataKey name=greeting configmap=tkg-system-public/tkg-metadata key=greeting observed=a" + strings.Repeat("é", 31) +
+				"... status=unmatched presence=true",
+		},
+		{
+			description: "binary data",
+			query:       ConfigMapKey("logo", "tkg-system-public", "tkg-metadata", "logo").WithValue("tanzu"),
+			want:        true,
+		},
+		{
+			description: "missing key",
+			query:       ConfigMapKey("missingKey", "tkg-system-public", "tkg-metadata", "missing"),
+			want:        false,
+			reason:      "method=dataKey name=missingKey configmap=tkg-system-public/tkg-metadata key=missing status=keyNotFound presence=true",
+		},
+		{
+			description: "missing ConfigMap",
+			query:       ConfigMapKey("missingConfigMap", "tkg-system-public", "missing", "metadata.yaml"),
+			want:        false,
+			reason:      "method=dataKey name=missingConfigMap configmap=tkg-system-public/missing key=metadata.yaml status=notFound presence=true",
+		},
+		{
+			description: "secret value",
+			query:       SecretKey("password", "tkg-system", "credentials", "password").WithValue("hunter2"),
+			want:        true,
+		},
+		{
+			description: "secret value is redacted",
+			query:       SecretKey("wrongPassword", "tkg-system", "credentials", "password").WithRegex("^admin"),
+			want:        false,
+			reason:      "method=dataKey name=wrongPassword secret=tkg-system/credentials key=password observed=<redacted> status=unmatched presence=true",
+		},
+		{
+			description: "secret value at path is redacted",
+			query:       SecretKey("endpoint", "tkg-system", "credentials", "config").WithPath("endpoint").WithRegex(`\.local$`),
+			want:        false,
+			reason:      "method=dataKey name=endpoint secret=tkg-system/credentials key=config path=endpoint observed=<redacted> status=unmatched presence=true",
+		},
+		{
+			description: "secret parse errors are redacted",
+			query:       SecretKey("notYAML", "tkg-system", "credentials", "password").WithPath("a[").WithValue("b"),
+			err:         `failed to evaluate path "a[" in key password of Secret tkg-system/credentials`,
+		},
+		{
+			description: "invalid regex",
+			query:       ConfigMapKey("invalidRegex", "tkg-system-public", "tkg-metadata", "mode").WithRegex("("),
+			err:         `invalid regular expression of data key query "invalidRegex"`,
+		},
+		{
+			description: "missing key name",
+			query:       SecretKey("noKey", "tkg-system", "credentials", ""),
+			err:         `data key query "noKey" requires a key`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			got, err := c.Query(tc.query).Execute()
+			if err != nil {
+				if tc.err == "" || !strings.Contains(err.Error(), tc.err) {
+					t.Errorf("want error containing %q, got: %v", tc.err, err)
+				}
+				if strings.Contains(err.Error(), "hunter2") {
+					t.Errorf("error leaks a secret value: %v", err)
+				}
+			} else if tc.err != "" {
+				t.Errorf("want error containing %q, got none", tc.err)
+			}
+			if got != tc.want {
+				t.Errorf("got=%t, want=%t, reason: %s", got, tc.want, tc.query.Reason())
+			}
+			if tc.reason != "" && tc.query.Reason() != tc.reason {
+				t.Errorf("reason: got %q, want %q", tc.query.Reason(), tc.reason)
+			}
+		})
+	}
+}
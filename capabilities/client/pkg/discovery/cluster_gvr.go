@@ -4,6 +4,7 @@
 package discovery
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
@@ -12,6 +13,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/discovery/cached/memory"
 )
 
 var (
@@ -20,6 +22,9 @@ var (
 
 	// errInvalidWithVersionsMethodArgument occurs when empty string argument(s) are passed into the WithVersions method.
 	errInvalidWithVersionsMethodArgument = errors.New("WithVersions method must be comprised of non-empty string argument(s); omit method to indicate any version")
+
+	// errResourceWithoutVersions occurs when a GVR query of a Capability has a resource but no versions.
+	errResourceWithoutVersions = errors.New("a resource requires at least one version")
 )
 
 // Group represents any API group that may exist on a cluster.
@@ -50,6 +55,8 @@ type QueryGVR struct {
 	resource      nullString
 	versions      []string
 	unmatchedGVRs []string
+	// versionsRequired is set for the queries of Capabilities, whose API requires versions when a resource is set.
+	versionsRequired bool
 }
 
 // Name returns the name of the query.
@@ -73,15 +80,28 @@ func (q *QueryGVR) WithResource(resource string) *QueryGVR {
 
 // Run discovery.
 func (q *QueryGVR) Run(config *clusterQueryClientConfig) (bool, error) {
-	if err := q.validate(config); err != nil {
+	return q.RunContext(context.Background(), config)
+}
+
+// RunContext runs discovery. The discovery client does not accept a context, so cancellation is only checked before
+// discovery is started.
+func (q *QueryGVR) RunContext(ctx context.Context, config *clusterQueryClientConfig) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	q.unmatchedGVRs = nil
+	if config == nil {
+		return false, fmt.Errorf("clusterQueryClientConfig must not be nil")
+	}
+	if err := q.validate(); err != nil {
 		return false, fmt.Errorf("failed GroupVersionResource API query validation: %w", err)
 	}
 
 	var unmatched []string
 
 	switch {
-	case q.versions == nil:
-		// q.WithVersions method was omitted and q.versions has not been set.
+	case len(q.versions) == 0:
+		// q.WithVersions method was omitted or called without versions.
 		// Any version that matches group and/or resource is considered a match.
 		unmatchedGroupResource, err := q.unmatchedGroupResource(config)
 		if err != nil {
@@ -108,15 +128,16 @@ func (q *QueryGVR) Run(config *clusterQueryClientConfig) (bool, error) {
 	return len(unmatched) == 0, nil
 }
 
-func (q *QueryGVR) validate(cfg *clusterQueryClientConfig) error {
-	if cfg == nil {
-		return fmt.Errorf("clusterQueryClientConfig must not be nil")
-	}
-
+// validate ensures the resource and versions are not empty strings when they are set, and that the queries of
+// Capabilities with a resource have versions.
+func (q *QueryGVR) validate() error {
 	var errs []error
 	if q.resource.IsSet && q.resource.String == "" {
 		errs = append(errs, errInvalidWithResourceMethodArgument)
 	}
+	if q.versionsRequired && q.resource.String != "" && len(q.versions) == 0 {
+		errs = append(errs, errResourceWithoutVersions)
+	}
 	if q.versions != nil && containsEmpty(q.versions) {
 		errs = append(errs, errInvalidWithVersionsMethodArgument)
 	}
@@ -136,7 +157,7 @@ func containsEmpty(strs []string) bool {
 }
 
 func (q *QueryGVR) unmatchedGroupResource(cfg *clusterQueryClientConfig) (string, error) {
-	groups, resources, err := cfg.discoveryClientset.ServerGroupsAndResources()
+	groups, resources, err := cfg.discovery().ServerGroupsAndResources()
 	if err != nil {
 		return "", fmt.Errorf("failed to discover server group and resource: %w", err)
 	}
@@ -167,7 +188,7 @@ func (q *QueryGVR) unmatchedGroupResource(cfg *clusterQueryClientConfig) (string
 }
 
 func (q *QueryGVR) unmatchedGroupVersions(cfg *clusterQueryClientConfig) ([]string, error) {
-	groupList, err := cfg.discoveryClientset.ServerGroups()
+	groupList, err := cfg.discovery().ServerGroups()
 	if err != nil {
 		return nil, fmt.Errorf("failed to discover server groups: %w", err)
 	}
@@ -215,17 +236,18 @@ func (q *QueryGVR) unmatchedGroupVersionResources(cfg *clusterQueryClientConfig)
 			Version:  ver,
 			Resource: q.resource.String,
 		}
-		resources, err := cfg.discoveryClientset.ServerResourcesForGroupVersion(gvr.GroupVersion().String())
+		resources, err := cfg.discovery().ServerResourcesForGroupVersion(gvr.GroupVersion().String())
 		if err != nil {
-			// Second condition is because fake discovery client does not return a proper NotFound error.
-			if apierrors.IsNotFound(err) || strings.Contains(
+			// The cached discovery client returns ErrCacheNotFound for group versions the server does not serve.
+			// The last condition is because fake discovery client does not return a proper NotFound error.
+			if apierrors.IsNotFound(err) || errors.Is(err, memory.ErrCacheNotFound) || strings.Contains(
 				err.Error(),
 				fmt.Sprintf("GroupVersion %q not found", gvr.GroupVersion().String()),
 			) {
 				unmatched = append(unmatched, gvr.String())
-			} else {
-				return nil, err
+				continue
 			}
+			return nil, err
 		}
 		if !q.resourceExists([]*metav1.APIResourceList{resources}) {
 			unmatched = append(unmatched, gvr.String())
@@ -263,6 +285,10 @@ func (q *QueryGVR) resourceListInGroup(resourceLists []*metav1.APIResourceList,
 
 // groupFromGroupList looks for a particular group from an APIGroupList.
 func (q *QueryGVR) groupFromGroupList(groupList *metav1.APIGroupList) *metav1.APIGroup {
+	// The cached discovery client returns a nil list when the server has no groups.
+	if groupList == nil {
+		return nil
+	}
 	for i := range groupList.Groups {
 		if strings.EqualFold(groupList.Groups[i].Name, q.group) {
 			return &groupList.Groups[i]
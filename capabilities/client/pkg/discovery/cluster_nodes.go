@@ -0,0 +1,294 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// nodes is the resource of the Nodes.
+var nodes = corev1.SchemeGroupVersion.WithResource("nodes")
+
+// maxReasonNodes is the maximum number of unmatched nodes listed in the reason of a nodes query.
+const maxReasonNodes = 5
+
+// NodeMatch is how many of the nodes of a nodes query must satisfy its constraints.
+type NodeMatch string
+
+const (
+	// NodeMatchAll requires all the nodes to satisfy the constraints.
+	NodeMatchAll = NodeMatch("all")
+	// NodeMatchAny requires any node to satisfy the constraints.
+	NodeMatchAny = NodeMatch("any")
+)
+
+// Nodes returns a query target that checks the nodes of the cluster, e.g. that all nodes are amd64 with
+// Nodes("amd64").WithArchitectures("amd64"), or that there is a Windows node pool with
+// Nodes("windows").WithLabelSelector("pool=windows").WithOperatingSystems("windows").
+// All the nodes must satisfy the constraints unless specified otherwise with WithMatch, and at least one node must
+// satisfy them unless specified otherwise with WithMinCount.
+func Nodes(name string) *QueryNodes {
+	return &QueryNodes{
+		name:  name,
+		match: NodeMatchAll,
+	}
+}
+
+// nodeTaint is a taint a node must have or not have. An empty effect matches any effect.
+type nodeTaint struct {
+	key    string
+	effect corev1.TaintEffect
+}
+
+// QueryNodes allows for querying the topology of the nodes of a cluster.
+type QueryNodes struct {
+	name              string
+	labelSelector     string
+	match             NodeMatch
+	minCount          *int
+	maxCount          *int
+	requiredLabels    string
+	architectures     []string
+	operatingSystems  []string
+	containerRuntimes []string
+	taints            []nodeTaint
+	withoutTaints     []nodeTaint
+
+	nodeCount      int
+	matchCount     int
+	unmatchedNodes []string
+}
+
+// Name is the name of the query.
+func (q *QueryNodes) Name() string {
+	return q.name
+}
+
+// WithLabelSelector restricts the query to the nodes that match the label selector, e.g. a node pool.
+func (q *QueryNodes) WithLabelSelector(selector string) *QueryNodes {
+	q.labelSelector = selector
+	return q
+}
+
+// WithMatch sets how many of the nodes must satisfy the constraints.
+func (q *QueryNodes) WithMatch(match NodeMatch) *QueryNodes {
+	q.match = match
+	return q
+}
+
+// WithMinCount requires at least n nodes to satisfy the constraints. It defaults to one.
+func (q *QueryNodes) WithMinCount(n int) *QueryNodes {
+	q.minCount = &n
+	return q
+}
+
+// WithMaxCount requires at most n nodes to satisfy the constraints.
+func (q *QueryNodes) WithMaxCount(n int) *QueryNodes {
+	q.maxCount = &n
+	return q
+}
+
+// WithRequiredLabels requires the labels of the nodes to match the label selector, e.g. "!nvidia.com/gpu.present"
+// for nodes without GPUs.
+func (q *QueryNodes) WithRequiredLabels(selector string) *QueryNodes {
+	q.requiredLabels = selector
+	return q
+}
+
+// WithArchitectures requires the architecture of the nodes to be one of the architectures, e.g. "amd64" or "arm64".
+func (q *QueryNodes) WithArchitectures(architectures ...string) *QueryNodes {
+	q.architectures = append(q.architectures, architectures...)
+	return q
+}
+
+// WithOperatingSystems requires the operating system of the nodes to be one of the operating systems, e.g. "linux"
+// or "windows".
+func (q *QueryNodes) WithOperatingSystems(operatingSystems ...string) *QueryNodes {
+	q.operatingSystems = append(q.operatingSystems, operatingSystems...)
+	return q
+}
+
+// WithContainerRuntimes requires the container runtime of the nodes to be one of the container runtimes, e.g.
+// "containerd" for nodes that report "containerd://1.6.6".
+func (q *QueryNodes) WithContainerRuntimes(containerRuntimes ...string) *QueryNodes {
+	q.containerRuntimes = append(q.containerRuntimes, containerRuntimes...)
+	return q
+}
+
+// WithTaint requires the nodes to have a taint with the key and effect. An empty effect matches any effect.
+func (q *QueryNodes) WithTaint(key string, effect corev1.TaintEffect) *QueryNodes {
+	q.taints = append(q.taints, nodeTaint{key: key, effect: effect})
+	return q
+}
+
+// WithoutTaint requires the nodes not to have a taint with the key and effect. An empty effect matches any effect.
+func (q *QueryNodes) WithoutTaint(key string, effect corev1.TaintEffect) *QueryNodes {
+	q.withoutTaints = append(q.withoutTaints, nodeTaint{key: key, effect: effect})
+	return q
+}
+
+// Run the nodes query.
+func (q *QueryNodes) Run(config *clusterQueryClientConfig) (bool, error) {
+	return q.RunContext(context.Background(), config)
+}
+
+// RunContext runs the nodes query using the context for the API calls.
+func (q *QueryNodes) RunContext(ctx context.Context, config *clusterQueryClientConfig) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	q.nodeCount, q.matchCount, q.unmatchedNodes = 0, 0, nil
+
+	if err := q.validate(); err != nil {
+		return false, err
+	}
+	requiredLabels, err := labels.Parse(q.requiredLabels)
+	if err != nil {
+		return false, fmt.Errorf("invalid required labels of nodes query %q: %w", q.name, err)
+	}
+
+	opts := metav1.ListOptions{LabelSelector: q.labelSelector, Limit: listPageSize}
+	for {
+		list, err := config.dynamicClient.Resource(nodes).List(ctx, opts)
+		if err != nil {
+			return false, fmt.Errorf("failed to list nodes: %w", err)
+		}
+		for i := range list.Items {
+			node := &corev1.Node{}
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(list.Items[i].Object, node); err != nil {
+				return false, fmt.Errorf("failed to convert node %s: %w", list.Items[i].GetName(), err)
+			}
+			q.nodeCount++
+			if unmatched := q.unmatchedConstraints(node, requiredLabels); len(unmatched) != 0 {
+				q.unmatchedNodes = append(q.unmatchedNodes, fmt.Sprintf("%s(%s)", node.Name, strings.Join(unmatched, ",")))
+				continue
+			}
+			q.matchCount++
+		}
+		if opts.Continue = list.GetContinue(); opts.Continue == "" {
+			break
+		}
+	}
+	sort.Strings(q.unmatchedNodes)
+
+	if q.match == NodeMatchAll && len(q.unmatchedNodes) != 0 {
+		return false, nil
+	}
+	return q.matchCount >= q.minimum() && (q.maxCount == nil || q.matchCount <= *q.maxCount), nil
+}
+
+// unmatchedConstraints returns the constraints the node does not satisfy, with the observed value.
+func (q *QueryNodes) unmatchedConstraints(node *corev1.Node, requiredLabels labels.Selector) []string {
+	var unmatched []string
+	if !requiredLabels.Matches(labels.Set(node.Labels)) {
+		unmatched = append(unmatched, "labels")
+	}
+	info := node.Status.NodeInfo
+	if len(q.architectures) != 0 && !containsString(q.architectures, info.Architecture) {
+		unmatched = append(unmatched, "architecture="+info.Architecture)
+	}
+	if len(q.operatingSystems) != 0 && !containsString(q.operatingSystems, info.OperatingSystem) {
+		unmatched = append(unmatched, "operatingSystem="+info.OperatingSystem)
+	}
+	if cr := containerRuntime(info.ContainerRuntimeVersion); len(q.containerRuntimes) != 0 && !containsString(q.containerRuntimes, cr) {
+		unmatched = append(unmatched, "containerRuntime="+cr)
+	}
+	for _, t := range q.taints {
+		if !hasTaint(node, t) {
+			unmatched = append(unmatched, "missingTaint="+t.String())
+		}
+	}
+	for _, t := range q.withoutTaints {
+		if hasTaint(node, t) {
+			unmatched = append(unmatched, "taint="+t.String())
+		}
+	}
+	return unmatched
+}
+
+// containerRuntime returns the name of the container runtime of a container runtime version, e.g. "containerd" for
+// "containerd://1.6.6".
+func containerRuntime(containerRuntimeVersion string) string {
+	return strings.SplitN(containerRuntimeVersion, "://", 2)[0]
+}
+
+func hasTaint(node *corev1.Node, t nodeTaint) bool {
+	for _, taint := range node.Spec.Taints {
+		if taint.Key == t.key && (t.effect == "" || taint.Effect == t.effect) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t nodeTaint) String() string {
+	if t.effect == "" {
+		return t.key
+	}
+	return fmt.Sprintf("%s:%s", t.key, t.effect)
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (q *QueryNodes) minimum() int {
+	if q.minCount == nil {
+		return 1
+	}
+	return *q.minCount
+}
+
+// validate ensures the match is known, the selectors parse and the count constraints are consistent.
+func (q *QueryNodes) validate() error {
+	if q.match != NodeMatchAll && q.match != NodeMatchAny {
+		return fmt.Errorf("nodes query %q has unknown match %q", q.name, q.match)
+	}
+	if _, err := labels.Parse(q.labelSelector); err != nil {
+		return fmt.Errorf("invalid label selector of nodes query %q: %w", q.name, err)
+	}
+	if _, err := labels.Parse(q.requiredLabels); err != nil {
+		return fmt.Errorf("invalid required labels of nodes query %q: %w", q.name, err)
+	}
+	if q.minimum() < 0 {
+		return fmt.Errorf("minimum count must not be negative")
+	}
+	if q.maxCount != nil {
+		if *q.maxCount < 0 {
+			return fmt.Errorf("maximum count must not be negative")
+		}
+		if *q.maxCount < q.minimum() {
+			return fmt.Errorf("minimum count %d must not be greater than maximum count %d", q.minimum(), *q.maxCount)
+		}
+	}
+	return nil
+}
+
+// Reason returns the number of nodes and the nodes that did not satisfy the constraints, e.g.
+// "unmatchedNodes=node-1(architecture=arm64)".
+func (q *QueryNodes) Reason() string {
+	unmatched := q.unmatchedNodes
+	if len(unmatched) > maxReasonNodes {
+		unmatched = append(unmatched[:maxReasonNodes:maxReasonNodes], fmt.Sprintf("+%d", len(q.unmatchedNodes)-maxReasonNodes))
+	}
+	countRange := fmt.Sprintf("minCount=%d", q.minimum())
+	if q.maxCount != nil {
+		countRange = fmt.Sprintf("minCount=%d maxCount=%d", q.minimum(), *q.maxCount)
+	}
+	return fmt.Sprintf("method=nodes name=%s match=%s nodes=%d matching=%d %s unmatchedNodes=%s status=unmatched presence=true",
+		q.name, q.match, q.nodeCount, q.matchCount, countRange, strings.Join(unmatched, ","))
+}
@@ -0,0 +1,136 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package discovery
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestNodesQueries(t *testing.T) {
+	node := func(name, arch, os, containerRuntime string, nodeLabels map[string]string, taints ...corev1.Taint) runtime.Object {
+		n := &corev1.Node{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Node"},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: nodeLabels},
+			Spec:       corev1.NodeSpec{Taints: taints},
+			Status: corev1.NodeStatus{NodeInfo: corev1.NodeSystemInfo{
+				Architecture:            arch,
+				OperatingSystem:         os,
+				ContainerRuntimeVersion: containerRuntime + "://1.6.6",
+			}},
+		}
+		return toUnstructured(t, n)
+	}
+	controlPlaneTaint := corev1.Taint{Key: "node-role.kubernetes.io/control-plane", Effect: corev1.TaintEffectNoSchedule}
+	c, _ := newTestClusterQueryClient(t, nil, map[schema.GroupVersionResource]string{nodes: "NodeList"}, []runtime.Object{
+		node("control-plane", "amd64", "linux", "containerd", map[string]string{"pool": "control-plane"}, controlPlaneTaint),
+		node("linux-1", "amd64", "linux", "containerd", map[string]string{"pool": "linux"}),
+		node("linux-2", "arm64", "linux", "containerd", map[string]string{"pool": "linux", "nvidia.com/gpu.present": "true"}),
+		node("windows-1", "amd64", "windows", "containerd", map[string]string{"pool": "windows"}),
+	})
+
+	testCases := []struct {
+		description string
+		query       *QueryNodes
+		want        bool
+		reason      string
+		err         string
+	}{
+		{
+			description: "mixed architectures",
+			query:       Nodes("amd64").WithArchitectures("amd64"),
+			want:        false,
+			reason:      "method=nodes name=amd64 match=all nodes=4 matching=3 minCount=1 unmatchedNodes=linux-2(architecture=arm64) status=unmatched presence=true",
+		},
+		{
+			description: "any node",
+			query:       Nodes("anyArm64").WithArchitectures("arm64").WithMatch(NodeMatchAny),
+			want:        true,
+		},
+		{
+			description: "windows node pool",
+			query:       Nodes("windows").WithLabelSelector("pool=windows").WithOperatingSystems("windows").WithContainerRuntimes("containerd"),
+			want:        true,
+		},
+		{
+			description: "node pool without GPUs",
+			query:       Nodes("noGPU").WithLabelSelector("pool=linux").WithRequiredLabels("!nvidia.com/gpu.present"),
+			want:        false,
+			reason:      "method=nodes name=noGPU match=all nodes=2 matching=1 minCount=1 unmatchedNodes=linux-2(labels) status=unmatched presence=true",
+		},
+		{
+			description: "taints",
+			query:       Nodes("controlPlane").WithLabelSelector("pool=control-plane").WithTaint("node-role.kubernetes.io/control-plane", corev1.TaintEffectNoSchedule),
+			want:        true,
+		},
+		{
+			description: "without taints",
+			query:       Nodes("schedulable").WithoutTaint("node-role.kubernetes.io/control-plane", "").WithContainerRuntimes("cri-o"),
+			want:        false,
+			reason: "method=nodes name=schedulable match=all nodes=4 matching=0 minCount=1 unmatchedNodes=control-plane(containerRuntime=containerd,taint=node-role.kubernetes.io/control-plane)," +
+				"linux-1(containerRuntime=containerd),linux-2(containerRuntime=containerd),windows-1(containerRuntime=containerd) status=unmatched presence=true",
+		},
+		{
+			description: "node count",
+			query:       Nodes("linuxCount").WithOperatingSystems("linux").WithMatch(NodeMatchAny).WithMinCount(2).WithMaxCount(2),
+			want:        false,
+			reason:      "method=nodes name=linuxCount match=any nodes=4 matching=3 minCount=2 maxCount=2 unmatchedNodes=windows-1(operatingSystem=windows) status=unmatched presence=true",
+		},
+		{
+			description: "no nodes in the pool",
+			query:       Nodes("missingPool").WithLabelSelector("pool=missing"),
+			want:        false,
+			reason:      "method=nodes name=missingPool match=all nodes=0 matching=0 minCount=1 unmatchedNodes= status=unmatched presence=true",
+		},
+		{
+			description: "unknown match",
+			query:       Nodes("unknownMatch").WithMatch("most"),
+			err:         `nodes query "unknownMatch" has unknown match "most"`,
+		},
+		{
+			description: "invalid required labels",
+			query:       Nodes("invalidLabels").WithRequiredLabels("a b"),
+			err:         `invalid required labels of nodes query "invalidLabels"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			got, err := c.Query(tc.query).Execute()
+			if err != nil {
+				if tc.err == "" || !strings.Contains(err.Error(), tc.err) {
+					t.Errorf("want error containing %q, got: %v", tc.err, err)
+				}
+			} else if tc.err != "" {
+				t.Errorf("want error containing %q, got none", tc.err)
+			}
+			if got != tc.want {
+				t.Errorf("got=%t, want=%t, reason: %s", got, tc.want, tc.query.Reason())
+			}
+			if tc.reason != "" && tc.query.Reason() != tc.reason {
+				t.Errorf("reason: got %q, want %q", tc.query.Reason(), tc.reason)
+			}
+		})
+	}
+}
+
+func TestNodesQueriesArePaginated(t *testing.T) {
+	dynamicClient := &pagedDynamicClient{resource: &pagedResource{t: t, pages: map[string][]string{"": {"node-1", "node-2"}, "next": {"node-3"}}}}
+	c, err := NewClusterQueryClient(dynamicClient, &fakediscovery.FakeDiscovery{Fake: &k8stesting.Fake{Resources: apiResources}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := Nodes("nodes").WithMinCount(3)
+	if got, err := c.Query(query).Execute(); err != nil || !got {
+		t.Errorf("want the nodes of all the pages to be counted, got: %t, %v, reason: %s", got, err, query.Reason())
+	}
+}
@@ -6,21 +6,28 @@ package discovery
 import (
 	"context"
 	"fmt"
+	"sort"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/restmapper"
+
+	corev1alpha2 "github.com/vmware-tanzu/tanzu-framework/apis/core/v1alpha2"
 )
 
 // Object represents any runtime.Object that could exist on a cluster, with ability to specify:
 // WithAnnotations()
-// WithLabels()
-// WithConditions()
+// WithoutAnnotations()
+// WithFieldPredicate()
+// WithLabelSelector(), WithFieldSelector(), WithMinCount() and WithMaxCount() to count the objects of the kind instead,
+// which is also the case when the reference has no name.
 func Object(queryName string, obj *corev1.ObjectReference) *QueryObject {
 	return &QueryObject{
 		name:     queryName,
@@ -34,8 +41,17 @@ type QueryObject struct {
 	name        string
 	object      *corev1.ObjectReference
 	annotations []resourceAnnotation
+	predicates  []fieldPredicate
 	presence    bool
-	//	conditions []resourceCondition
+
+	labelSelector string
+	fieldSelector string
+	minCount      *int
+	maxCount      *int
+
+	unmatchedPredicates  []string
+	unmatchedAnnotations []string
+	count                int
 }
 
 // Name is the name of the query.
@@ -45,10 +61,10 @@ func (q *QueryObject) Name() string {
 
 // WithoutAnnotations ensures lack of presence annotations on a resource
 func (q *QueryObject) WithoutAnnotations(a map[string]string) *QueryObject {
-	for k, v := range a {
+	for _, k := range sortedKeys(a) {
 		q.annotations = append(q.annotations, resourceAnnotation{
 			key:      k,
-			value:    v,
+			value:    a[k],
 			presence: false,
 		})
 	}
@@ -58,10 +74,10 @@ func (q *QueryObject) WithoutAnnotations(a map[string]string) *QueryObject {
 
 // WithAnnotations matches annotations on a resource
 func (q *QueryObject) WithAnnotations(a map[string]string) *QueryObject {
-	for k, v := range a {
+	for _, k := range sortedKeys(a) {
 		q.annotations = append(q.annotations, resourceAnnotation{
 			key:      k,
-			value:    v,
+			value:    a[k],
 			presence: true,
 		})
 	}
@@ -69,15 +85,60 @@ func (q *QueryObject) WithAnnotations(a map[string]string) *QueryObject {
 	return q
 }
 
+// sortedKeys returns the keys of the annotations in order, so that unmatched annotations are reported in a stable
+// order.
+func sortedKeys(a map[string]string) []string {
+	keys := make([]string, 0, len(a))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// WithFieldPredicate asserts that the value found at a JSONPath in the object satisfies the operator.
+// Paths may be written with or without braces and the leading dot, e.g. "spec.replicas" or "{.status.phase}".
+// Examples: WithFieldPredicate("spec.replicas", FieldGreaterThanOrEqual, "2"),
+// WithFieldPredicate("status.phase", FieldEquals, "Ready").
+func (q *QueryObject) WithFieldPredicate(path string, operator FieldOperator, values ...string) *QueryObject {
+	q.predicates = append(q.predicates, fieldPredicate{
+		path:     path,
+		operator: operator,
+		values:   values,
+	})
+
+	return q
+}
+
 // Run the object discovery
 func (q *QueryObject) Run(config *clusterQueryClientConfig) (bool, error) {
-	groupResources, err := restmapper.GetAPIGroupResources(config.discoveryClientset)
+	return q.RunContext(context.Background(), config)
+}
+
+// RunContext runs the object discovery using the context for the API calls.
+func (q *QueryObject) RunContext(ctx context.Context, config *clusterQueryClientConfig) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	q.unmatchedPredicates = nil
+	q.unmatchedAnnotations = nil
+	q.count = 0
+
+	if err := q.validate(); err != nil {
+		return false, err
+	}
+
+	rm, err := config.restMapper()
 	if err != nil {
 		return false, err
 	}
 
+	if q.listMode() {
+		return q.countObjects(ctx, rm, config)
+	}
+
 	// Ensure object presence or lack
-	objectExists, err := q.QueryObjectExists(groupResources, config)
+	objectExists, err := q.queryObjectExists(ctx, rm, config)
 	if err != nil {
 		return false, err
 	}
@@ -91,7 +152,11 @@ func (q *QueryObject) Run(config *clusterQueryClientConfig) (bool, error) {
 
 // QueryObjectExists uses dynamic and unstructured APIs to reason about object state
 func (q *QueryObject) QueryObjectExists(resources []*restmapper.APIGroupResources, config *clusterQueryClientConfig) (bool, error) {
-	u, err := q.objectExists(resources, config)
+	return q.queryObjectExists(context.Background(), restmapper.NewDiscoveryRESTMapper(resources), config)
+}
+
+func (q *QueryObject) queryObjectExists(ctx context.Context, rm meta.RESTMapper, config *clusterQueryClientConfig) (bool, error) {
+	u, err := q.objectExists(ctx, rm, config)
 	if err != nil {
 		return false, err
 	}
@@ -99,31 +164,36 @@ func (q *QueryObject) QueryObjectExists(resources []*restmapper.APIGroupResource
 		return false, nil
 	}
 
-	if !q.checkAnnotations(u) {
+	q.unmatchedAnnotations = q.unmatchedAnnotationKeys(u)
+	if len(q.unmatchedAnnotations) != 0 {
+		return false, nil
+	}
+
+	unmatched, err := q.checkFieldPredicates(u)
+	if err != nil {
+		return false, err
+	}
+	q.unmatchedPredicates = unmatched
+	if len(unmatched) != 0 {
 		return false, nil
 	}
 
 	return true, nil
 }
 
-func (q *QueryObject) objectExists(resources []*restmapper.APIGroupResources, config *clusterQueryClientConfig) (obj *unstructured.Unstructured, err error) {
-	gvk := q.object.GroupVersionKind()
-	gk := schema.GroupKind{Group: gvk.Group, Kind: gvk.Kind}
+func (q *QueryObject) objectExists(ctx context.Context, rm meta.RESTMapper, config *clusterQueryClientConfig) (*unstructured.Unstructured, error) {
+	return getObject(ctx, rm, config, q.object)
+}
 
-	rm := restmapper.NewDiscoveryRESTMapper(resources)
-	mapping, err := rm.RESTMapping(gk, gvk.Version)
+// getObject maps the object reference to its resource and gets the object with the dynamic client.
+// It returns nil if the object does not exist.
+func getObject(ctx context.Context, rm meta.RESTMapper, config *clusterQueryClientConfig, ref *corev1.ObjectReference) (*unstructured.Unstructured, error) {
+	dr, err := resourceInterface(rm, config, ref)
 	if err != nil {
 		return nil, err
 	}
 
-	var dr dynamic.ResourceInterface
-	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
-		dr = config.dynamicClient.Resource(mapping.Resource).Namespace(q.object.Namespace)
-	} else {
-		dr = config.dynamicClient.Resource(mapping.Resource)
-	}
-
-	o, err := dr.Get(context.Background(), q.object.Name, metav1.GetOptions{})
+	o, err := dr.Get(ctx, ref.Name, metav1.GetOptions{})
 	if err != nil {
 		if errors.IsNotFound(err) {
 			return nil, nil
@@ -134,25 +204,103 @@ func (q *QueryObject) objectExists(resources []*restmapper.APIGroupResources, co
 	return o, nil
 }
 
+// resourceInterface maps the kind of the object reference to its resource and returns the dynamic client for it,
+// in the namespace of the object reference for namespaced resources.
+func resourceInterface(rm meta.RESTMapper, config *clusterQueryClientConfig, ref *corev1.ObjectReference) (dynamic.ResourceInterface, error) {
+	gvr, namespaced, err := objectResource(rm, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	if namespaced {
+		return config.dynamicClient.Resource(gvr).Namespace(ref.Namespace), nil
+	}
+	return config.dynamicClient.Resource(gvr), nil
+}
+
+// objectResource maps the kind of the object reference to its resource and returns whether the resource is namespaced.
+// An empty apiVersion defaults to v1.
+func objectResource(rm meta.RESTMapper, ref *corev1.ObjectReference) (schema.GroupVersionResource, bool, error) {
+	gvk := ref.GroupVersionKind()
+	if ref.APIVersion == "" {
+		gvk.Version = "v1"
+	}
+	gk := schema.GroupKind{Group: gvk.Group, Kind: gvk.Kind}
+
+	mapping, err := rm.RESTMapping(gk, gvk.Version)
+	if err != nil {
+		return schema.GroupVersionResource{}, false, err
+	}
+	return mapping.Resource, mapping.Scope.Name() == meta.RESTScopeNameNamespace, nil
+}
+
+// validate ensures the object reference has a kind, and the field predicates, selectors and count constraints are valid.
+func (q *QueryObject) validate() error {
+	if err := validateObjectReference(q.object); err != nil {
+		return fmt.Errorf("object query %q: %w", q.name, err)
+	}
+	for _, p := range q.predicates {
+		if err := p.validate(); err != nil {
+			return fmt.Errorf("invalid field predicate %q of object query %q: %w", p.path, q.name, err)
+		}
+	}
+	if _, err := labels.Parse(q.labelSelector); err != nil {
+		return fmt.Errorf("invalid label selector of object query %q: %w", q.name, err)
+	}
+	if _, err := fields.ParseSelector(q.fieldSelector); err != nil {
+		return fmt.Errorf("invalid field selector of object query %q: %w", q.name, err)
+	}
+	if q.listMode() {
+		if err := q.validateCount(); err != nil {
+			return fmt.Errorf("object query %q: %w", q.name, err)
+		}
+	}
+	return nil
+}
+
+// validateObjectReference ensures the object reference has a kind and a valid apiVersion, which is required to map
+// the kind to its resource. An empty apiVersion is valid and defaults to v1.
+func validateObjectReference(ref *corev1.ObjectReference) error {
+	if ref == nil {
+		return fmt.Errorf("object reference is required")
+	}
+	if ref.Kind == "" {
+		return fmt.Errorf("object reference requires a kind")
+	}
+	if _, err := schema.ParseGroupVersion(ref.APIVersion); err != nil {
+		return fmt.Errorf("invalid apiVersion of object reference: %w", err)
+	}
+	return nil
+}
+
 func (q *QueryObject) checkAnnotations(u *unstructured.Unstructured) bool {
+	return len(q.unmatchedAnnotationKeys(u)) == 0
+}
+
+// unmatchedAnnotationKeys returns the keys of the annotations that are missing, unexpected or have a different value.
+func (q *QueryObject) unmatchedAnnotationKeys(u *unstructured.Unstructured) []string {
+	var unmatched []string
 	for _, v := range q.annotations {
 		val, ok := u.GetAnnotations()[v.key]
 		if ok {
-			if !v.presence {
-				return false
-			}
-			if v.value != "" && v.value != val {
-				return false
+			if !v.presence || (v.value != "" && v.value != val) {
+				unmatched = append(unmatched, v.key)
 			}
 		} else if v.presence {
-			return false
+			unmatched = append(unmatched, v.key)
 		}
 	}
-	return true
+	return unmatched
 }
 
 // Reason for failures, in a standard structure
 func (q *QueryObject) Reason() string {
+	if q.listMode() {
+		return fmt.Sprintf("kind=%s count=%d %s status=unmatched presence=%t", q.object.Kind, q.count, q.countRange(), q.presence)
+	}
+	if len(q.unmatchedPredicates) != 0 {
+		return fmt.Sprintf("kind=%s predicates=%v status=unmatched presence=%t", q.object.Kind, q.unmatchedPredicates, q.presence)
+	}
 	return fmt.Sprintf("kind=%s status=unmatched presence=%t", q.object.Kind, q.presence)
 }
 
@@ -166,6 +314,18 @@ func (q *QueryObject) annotationsMap(presence bool) map[string]string {
 	return annotations
 }
 
+func (q *QueryObject) fieldPredicates() []corev1alpha2.FieldPredicate {
+	var predicates []corev1alpha2.FieldPredicate
+	for _, p := range q.predicates {
+		predicates = append(predicates, corev1alpha2.FieldPredicate{
+			Path:     p.path,
+			Operator: corev1alpha2.FieldPredicateOperator(p.operator),
+			Values:   p.values,
+		})
+	}
+	return predicates
+}
+
 type resourceAnnotation struct {
 	key      string
 	value    string
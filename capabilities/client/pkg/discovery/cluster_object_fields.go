@@ -0,0 +1,229 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package discovery
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// FieldOperator is the operator used by a field predicate to compare the value found at a path in an object.
+type FieldOperator string
+
+const (
+	// FieldExists matches when the path resolves to at least one value.
+	FieldExists = FieldOperator("Exists")
+	// FieldDoesNotExist matches when the path does not resolve to any value.
+	FieldDoesNotExist = FieldOperator("DoesNotExist")
+	// FieldEquals matches when a value at the path equals the single operand.
+	FieldEquals = FieldOperator("Equals")
+	// FieldNotEquals matches when no value at the path equals the single operand.
+	FieldNotEquals = FieldOperator("NotEquals")
+	// FieldIn matches when a value at the path equals one of the operands.
+	FieldIn = FieldOperator("In")
+	// FieldNotIn matches when no value at the path equals any of the operands.
+	FieldNotIn = FieldOperator("NotIn")
+	// FieldGreaterThan matches when a numeric value at the path is greater than the single operand.
+	FieldGreaterThan = FieldOperator("GreaterThan")
+	// FieldGreaterThanOrEqual matches when a numeric value at the path is greater than or equal to the single operand.
+	FieldGreaterThanOrEqual = FieldOperator("GreaterThanOrEqual")
+	// FieldLessThan matches when a numeric value at the path is less than the single operand.
+	FieldLessThan = FieldOperator("LessThan")
+	// FieldLessThanOrEqual matches when a numeric value at the path is less than or equal to the single operand.
+	FieldLessThanOrEqual = FieldOperator("LessThanOrEqual")
+)
+
+// fieldPredicate asserts on the value(s) found at a JSONPath in an object.
+type fieldPredicate struct {
+	path     string
+	operator FieldOperator
+	values   []string
+}
+
+// String renders the predicate in the form used in query reasons, e.g. "spec.replicas GreaterThanOrEqual [2]".
+func (p fieldPredicate) String() string {
+	if len(p.values) == 0 {
+		return fmt.Sprintf("%s %s", p.path, p.operator)
+	}
+	return fmt.Sprintf("%s %s %v", p.path, p.operator, p.values)
+}
+
+// checkFieldPredicates evaluates all the field predicates against the object and returns the ones that did not match.
+func (q *QueryObject) checkFieldPredicates(u *unstructured.Unstructured) ([]string, error) {
+	var unmatched []string
+	for _, p := range q.predicates {
+		ok, err := p.matches(u)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate field predicate %q: %w", p.String(), err)
+		}
+		if !ok {
+			unmatched = append(unmatched, p.String())
+		}
+	}
+	return unmatched, nil
+}
+
+// matches returns true if the object satisfies the predicate.
+func (p fieldPredicate) matches(u *unstructured.Unstructured) (bool, error) {
+	if err := p.validate(); err != nil {
+		return false, err
+	}
+
+	found, err := valuesAtPath(u, p.path)
+	if err != nil {
+		return false, err
+	}
+
+	switch p.operator {
+	case FieldExists:
+		return len(found) != 0, nil
+	case FieldDoesNotExist:
+		return len(found) == 0, nil
+	case FieldEquals, FieldIn:
+		return containsAny(found, p.values), nil
+	case FieldNotEquals, FieldNotIn:
+		return !containsAny(found, p.values), nil
+	}
+
+	// Ordering operators compare numerically. A value that is not a number never satisfies the predicate.
+	want, _ := strconv.ParseFloat(p.values[0], 64)
+	for _, s := range found {
+		got, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			continue
+		}
+		if compareFloat(p.operator, got, want) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// validate ensures the predicate has the right number and kind of operands for its operator.
+func (p fieldPredicate) validate() error {
+	if strings.TrimSpace(p.path) == "" {
+		return fmt.Errorf("path must not be empty")
+	}
+	switch p.operator {
+	case FieldExists, FieldDoesNotExist:
+		if len(p.values) != 0 {
+			return fmt.Errorf("operator %s does not take values", p.operator)
+		}
+	case FieldIn, FieldNotIn:
+		if len(p.values) == 0 {
+			return fmt.Errorf("operator %s requires at least one value", p.operator)
+		}
+	case FieldEquals, FieldNotEquals:
+		if len(p.values) != 1 {
+			return fmt.Errorf("operator %s requires exactly one value", p.operator)
+		}
+	case FieldGreaterThan, FieldGreaterThanOrEqual, FieldLessThan, FieldLessThanOrEqual:
+		if len(p.values) != 1 {
+			return fmt.Errorf("operator %s requires exactly one value", p.operator)
+		}
+		if _, err := strconv.ParseFloat(p.values[0], 64); err != nil {
+			return fmt.Errorf("operator %s requires a numeric value: %w", p.operator, err)
+		}
+	default:
+		return fmt.Errorf("unknown operator %q", p.operator)
+	}
+	return nil
+}
+
+// valuesAtPath returns the string representations of all the values found at a JSONPath in the object.
+func valuesAtPath(u *unstructured.Unstructured, path string) ([]string, error) {
+	return valuesAtJSONPath(u.UnstructuredContent(), path)
+}
+
+// valuesAtJSONPath returns the string representations of all the values found at a JSONPath in decoded JSON content.
+func valuesAtJSONPath(content interface{}, path string) ([]string, error) {
+	jp := jsonpath.New("field").AllowMissingKeys(true)
+	if err := jp.Parse(relaxedJSONPath(path)); err != nil {
+		return nil, err
+	}
+	results, err := jp.FindResults(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var values []string
+	for _, result := range results {
+		for _, v := range result {
+			s, ok, err := valueString(v)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				values = append(values, s)
+			}
+		}
+	}
+	return values, nil
+}
+
+// relaxedJSONPath turns "spec.replicas" and ".spec.replicas" into the "{.spec.replicas}" template form.
+func relaxedJSONPath(path string) string {
+	path = strings.TrimSpace(path)
+	if strings.HasPrefix(path, "{") {
+		return path
+	}
+	if !strings.HasPrefix(path, ".") && !strings.HasPrefix(path, "$") {
+		path = "." + path
+	}
+	return "{" + path + "}"
+}
+
+// valueString renders a value found by JSONPath as a string. Scalars are rendered as-is; maps and slices as JSON.
+func valueString(v reflect.Value) (string, bool, error) {
+	for v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "", false, nil
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return "", false, nil
+	}
+	switch v.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array:
+		b, err := json.Marshal(v.Interface())
+		if err != nil {
+			return "", false, err
+		}
+		return string(b), true, nil
+	}
+	return fmt.Sprint(v.Interface()), true, nil
+}
+
+// containsAny returns true if any of the found values equals any of the wanted values.
+func containsAny(found, want []string) bool {
+	for _, f := range found {
+		for _, w := range want {
+			if f == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func compareFloat(operator FieldOperator, got, want float64) bool {
+	switch operator {
+	case FieldGreaterThan:
+		return got > want
+	case FieldGreaterThanOrEqual:
+		return got >= want
+	case FieldLessThan:
+		return got < want
+	case FieldLessThanOrEqual:
+		return got <= want
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package discovery
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+// listPageSize is the number of objects fetched per request when objects are counted, so that kinds with many objects
+// are not listed in a single response.
+const listPageSize = 500
+
+// WithLabelSelector counts the objects of the kind that match the label selector, e.g. "app=nginx", instead of
+// getting the named object. The objects are listed in the namespace of the object reference, or in all namespaces
+// when it is empty. Only the objects with the name of the object reference are counted when it has one. Annotations
+// and field predicates filter the counted objects.
+func (q *QueryObject) WithLabelSelector(selector string) *QueryObject {
+	q.labelSelector = selector
+	return q
+}
+
+// WithFieldSelector counts the objects of the kind that match the field selector, e.g. "metadata.name=default",
+// instead of getting the named object. See WithLabelSelector.
+func (q *QueryObject) WithFieldSelector(selector string) *QueryObject {
+	q.fieldSelector = selector
+	return q
+}
+
+// WithMinCount requires at least n objects to match. It defaults to one when the objects are counted.
+func (q *QueryObject) WithMinCount(n int) *QueryObject {
+	q.minCount = &n
+	return q
+}
+
+// WithMaxCount requires at most n objects to match, e.g. WithMinCount(1).WithMaxCount(1) for exactly one object.
+func (q *QueryObject) WithMaxCount(n int) *QueryObject {
+	q.maxCount = &n
+	return q
+}
+
+// listMode returns true if the query counts the objects that match instead of getting a single object by name.
+func (q *QueryObject) listMode() bool {
+	return q.labelSelector != "" || q.fieldSelector != "" || q.minCount != nil || q.maxCount != nil || q.object.Name == ""
+}
+
+// countObjects lists the objects that match the selectors and succeeds if the number of objects that match the
+// annotations and field predicates is within the count constraints.
+func (q *QueryObject) countObjects(ctx context.Context, rm meta.RESTMapper, config *clusterQueryClientConfig) (bool, error) {
+	dr, err := resourceInterface(rm, config, q.object)
+	if err != nil {
+		return false, err
+	}
+	opts := metav1.ListOptions{
+		LabelSelector: q.labelSelector,
+		FieldSelector: q.listFieldSelector(),
+		Limit:         listPageSize,
+	}
+	for {
+		list, err := dr.List(ctx, opts)
+		if err != nil {
+			return false, err
+		}
+		for i := range list.Items {
+			u := &list.Items[i]
+			// The name is also checked here, since field selectors are not supported by every client, e.g. of
+			// snapshots.
+			if q.object.Name != "" && u.GetName() != q.object.Name {
+				continue
+			}
+			if !q.checkAnnotations(u) {
+				continue
+			}
+			unmatched, err := q.checkFieldPredicates(u)
+			if err != nil {
+				return false, err
+			}
+			if len(unmatched) == 0 {
+				q.count++
+			}
+		}
+		if opts.Continue = list.GetContinue(); opts.Continue == "" {
+			break
+		}
+	}
+
+	inRange := q.count >= q.minimum() && (q.maxCount == nil || q.count <= *q.maxCount)
+	return inRange == q.presence, nil
+}
+
+// listFieldSelector returns the field selector of the query, which also selects the name of the object reference when
+// it has one.
+func (q *QueryObject) listFieldSelector() string {
+	if q.object.Name == "" {
+		return q.fieldSelector
+	}
+	name := fields.OneTermEqualSelector("metadata.name", q.object.Name).String()
+	if q.fieldSelector == "" {
+		return name
+	}
+	return q.fieldSelector + "," + name
+}
+
+func (q *QueryObject) minimum() int {
+	if q.minCount == nil {
+		return 1
+	}
+	return *q.minCount
+}
+
+// validateCount ensures the count constraints are not negative and the minimum is not greater than the maximum.
+func (q *QueryObject) validateCount() error {
+	if q.minimum() < 0 {
+		return fmt.Errorf("minimum count must not be negative")
+	}
+	if q.maxCount != nil {
+		if *q.maxCount < 0 {
+			return fmt.Errorf("maximum count must not be negative")
+		}
+		if *q.maxCount < q.minimum() {
+			return fmt.Errorf("minimum count %d must not be greater than maximum count %d", q.minimum(), *q.maxCount)
+		}
+	}
+	return nil
+}
+
+// countRange renders the count constraints in the form used in query reasons.
+func (q *QueryObject) countRange() string {
+	if q.maxCount == nil {
+		return fmt.Sprintf("minCount=%d", q.minimum())
+	}
+	return fmt.Sprintf("minCount=%d maxCount=%d", q.minimum(), *q.maxCount)
+}
@@ -0,0 +1,162 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package discovery
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	testapigroup "k8s.io/apimachinery/pkg/apis/testapigroup/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/dynamic"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestObjectCountQueries(t *testing.T) {
+	carpObject := func(name, namespace string, labels, annotations map[string]string) *testapigroup.Carp {
+		return &testapigroup.Carp{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels, Annotations: annotations}}
+	}
+	objs := []runtime.Object{
+		carpObject("koi", "pond", map[string]string{"color": "orange"}, map[string]string{"default": "true"}),
+		carpObject("goldfish", "pond", map[string]string{"color": "orange"}, nil),
+		carpObject("grass", "lake", map[string]string{"color": "green"}, nil),
+	}
+	carps := func(namespace string) *corev1.ObjectReference {
+		return &corev1.ObjectReference{Kind: "Carp", Namespace: namespace, APIVersion: testapigroup.SchemeGroupVersion.String()}
+	}
+
+	testCases := []struct {
+		description string
+		query       *QueryObject
+		want        bool
+		reason      string
+		err         string
+	}{
+		{
+			description: "any object of the kind in all namespaces",
+			query:       Object("carps", carps("")),
+			want:        true,
+		},
+		{
+			description: "at least one with label",
+			query:       Object("orange", carps("")).WithLabelSelector("color=orange"),
+			want:        true,
+		},
+		{
+			description: "at least one with label in namespace",
+			query:       Object("green", carps("pond")).WithLabelSelector("color=green"),
+			want:        false,
+			reason:      "kind=Carp count=0 minCount=1 status=unmatched presence=true",
+		},
+		{
+			description: "exactly one with annotation",
+			query:       Object("default", carps("")).WithAnnotations(map[string]string{"default": "true"}).WithMinCount(1).WithMaxCount(1),
+			want:        true,
+		},
+		{
+			description: "more than the maximum",
+			query:       Object("orange", carps("")).WithLabelSelector("color=orange").WithMaxCount(1),
+			want:        false,
+			reason:      "kind=Carp count=2 minCount=1 maxCount=1 status=unmatched presence=true",
+		},
+		{
+			description: "none allowed",
+			query:       Object("blue", carps("")).WithLabelSelector("color=blue").WithMinCount(0).WithMaxCount(0),
+			want:        true,
+		},
+		{
+			description: "field predicates filter the count",
+			query:       Object("koi", carps("")).WithFieldPredicate("metadata.name", FieldEquals, "koi").WithMinCount(1).WithMaxCount(1),
+			want:        true,
+		},
+		{
+			description: "only the named object is counted",
+			query:       Object("goldfish", &corev1.ObjectReference{Kind: "Carp", Name: "goldfish", Namespace: "pond", APIVersion: testapigroup.SchemeGroupVersion.String()}).WithLabelSelector("color=orange").WithMaxCount(1),
+			want:        true,
+		},
+		{
+			description: "minimum greater than maximum",
+			query:       Object("carps", carps("")).WithMinCount(2).WithMaxCount(1),
+			err:         "minimum count 2 must not be greater than maximum count 1",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			c, err := NewFakeClusterQueryClient(apiResources, testScheme, objs)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := c.Query(tc.query).Execute()
+			if err != nil {
+				if tc.err == "" || !strings.Contains(err.Error(), tc.err) {
+					t.Errorf("want error containing %q, got: %v", tc.err, err)
+				}
+			} else if tc.err != "" {
+				t.Errorf("want error containing %q, got none", tc.err)
+			}
+			if got != tc.want {
+				t.Errorf("got=%t, want=%t, reason: %s", got, tc.want, tc.query.Reason())
+			}
+			if tc.reason != "" && tc.query.Reason() != tc.reason {
+				t.Errorf("reason:\n got: %s\nwant: %s", tc.query.Reason(), tc.reason)
+			}
+		})
+	}
+}
+
+// pagedResource serves the pages of objects of a resource, keyed by their continue token.
+type pagedResource struct {
+	dynamic.NamespaceableResourceInterface
+	t     *testing.T
+	pages map[string][]string
+}
+
+func (r *pagedResource) Namespace(string) dynamic.ResourceInterface {
+	return r
+}
+
+func (r *pagedResource) List(_ context.Context, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	if opts.Limit != listPageSize {
+		r.t.Errorf("want pages of %d objects, got limit %d", listPageSize, opts.Limit)
+	}
+	list := &unstructured.UnstructuredList{}
+	for _, name := range r.pages[opts.Continue] {
+		list.Items = append(list.Items, *toUnstructured(r.t, &testapigroup.Carp{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "pond"}}))
+	}
+	if opts.Continue == "" {
+		list.SetContinue("next")
+	}
+	return list, nil
+}
+
+// pagedDynamicClient is a dynamic client whose resources are served in pages.
+type pagedDynamicClient struct {
+	dynamic.Interface
+	resource *pagedResource
+}
+
+func (c *pagedDynamicClient) Resource(schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return c.resource
+}
+
+func TestObjectCountQueriesArePaginated(t *testing.T) {
+	dynamicClient := &pagedDynamicClient{resource: &pagedResource{t: t, pages: map[string][]string{"": {"koi", "goldfish"}, "next": {"grass"}}}}
+	c, err := NewClusterQueryClient(dynamicClient, &fakediscovery.FakeDiscovery{Fake: &k8stesting.Fake{Resources: apiResources}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := Object("carps", &corev1.ObjectReference{Kind: "Carp", APIVersion: testapigroup.SchemeGroupVersion.String()}).WithMinCount(3)
+	if got, err := c.Query(query).Execute(); err != nil || !got {
+		t.Errorf("want all the pages to be counted, got: %t, %v, reason: %s", got, err, query.Reason())
+	}
+}
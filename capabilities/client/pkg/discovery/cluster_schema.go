@@ -4,11 +4,24 @@
 package discovery
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"reflect"
+	"sort"
 	"strings"
+
+	"gopkg.in/yaml.v3"
 )
 
-// Schema represents any openapi schema that may exist on a cluster
+// Schema represents any openapi schema that may exist on a cluster.
+// The partial schema is YAML or JSON and is matched as a subset of an OpenAPI definition, e.g.
+//
+//	properties:
+//	  spec:
+//	    properties:
+//	      replicas:
+//	        type: integer
 func Schema(name, partialSchema string) *QueryPartialSchema {
 	return &QueryPartialSchema{
 		schema:   partialSchema,
@@ -17,12 +30,16 @@ func Schema(name, partialSchema string) *QueryPartialSchema {
 	}
 }
 
-// QueryPartialSchema allows for matching a partial schema
-// currently this is quite naive string comparison but will be updated fpr better validation
+// QueryPartialSchema allows for matching a partial schema against the definitions in the cluster's OpenAPI documents.
 type QueryPartialSchema struct {
-	schema   string
-	name     string
-	presence bool
+	schema     string
+	name       string
+	presence   bool
+	definition string
+	openAPIV3  bool
+	v3Paths    []string
+
+	unmatchedField string
 }
 
 // Name is the name of the query.
@@ -30,27 +47,270 @@ func (q *QueryPartialSchema) Name() string {
 	return q.name
 }
 
+// WithDefinition restricts the match to the named definition, e.g. "io.k8s.api.apps.v1.Deployment".
+// This method can be omitted to match any definition.
+func (q *QueryPartialSchema) WithDefinition(definition string) *QueryPartialSchema {
+	q.definition = definition
+	return q
+}
+
+// WithOpenAPIV3 matches against the OpenAPI v3 documents served at /openapi/v3 instead of the OpenAPI v2 document.
+// The documents can be restricted to a set of paths, e.g. "apis/apps/v1"; all documents are searched otherwise.
+func (q *QueryPartialSchema) WithOpenAPIV3(paths ...string) *QueryPartialSchema {
+	q.openAPIV3 = true
+	q.v3Paths = paths
+	return q
+}
+
 // Run the partial query match
 func (q *QueryPartialSchema) Run(config *clusterQueryClientConfig) (bool, error) {
-	doc, err := config.discoveryClientset.OpenAPISchema()
+	return q.RunContext(context.Background(), config)
+}
+
+// RunContext runs the partial query match. The OpenAPI v2 document is fetched without a context.
+func (q *QueryPartialSchema) RunContext(ctx context.Context, config *clusterQueryClientConfig) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	q.unmatchedField = ""
+
+	partial, err := q.parse()
 	if err != nil {
 		return false, err
 	}
-	// TODO: fix this with better validation means
-	if strings.Contains(doc.String(), q.schema) {
+
+	var definitions map[string]interface{}
+	if q.openAPIV3 {
+		definitions, err = openAPIV3Definitions(ctx, config, q.v3Paths)
+	} else {
+		definitions, err = openAPIV2Definitions(config)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if q.definition != "" {
+		def, ok := definitions[q.definition]
+		if !ok {
+			q.unmatchedField = q.definition
+			return false, nil
+		}
+		if field, ok := matchSubset(partial, def, q.definition); !ok {
+			q.unmatchedField = field
+			return false, nil
+		}
 		return true, nil
 	}
+
+	// Match any definition. Names are sorted so the reported field is stable across runs.
+	names := make([]string, 0, len(definitions))
+	for name := range definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	deepest := -1
+	for _, name := range names {
+		field, ok := matchSubset(partial, definitions[name], name)
+		if ok {
+			return true, nil
+		}
+		// Report the mismatch from the definition that matched the deepest.
+		if depth := strings.Count(strings.TrimPrefix(field, name), "."); depth > deepest {
+			deepest = depth
+			q.unmatchedField = field
+		}
+	}
 	return false, nil
 }
 
+// validate ensures the partial schema parses as a YAML or JSON object.
+func (q *QueryPartialSchema) validate() error {
+	_, err := q.parse()
+	return err
+}
+
+func (q *QueryPartialSchema) parse() (map[string]interface{}, error) {
+	partial := make(map[string]interface{})
+	if err := yaml.Unmarshal([]byte(q.schema), &partial); err != nil {
+		return nil, fmt.Errorf("partial schema must be a YAML or JSON object: %w", err)
+	}
+	if len(partial) == 0 {
+		return nil, fmt.Errorf("partial schema must not be empty")
+	}
+	return partial, nil
+}
+
+// openAPIV2Definitions returns the definitions of the cluster's OpenAPI v2 document keyed by name.
+func openAPIV2Definitions(config *clusterQueryClientConfig) (map[string]interface{}, error) {
+	return config.openAPIDefinitions(openAPIV2CacheKey, func() (map[string]interface{}, error) {
+		doc, err := config.discovery().OpenAPISchema()
+		if err != nil {
+			return nil, err
+		}
+		b, err := doc.YAMLValue("")
+		if err != nil {
+			return nil, err
+		}
+		return definitionsAt(b, "definitions")
+	})
+}
+
+// openAPIV3Discovery is the index served at /openapi/v3 that lists the OpenAPI v3 document of each group version.
+type openAPIV3Discovery struct {
+	Paths map[string]struct {
+		ServerRelativeURL string `json:"serverRelativeURL"`
+	} `json:"paths"`
+}
+
+// openAPIV3Definitions returns the component schemas of the cluster's OpenAPI v3 documents keyed by name.
+// The documents are fetched as JSON through the discovery REST client, which is stable across client-go versions.
+func openAPIV3Definitions(ctx context.Context, config *clusterQueryClientConfig, paths []string) (map[string]interface{}, error) {
+	restClient := config.discovery().RESTClient()
+	if restClient == nil {
+		return nil, fmt.Errorf("discovery client does not support OpenAPI v3")
+	}
+
+	data, err := restClient.Get().AbsPath("/openapi/v3").Do(ctx).Raw()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get OpenAPI v3 discovery: %w", err)
+	}
+	index := &openAPIV3Discovery{}
+	if err := json.Unmarshal(data, index); err != nil {
+		return nil, fmt.Errorf("failed to decode OpenAPI v3 discovery: %w", err)
+	}
+
+	if len(paths) == 0 {
+		for path := range index.Paths {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+	}
+
+	definitions := make(map[string]interface{})
+	for _, path := range paths {
+		gv, ok := index.Paths[strings.TrimPrefix(path, "/")]
+		if !ok {
+			continue
+		}
+		// Documents are cached by their URL, which changes with the document hash when the schema changes.
+		defs, err := config.openAPIDefinitions(gv.ServerRelativeURL, func() (map[string]interface{}, error) {
+			doc, err := restClient.Get().RequestURI(gv.ServerRelativeURL).SetHeader("Accept", "application/json").Do(ctx).Raw()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get OpenAPI v3 document for %s: %w", path, err)
+			}
+			// JSON is valid YAML, so the document decodes to the same types as the partial schema.
+			return definitionsAt(doc, "components", "schemas")
+		})
+		if err != nil {
+			return nil, err
+		}
+		for name, def := range defs {
+			definitions[name] = def
+		}
+	}
+	return definitions, nil
+}
+
+// definitionsAt decodes a YAML document and returns the map found at the given keys.
+func definitionsAt(b []byte, keys ...string) (map[string]interface{}, error) {
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	for _, k := range keys {
+		next, ok := doc[k].(map[string]interface{})
+		if !ok {
+			return map[string]interface{}{}, nil
+		}
+		doc = next
+	}
+	return doc, nil
+}
+
+// matchSubset returns true if partial is a subset of target. Maps match when every key in partial matches the same
+// key in target, lists match when every item in partial matches some item in target and scalars must be equal.
+// When there is no match, the path of the first field that did not match is returned.
+func matchSubset(partial, target interface{}, path string) (string, bool) {
+	switch p := partial.(type) {
+	case map[string]interface{}:
+		t, ok := target.(map[string]interface{})
+		if !ok {
+			return path, false
+		}
+		keys := make([]string, 0, len(p))
+		for k := range p {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			tv, ok := t[k]
+			if !ok {
+				return path + "." + k, false
+			}
+			if field, ok := matchSubset(p[k], tv, path+"."+k); !ok {
+				return field, false
+			}
+		}
+		return "", true
+	case []interface{}:
+		t, ok := target.([]interface{})
+		if !ok {
+			return path, false
+		}
+		for i, pv := range p {
+			var matched bool
+			for _, tv := range t {
+				if _, ok := matchSubset(pv, tv, path); ok {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return fmt.Sprintf("%s[%d]", path, i), false
+			}
+		}
+		return "", true
+	}
+	if !scalarEqual(partial, target) {
+		return path, false
+	}
+	return "", true
+}
+
+// scalarEqual compares scalars, treating numbers of different types as equal when their values are, e.g. 1 and 1.0.
+func scalarEqual(a, b interface{}) bool {
+	fa, aok := toFloat(a)
+	fb, bok := toFloat(b)
+	if aok && bok {
+		return fa == fb
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
 // QueryFailure exposes detail on the query failure for consumers to parse
 type QueryFailure struct {
 	Target   QueryTarget
 	Presence bool
 }
 
-// Reason returns  the query failure, of it failed
-// todo: this should be a results{} struct
+// Reason returns the query failure, if it failed
 func (q *QueryPartialSchema) Reason() string {
+	if q.unmatchedField != "" {
+		return fmt.Sprintf("method=partial-schema name=%s field=%s status=unmatched presence=%t", q.name, q.unmatchedField, q.presence)
+	}
 	return fmt.Sprintf("method=partial-schema name=%s status=unmatched presence=%t", q.name, q.presence)
 }
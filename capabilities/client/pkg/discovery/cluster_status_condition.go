@@ -0,0 +1,175 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// StatusCondition represents a status condition of an object that could exist on a cluster, e.g. Ready=True on a
+// PackageInstall or Available=True on a Deployment. The condition must have status True unless specified otherwise
+// with WithStatus.
+func StatusCondition(queryName string, obj *corev1.ObjectReference, conditionType string) *QueryStatusCondition {
+	return &QueryStatusCondition{
+		name:          queryName,
+		object:        obj,
+		conditionType: conditionType,
+		status:        metav1.ConditionTrue,
+	}
+}
+
+// QueryStatusCondition allows for querying the status conditions of an object.
+type QueryStatusCondition struct {
+	name          string
+	object        *corev1.ObjectReference
+	conditionType string
+	status        metav1.ConditionStatus
+	minDuration   time.Duration
+
+	objectFound     bool
+	observedStatus  string
+	remainingPeriod time.Duration
+}
+
+// Name is the name of the query.
+func (q *QueryStatusCondition) Name() string {
+	return q.name
+}
+
+// WithStatus sets the status the condition must have, e.g. metav1.ConditionFalse.
+func (q *QueryStatusCondition) WithStatus(status metav1.ConditionStatus) *QueryStatusCondition {
+	q.status = status
+	return q
+}
+
+// WithMinDuration requires the condition to have had the status for at least the duration, according to its
+// lastTransitionTime. A condition without a lastTransitionTime never matches.
+func (q *QueryStatusCondition) WithMinDuration(d time.Duration) *QueryStatusCondition {
+	q.minDuration = d
+	return q
+}
+
+// Run the status condition query.
+func (q *QueryStatusCondition) Run(config *clusterQueryClientConfig) (bool, error) {
+	return q.RunContext(context.Background(), config)
+}
+
+// RunContext runs the status condition query using the context for the API calls.
+func (q *QueryStatusCondition) RunContext(ctx context.Context, config *clusterQueryClientConfig) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	q.objectFound, q.observedStatus, q.remainingPeriod = false, "", 0
+
+	if err := q.validate(); err != nil {
+		return false, err
+	}
+
+	rm, err := config.restMapper()
+	if err != nil {
+		return false, err
+	}
+	u, err := getObject(ctx, rm, config, q.object)
+	if err != nil {
+		return false, err
+	}
+	if u == nil {
+		return false, nil
+	}
+	q.objectFound = true
+
+	condition, err := findStatusCondition(u, q.conditionType)
+	if err != nil {
+		return false, err
+	}
+	if condition == nil {
+		return false, nil
+	}
+	q.observedStatus = string(condition.Status)
+	if condition.Status != q.status {
+		return false, nil
+	}
+
+	if q.minDuration > 0 {
+		if condition.LastTransitionTime.IsZero() {
+			q.remainingPeriod = q.minDuration
+			return false, nil
+		}
+		elapsed := config.now().Sub(condition.LastTransitionTime.Time)
+		if elapsed < q.minDuration {
+			q.remainingPeriod = q.minDuration - elapsed
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// validate ensures the object reference names an object of a kind, and the condition type is set.
+func (q *QueryStatusCondition) validate() error {
+	if err := validateObjectReference(q.object); err != nil {
+		return fmt.Errorf("status condition query %q: %w", q.name, err)
+	}
+	if q.object.Name == "" {
+		return fmt.Errorf("status condition query %q requires the name of an object", q.name)
+	}
+	if q.conditionType == "" {
+		return fmt.Errorf("status condition query %q requires a condition type", q.name)
+	}
+	return nil
+}
+
+// RemainingDuration returns how much longer the condition must keep the expected status to satisfy the minimum
+// duration, as of the last run. It is zero unless the condition has the expected status for less than the duration.
+// The remaining duration is not part of the reason, which stays the same while the condition keeps its status.
+func (q *QueryStatusCondition) RemainingDuration() time.Duration {
+	return q.remainingPeriod
+}
+
+// Reason for failures, in a standard structure
+func (q *QueryStatusCondition) Reason() string {
+	switch {
+	case !q.objectFound:
+		return fmt.Sprintf("kind=%s object=%s status=unmatched presence=true", q.object.Kind, q.object.Name)
+	case q.remainingPeriod > 0:
+		return fmt.Sprintf("kind=%s condition=%s=%s minDuration=%s status=unmatched presence=true", q.object.Kind, q.conditionType, q.status, q.minDuration)
+	}
+	return fmt.Sprintf("kind=%s condition=%s=%s observed=%q status=unmatched presence=true", q.object.Kind, q.conditionType, q.status, q.observedStatus)
+}
+
+// findStatusCondition returns the condition of the given type in status.conditions of the object, or nil if there is
+// no such condition.
+func findStatusCondition(u *unstructured.Unstructured, conditionType string) (*metav1.Condition, error) {
+	conditions, found, err := unstructured.NestedSlice(u.Object, "status", "conditions")
+	if err != nil || !found {
+		return nil, err
+	}
+
+	for _, c := range conditions {
+		m, ok := c.(map[string]interface{})
+		if !ok || m["type"] != conditionType {
+			continue
+		}
+		condition := &metav1.Condition{Type: conditionType}
+		status, _, _ := unstructured.NestedString(m, "status")
+		condition.Status = metav1.ConditionStatus(status)
+		condition.Reason, _, _ = unstructured.NestedString(m, "reason")
+		condition.Message, _, _ = unstructured.NestedString(m, "message")
+		if t, _, _ := unstructured.NestedString(m, "lastTransitionTime"); t != "" {
+			parsed, err := time.Parse(time.RFC3339, t)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse lastTransitionTime of condition %s: %w", conditionType, err)
+			}
+			condition.LastTransitionTime = metav1.NewTime(parsed)
+		}
+		return condition, nil
+	}
+	return nil, nil
+}
@@ -4,16 +4,27 @@
 package discovery
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	testapigroup "k8s.io/apimachinery/pkg/apis/testapigroup/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	apitest "k8s.io/apimachinery/pkg/test"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	dynamicFake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
 )
 
 var testScheme = runtime.NewScheme()
@@ -39,8 +50,8 @@ var testGVR = Group("carpResource", testapigroup.SchemeGroupVersion.Group).
 	WithVersions(testapigroup.SchemeGroupVersion.Version).
 	WithResource("carps")
 
-var testPartialSchemaNotFound = Schema("partialSchemaQuery", "partial schema")
-var testPartialSchemaFound = Schema("partialSchemaQuery", "example schema for test")
+var testPartialSchemaNotFound = Schema("partialSchemaQuery", "properties: {spec: {properties: {paused: {type: boolean}}}}")
+var testPartialSchemaFound = Schema("partialSchemaQuery", "properties: {spec: {properties: {replicas: {type: integer}}}}")
 
 var testObjects = []runtime.Object{
 	&testapigroup.Carp{
@@ -85,6 +96,29 @@ func queryClientWithSchema() (*ClusterQueryClient, error) {
 	return NewFakeClusterQueryClientWithSchema(nil, scheme, nil)
 }
 
+// newTestClusterQueryClient returns a ClusterQueryClient over a fake discovery client serving the resources and a fake
+// dynamic client with the objects, whose list kinds are registered for resources that are listed. The dynamic client is
+// returned too, so that tests can change the objects.
+func newTestClusterQueryClient(t *testing.T, resources []*metav1.APIResourceList, listKinds map[schema.GroupVersionResource]string, objects []runtime.Object, opts ...ClusterQueryClientOption) (*ClusterQueryClient, *dynamicFake.FakeDynamicClient) {
+	t.Helper()
+	dynamicClient := dynamicFake.NewSimpleDynamicClientWithCustomListKinds(testScheme, listKinds, objects...)
+	c, err := NewClusterQueryClient(dynamicClient, &fakediscovery.FakeDiscovery{Fake: &k8stesting.Fake{Resources: resources}}, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, dynamicClient
+}
+
+// toUnstructured converts a typed object to an unstructured object, as served by the dynamic client.
+func toUnstructured(t *testing.T, obj runtime.Object) *unstructured.Unstructured {
+	t.Helper()
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &unstructured.Unstructured{Object: u}
+}
+
 func TestClusterQueries(t *testing.T) {
 	testCases := []struct {
 		description       string
@@ -438,3 +472,990 @@ func TestGVRQueries(t *testing.T) {
 		})
 	}
 }
+
+// TestObjectFieldPredicates tests field predicates on object queries.
+func TestObjectFieldPredicates(t *testing.T) {
+	deadline := int64(30)
+	objs := []runtime.Object{
+		&testapigroup.Carp{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test14",
+				Namespace: "testns",
+			},
+			Spec: testapigroup.CarpSpec{
+				ActiveDeadlineSeconds: &deadline,
+				NodeSelector:          map[string]string{"pond": "koi"},
+			},
+			Status: testapigroup.CarpStatus{
+				Phase: "Running",
+				Conditions: []testapigroup.CarpCondition{
+					{Type: "Ready", Status: "True"},
+					{Type: "Hungry", Status: "False"},
+				},
+			},
+		},
+	}
+
+	testClient, err := NewFakeClusterQueryClient(apiResources, testScheme, objs)
+	if err != nil {
+		t.Fatalf("initiating test client: %v", err)
+	}
+
+	testCases := []struct {
+		description string
+		query       *QueryObject
+		want        bool
+		err         string
+	}{
+		{
+			description: "numeric field greater than or equal",
+			query:       Object("test", &carp).WithFieldPredicate("spec.activeDeadlineSeconds", FieldGreaterThanOrEqual, "30"),
+			want:        true,
+		},
+		{
+			description: "numeric field less than",
+			query:       Object("test", &carp).WithFieldPredicate("spec.activeDeadlineSeconds", FieldLessThan, "30"),
+			want:        false,
+		},
+		{
+			description: "string field equals with braces",
+			query:       Object("test", &carp).WithFieldPredicate("{.status.phase}", FieldEquals, "Running"),
+			want:        true,
+		},
+		{
+			description: "string field in",
+			query:       Object("test", &carp).WithFieldPredicate(".status.phase", FieldIn, "Pending", "Running"),
+			want:        true,
+		},
+		{
+			description: "string field not in",
+			query:       Object("test", &carp).WithFieldPredicate("status.phase", FieldNotIn, "Pending", "Running"),
+			want:        false,
+		},
+		{
+			description: "filter expression on conditions",
+			query:       Object("test", &carp).WithFieldPredicate(`status.conditions[?(@.type=="Ready")].status`, FieldEquals, "True"),
+			want:        true,
+		},
+		{
+			description: "field exists",
+			query:       Object("test", &carp).WithFieldPredicate("spec.nodeSelector.pond", FieldExists),
+			want:        true,
+		},
+		{
+			description: "field does not exist",
+			query:       Object("test", &carp).WithFieldPredicate("spec.hostname", FieldDoesNotExist),
+			want:        true,
+		},
+		{
+			description: "all predicates must match",
+			query: Object("test", &carp).
+				WithFieldPredicate("status.phase", FieldEquals, "Running").
+				WithFieldPredicate("spec.nodeSelector.pond", FieldNotEquals, "koi"),
+			want: false,
+		},
+		{
+			description: "non-numeric operand returns error",
+			query:       Object("test", &carp).WithFieldPredicate("spec.activeDeadlineSeconds", FieldGreaterThan, "many"),
+			err:         "requires a numeric value",
+		},
+		{
+			description: "unknown operator returns error",
+			query:       Object("test", &carp).WithFieldPredicate("spec.activeDeadlineSeconds", FieldOperator("Matches"), "30"),
+			err:         "unknown operator",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			query := testClient.Query(tc.query)
+
+			got, err := query.Execute()
+			if err != nil {
+				if tc.err == "" || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("want error containing %q, got: %v", tc.err, err)
+				}
+				return
+			}
+			if tc.err != "" {
+				t.Fatalf("want error containing %q, got none", tc.err)
+			}
+			if got != tc.want {
+				t.Errorf("want: %t, got: %t", tc.want, got)
+			}
+			if !got && !strings.Contains(query.Results().ForQuery("test").NotFoundReason, "predicates=") {
+				t.Errorf("want unmatched predicates in reason, got: %s", query.Results().ForQuery("test").NotFoundReason)
+			}
+		})
+	}
+}
+
+// TestPartialSchemaQueries tests structural matching of partial schemas against OpenAPI definitions.
+func TestPartialSchemaQueries(t *testing.T) {
+	testClient, err := queryClientWithSchema()
+	if err != nil {
+		t.Fatalf("initiating test client: %v", err)
+	}
+
+	testCases := []struct {
+		description string
+		query       *QueryPartialSchema
+		want        bool
+		field       string
+		err         string
+	}{
+		{
+			description: "yaml partial schema found in named definition",
+			query: Schema("test", `
+properties:
+  spec:
+    properties:
+      replicas:
+        type: integer
+`).WithDefinition("io.example.v1.Widget"),
+			want: true,
+		},
+		{
+			description: "json partial schema with different field order found",
+			query:       Schema("test", `{"properties":{"spec":{"properties":{"replicas":{"format":"int32","type":"integer"}}}},"type":"object"}`),
+			want:        true,
+		},
+		{
+			description: "list items matched as a subset",
+			query:       Schema("test", `properties: {spec: {properties: {mode: {enum: [slow]}}}}`),
+			want:        true,
+		},
+		{
+			description: "first unmatched field is reported",
+			query:       Schema("test", `properties: {spec: {properties: {replicas: {type: string}}}}`).WithDefinition("io.example.v1.Widget"),
+			want:        false,
+			field:       "io.example.v1.Widget.properties.spec.properties.replicas.type",
+		},
+		{
+			description: "missing field is reported",
+			query:       Schema("test", `properties: {spec: {properties: {paused: {type: boolean}}}}`).WithDefinition("io.example.v1.Widget"),
+			want:        false,
+			field:       "io.example.v1.Widget.properties.spec.properties.paused",
+		},
+		{
+			description: "unknown definition is reported",
+			query:       Schema("test", `type: object`).WithDefinition("io.example.v1.Gizmo"),
+			want:        false,
+			field:       "io.example.v1.Gizmo",
+		},
+		{
+			description: "openapi v3 partial schema found",
+			query:       Schema("test", `properties: {status: {properties: {ready: {type: boolean}}}}`).WithDefinition("io.example.v1.Gadget").WithOpenAPIV3(),
+			want:        true,
+		},
+		{
+			description: "openapi v3 numbers compared by value",
+			query:       Schema("test", `properties: {status: {properties: {replicas: {minimum: 1.0}}}}`).WithOpenAPIV3("apis/example.io/v1"),
+			want:        true,
+		},
+		{
+			description: "openapi v3 partial schema not found in other paths",
+			query:       Schema("test", `properties: {status: {properties: {ready: {type: boolean}}}}`).WithOpenAPIV3("apis/apps/v1"),
+			want:        false,
+		},
+		{
+			description: "scalar partial schema returns error",
+			query:       Schema("test", "example schema for test"),
+			err:         "partial schema must be a YAML or JSON object",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			query := testClient.Query(tc.query)
+
+			got, err := query.Execute()
+			if err != nil {
+				if tc.err == "" || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("want error containing %q, got: %v", tc.err, err)
+				}
+				return
+			}
+			if tc.err != "" {
+				t.Fatalf("want error containing %q, got none", tc.err)
+			}
+			if got != tc.want {
+				t.Errorf("want: %t, got: %t", tc.want, got)
+			}
+			if tc.field != "" {
+				if reason := query.Results().ForQuery("test").NotFoundReason; !strings.Contains(reason, "field="+tc.field+" ") {
+					t.Errorf("want field %s in reason, got: %s", tc.field, reason)
+				}
+			}
+		})
+	}
+}
+
+// countingQueryTarget records the maximum number of targets running at the same time.
+type countingQueryTarget struct {
+	name    string
+	running *int32
+	max     *int32
+}
+
+func (c countingQueryTarget) Name() string {
+	return c.name
+}
+
+func (c countingQueryTarget) Run(config *clusterQueryClientConfig) (bool, error) {
+	return c.RunContext(context.Background(), config)
+}
+
+func (c countingQueryTarget) RunContext(_ context.Context, _ *clusterQueryClientConfig) (bool, error) {
+	n := atomic.AddInt32(c.running, 1)
+	defer atomic.AddInt32(c.running, -1)
+	for {
+		m := atomic.LoadInt32(c.max)
+		if n <= m || atomic.CompareAndSwapInt32(c.max, m, n) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	return true, nil
+}
+
+func (c countingQueryTarget) Reason() string {
+	return ""
+}
+
+func TestExecuteContext(t *testing.T) {
+	t.Run("errors are collected per target", func(t *testing.T) {
+		c, err := queryClientWithNoResources()
+		if err != nil {
+			t.Fatal(err)
+		}
+		query := c.Query(testGVR, testObject)
+
+		got, err := query.ExecuteContext(context.Background())
+		if got {
+			t.Errorf("want: false, got: true")
+		}
+		if err == nil || !strings.Contains(err.Error(), `query "carpObj" failed`) {
+			t.Errorf("want error for carpObj query, got: %v", err)
+		}
+		if r := query.Results().ForQuery("carpResource"); r == nil || r.Err != nil || r.NotFoundReason == "" {
+			t.Errorf("want not found result without error for carpResource, got: %+v", r)
+		}
+		if r := query.Results().ForQuery("carpObj"); r == nil || r.Err == nil {
+			t.Errorf("want result with error for carpObj, got: %+v", r)
+		}
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		c, err := queryClientWithResourcesAndObjects()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err = c.Query(testGVR, testObject).ExecuteContext(ctx)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("want: %v, got: %v", context.Canceled, err)
+		}
+	})
+
+	t.Run("renamed targets with the same name", func(t *testing.T) {
+		c, err := queryClientWithNoResources()
+		if err != nil {
+			t.Fatal(err)
+		}
+		gvr := func() QueryTarget {
+			return Group("carps", testapigroup.SchemeGroupVersion.Group).WithVersions("v1").WithResource("carps")
+		}
+		query := c.Query(Renamed("first", gvr()), Renamed("second", gvr()))
+
+		if _, err := query.ExecuteContext(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range []string{"first", "second"} {
+			r := query.Results().ForQuery(name)
+			if r == nil || r.Found || r.NotFoundReason == "" || len(r.UnmatchedGVRs) == 0 {
+				t.Errorf("want not found result of %s with its reason and unmatched GVRs, got: %+v", name, r)
+			}
+		}
+	})
+
+	t.Run("concurrency is bounded", func(t *testing.T) {
+		c, err := queryClientWithResourcesAndObjects()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var running, max int32
+		var targets []QueryTarget
+		for i := 0; i < 10; i++ {
+			targets = append(targets, countingQueryTarget{name: fmt.Sprintf("target-%d", i), running: &running, max: &max})
+		}
+
+		got, err := c.Query(targets...).WithConcurrency(3).ExecuteContext(context.Background())
+		if err != nil || !got {
+			t.Fatalf("want: true, got: %t, %v", got, err)
+		}
+		if max > 3 {
+			t.Errorf("want at most 3 targets running at the same time, got: %d", max)
+		}
+	})
+}
+
+func TestCombinatorQueries(t *testing.T) {
+	missingGVR := func(name string) *QueryGVR {
+		return Group(name, testapigroup.SchemeGroupVersion.Group).WithVersions("v1alpha3").WithResource("carps")
+	}
+	missingObject := Object("missingObj", &corev1.ObjectReference{
+		Kind:       "Carp",
+		Name:       "missing",
+		Namespace:  "testns",
+		APIVersion: testapigroup.SchemeGroupVersion.String(),
+	})
+
+	testCases := []struct {
+		description string
+		queryTarget QueryTarget
+		noResources bool
+		want        bool
+		reason      string
+		err         string
+	}{
+		{
+			description: "allOf all targets found",
+			queryTarget: AllOf("all", testGVR, testObject),
+			want:        true,
+		},
+		{
+			description: "allOf one target not found",
+			queryTarget: AllOf("all", testGVR, missingGVR("v1alpha3")),
+			want:        false,
+			reason:      "method=allOf name=all status=unmatched reasons=[GVRs=[testapigroup.apimachinery.k8s.io/v1alpha3, Resource=carps] status=unmatched presence=true]",
+		},
+		{
+			description: "anyOf one target found",
+			queryTarget: AnyOf("any", missingGVR("v1alpha3"), testGVR),
+			want:        true,
+		},
+		{
+			description: "anyOf no target found",
+			queryTarget: AnyOf("any", missingGVR("v1alpha3"), missingObject),
+			want:        false,
+			reason:      "method=anyOf name=any status=unmatched reasons=[GVRs=[testapigroup.apimachinery.k8s.io/v1alpha3, Resource=carps] status=unmatched presence=true; kind=Carp status=unmatched presence=true]",
+		},
+		{
+			description: "anyOf ignores errors when a target is found",
+			queryTarget: AnyOf("any", AllOf("empty"), testGVR),
+			want:        true,
+		},
+		{
+			description: "anyOf returns errors when no target is found",
+			queryTarget: AnyOf("any", AllOf("empty"), missingObject),
+			want:        false,
+			err:         `allOf query "empty" requires at least one query target`,
+		},
+		{
+			description: "not target not found",
+			queryTarget: Not("not", missingObject),
+			want:        true,
+		},
+		{
+			description: "not target found",
+			queryTarget: Not("not", missingObject, testObject),
+			want:        false,
+			reason:      "method=not name=not matched=[carpObj] status=unmatched",
+		},
+		{
+			description: "nested combinators",
+			queryTarget: AllOf("all", AnyOf("any", missingGVR("v1alpha3"), testGVR), Not("not", missingObject)),
+			want:        true,
+		},
+		{
+			description: "not target of a kind that is not served",
+			queryTarget: Not("not", testObject),
+			noResources: true,
+			want:        true,
+		},
+		{
+			description: "not propagates errors",
+			queryTarget: Not("not", AllOf("empty")),
+			want:        false,
+			err:         `query "empty" failed`,
+		},
+		{
+			description: "not without targets",
+			queryTarget: Not("not"),
+			want:        false,
+			err:         `not query "not" requires at least one query target`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			clientFn := queryClientWithResourcesAndObjects
+			if tc.noResources {
+				clientFn = queryClientWithNoResources
+			}
+			c, err := clientFn()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := tc.queryTarget.RunContext(context.Background(), c.config)
+			if err != nil {
+				if tc.err == "" || !strings.Contains(err.Error(), tc.err) {
+					t.Errorf("want error containing %q, got: %v", tc.err, err)
+				}
+			} else if tc.err != "" {
+				t.Errorf("want error containing %q, got none", tc.err)
+			}
+			if got != tc.want {
+				t.Errorf("got=%t, want=%t", got, tc.want)
+			}
+			if tc.reason != "" && tc.queryTarget.Reason() != tc.reason {
+				t.Errorf("reason:\n got: %s\nwant: %s", tc.queryTarget.Reason(), tc.reason)
+			}
+		})
+	}
+}
+
+func TestServerVersionQueries(t *testing.T) {
+	testCases := []struct {
+		description   string
+		serverVersion version.Info
+		constraint    string
+		want          bool
+		err           string
+	}{
+		{
+			description:   "within range",
+			serverVersion: version.Info{Major: "1", Minor: "26", GitVersion: "v1.26.5+vmware.2"},
+			constraint:    ">=1.24, <1.29",
+			want:          true,
+		},
+		{
+			description:   "above range",
+			serverVersion: version.Info{Major: "1", Minor: "29", GitVersion: "v1.29.0"},
+			constraint:    ">=1.24, <1.29",
+			want:          false,
+		},
+		{
+			description:   "equality matches any patch",
+			serverVersion: version.Info{Major: "1", Minor: "24", GitVersion: "v1.24.9"},
+			constraint:    "1.24.x",
+			want:          true,
+		},
+		{
+			description:   "alternatives",
+			serverVersion: version.Info{Major: "1", Minor: "27", GitVersion: "v1.27.1"},
+			constraint:    "=1.24 || >1.26.3",
+			want:          true,
+		},
+		{
+			description:   "not equal",
+			serverVersion: version.Info{Major: "1", Minor: "24", GitVersion: "v1.24.9"},
+			constraint:    "!=1.24",
+			want:          false,
+		},
+		{
+			description:   "falls back to major and minor",
+			serverVersion: version.Info{Major: "1", Minor: "25+"},
+			constraint:    "<=1.25",
+			want:          true,
+		},
+		{
+			description:   "invalid constraint",
+			serverVersion: version.Info{Major: "1", Minor: "25", GitVersion: "v1.25.0"},
+			constraint:    ">=one",
+			err:           `invalid version constraint ">=one"`,
+		},
+		{
+			description:   "empty constraint",
+			serverVersion: version.Info{Major: "1", Minor: "25", GitVersion: "v1.25.0"},
+			err:           "version constraint must not be empty",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			serverVersion := tc.serverVersion
+			fakeDiscovery := &fakediscovery.FakeDiscovery{Fake: &k8stesting.Fake{}, FakedServerVersion: &serverVersion}
+			c, err := NewClusterQueryClient(dynamicFake.NewSimpleDynamicClient(testScheme), fakeDiscovery)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			q := ServerVersion("kubeVersion").WithConstraint(tc.constraint)
+			got, err := c.Query(q).Execute()
+			if err != nil {
+				if tc.err == "" || !strings.Contains(err.Error(), tc.err) {
+					t.Errorf("want error containing %q, got: %v", tc.err, err)
+				}
+			} else if tc.err != "" {
+				t.Errorf("want error containing %q, got none", tc.err)
+			}
+			if got != tc.want {
+				t.Errorf("got=%t, want=%t, reason: %s", got, tc.want, q.Reason())
+			}
+		})
+	}
+}
+
+func TestStatusConditionQueries(t *testing.T) {
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	readyCarp := &testapigroup.Carp{
+		ObjectMeta: metav1.ObjectMeta{Name: "ready", Namespace: "testns"},
+		Status: testapigroup.CarpStatus{
+			Conditions: []testapigroup.CarpCondition{
+				{Type: "Ready", Status: "True", LastTransitionTime: metav1.NewTime(now.Add(-10 * time.Minute))},
+				{Type: "Degraded", Status: "False"},
+			},
+		},
+	}
+	carpRef := func(name string) *corev1.ObjectReference {
+		return &corev1.ObjectReference{Kind: "Carp", Name: name, Namespace: "testns", APIVersion: testapigroup.SchemeGroupVersion.String()}
+	}
+
+	testCases := []struct {
+		description string
+		query       *QueryStatusCondition
+		want        bool
+		reason      string
+		remaining   time.Duration
+	}{
+		{
+			description: "condition is true",
+			query:       StatusCondition("ready", carpRef("ready"), "Ready"),
+			want:        true,
+		},
+		{
+			description: "condition has expected status",
+			query:       StatusCondition("degraded", carpRef("ready"), "Degraded").WithStatus(metav1.ConditionFalse),
+			want:        true,
+		},
+		{
+			description: "condition has other status",
+			query:       StatusCondition("degraded", carpRef("ready"), "Degraded"),
+			want:        false,
+			reason:      `kind=Carp condition=Degraded=True observed="False" status=unmatched presence=true`,
+		},
+		{
+			description: "condition not found",
+			query:       StatusCondition("available", carpRef("ready"), "Available"),
+			want:        false,
+			reason:      `kind=Carp condition=Available=True observed="" status=unmatched presence=true`,
+		},
+		{
+			description: "object not found",
+			query:       StatusCondition("ready", carpRef("missing"), "Ready"),
+			want:        false,
+			reason:      "kind=Carp object=missing status=unmatched presence=true",
+		},
+		{
+			description: "condition held for min duration",
+			query:       StatusCondition("ready", carpRef("ready"), "Ready").WithMinDuration(5 * time.Minute),
+			want:        true,
+		},
+		{
+			description: "condition not held for min duration",
+			query:       StatusCondition("ready", carpRef("ready"), "Ready").WithMinDuration(15 * time.Minute),
+			want:        false,
+			reason:      "kind=Carp condition=Ready=True minDuration=15m0s status=unmatched presence=true",
+			remaining:   5 * time.Minute,
+		},
+		{
+			description: "min duration without lastTransitionTime",
+			query:       StatusCondition("degraded", carpRef("ready"), "Degraded").WithStatus(metav1.ConditionFalse).WithMinDuration(time.Minute),
+			want:        false,
+			remaining:   time.Minute,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			fakeDiscovery := &fakediscovery.FakeDiscovery{Fake: &k8stesting.Fake{Resources: apiResources}}
+			c, err := NewClusterQueryClient(dynamicFake.NewSimpleDynamicClient(testScheme, readyCarp), fakeDiscovery, withClock(func() time.Time { return now }))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := c.Query(tc.query).Execute()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("got=%t, want=%t, reason: %s", got, tc.want, tc.query.Reason())
+			}
+			if tc.reason != "" && tc.query.Reason() != tc.reason {
+				t.Errorf("reason:\n got: %s\nwant: %s", tc.query.Reason(), tc.reason)
+			}
+			if tc.query.RemainingDuration() != tc.remaining {
+				t.Errorf("remaining duration: got: %s, want: %s", tc.query.RemainingDuration(), tc.remaining)
+			}
+		})
+	}
+}
+
+func TestAccessQueries(t *testing.T) {
+	// The fake authorizer allows listing secrets in tkg-system only.
+	authorize := func(action k8stesting.Action) (bool, runtime.Object, error) {
+		u := action.(k8stesting.CreateAction).GetObject().(*unstructured.Unstructured)
+		attributes, _, _ := unstructured.NestedStringMap(u.Object, "spec", "resourceAttributes")
+		allowed := attributes["verb"] == "list" && attributes["resource"] == "secrets" && attributes["namespace"] == "tkg-system"
+		result := u.DeepCopy()
+		if err := unstructured.SetNestedField(result.Object, allowed, "status", "allowed"); err != nil {
+			return true, nil, err
+		}
+		if !allowed {
+			_ = unstructured.SetNestedField(result.Object, "no RBAC policy matched", "status", "reason")
+		}
+		return true, result, nil
+	}
+	secrets := corev1.SchemeGroupVersion.WithResource("secrets")
+
+	testCases := []struct {
+		description string
+		query       *QueryAccess
+		want        bool
+		reason      string
+		err         string
+	}{
+		{
+			description: "allowed",
+			query:       Access("list-secrets", "list", secrets, "tkg-system"),
+			want:        true,
+		},
+		{
+			description: "denied in namespace",
+			query:       Access("list-secrets", "list", secrets, "default"),
+			want:        false,
+			reason:      `method=access verb=list resource=secrets namespace=default resourceName= reason="no RBAC policy matched" status=denied presence=true`,
+		},
+		{
+			description: "denied verb on subresource",
+			query:       Access("update-secrets", "update", secrets, "tkg-system").WithSubresource("status").WithResourceName("creds"),
+			want:        false,
+			reason:      `method=access verb=update resource=secrets/status namespace=tkg-system resourceName=creds reason="no RBAC policy matched" status=denied presence=true`,
+		},
+		{
+			description: "missing verb",
+			query:       Access("no-verb", "", secrets, "tkg-system"),
+			err:         `access query "no-verb" requires a verb`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			dynamicClient := dynamicFake.NewSimpleDynamicClient(testScheme)
+			dynamicClient.PrependReactor("create", "selfsubjectaccessreviews", authorize)
+			c, err := NewClusterQueryClient(dynamicClient, &fakediscovery.FakeDiscovery{Fake: &k8stesting.Fake{}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := c.Query(tc.query).Execute()
+			if err != nil {
+				if tc.err == "" || !strings.Contains(err.Error(), tc.err) {
+					t.Errorf("want error containing %q, got: %v", tc.err, err)
+				}
+			} else if tc.err != "" {
+				t.Errorf("want error containing %q, got none", tc.err)
+			}
+			if got != tc.want {
+				t.Errorf("got=%t, want=%t", got, tc.want)
+			}
+			if tc.reason != "" && tc.query.Reason() != tc.reason {
+				t.Errorf("reason:\n got: %s\nwant: %s", tc.query.Reason(), tc.reason)
+			}
+		})
+	}
+}
+
+func TestQueryResultDetails(t *testing.T) {
+	carps := testapigroup.SchemeGroupVersion.WithResource("carps")
+	annotated := Object("annotated", &carp).WithAnnotations(map[string]string{"cluster.x-k8s.io/provider": "infrastructure-other", "missing": ""})
+
+	testCases := []struct {
+		description string
+		query       QueryTarget
+		reactor     k8stesting.ReactionFunc
+		want        QueryResult
+	}{
+		{
+			description: "unmatched GVRs",
+			query:       Group("gvr", testapigroup.SchemeGroupVersion.Group).WithVersions("v1", "v2").WithResource("carps"),
+			want:        QueryResult{UnmatchedGVRs: []string{"testapigroup.apimachinery.k8s.io/v2, Resource=carps"}},
+		},
+		{
+			description: "unmatched annotations and object reference",
+			query:       annotated,
+			want:        QueryResult{UnmatchedAnnotations: []string{"cluster.x-k8s.io/provider", "missing"}, Object: &carp},
+		},
+		{
+			description: "found object",
+			query:       Object("found", &carp),
+			want:        QueryResult{Found: true, Object: &carp},
+		},
+		{
+			description: "kind not served is classified as not found",
+			query:       Object("unknownKind", &corev1.ObjectReference{Kind: "Trout", APIVersion: "fish.example.com/v1", Name: "t"}),
+			want:        QueryResult{ErrorClass: ErrorClassNotFound, Object: &corev1.ObjectReference{Kind: "Trout", APIVersion: "fish.example.com/v1", Name: "t"}},
+		},
+		{
+			description: "forbidden",
+			query:       Object("forbidden", &carp),
+			reactor: func(k8stesting.Action) (bool, runtime.Object, error) {
+				return true, nil, apierrors.NewForbidden(carps.GroupResource(), carp.Name, errors.New("denied"))
+			},
+			want: QueryResult{ErrorClass: ErrorClassForbidden, Object: &carp},
+		},
+		{
+			description: "timeout",
+			query:       Object("timeout", &carp),
+			reactor: func(k8stesting.Action) (bool, runtime.Object, error) {
+				return true, nil, fmt.Errorf("get failed: %w", context.DeadlineExceeded)
+			},
+			want: QueryResult{ErrorClass: ErrorClassTimeout, Object: &carp},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			dynamicClient := dynamicFake.NewSimpleDynamicClient(testScheme, testObjects...)
+			if tc.reactor != nil {
+				dynamicClient.PrependReactor("get", "carps", tc.reactor)
+			}
+			// Every reading of the clock advances it by a second.
+			now := time.Now()
+			clock := func() time.Time {
+				now = now.Add(time.Second)
+				return now
+			}
+			c, err := NewClusterQueryClient(dynamicClient, &fakediscovery.FakeDiscovery{Fake: &k8stesting.Fake{Resources: apiResources}}, WithDiscoveryCacheTTL(0), withClock(clock))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			q := c.Query(tc.query).WithConcurrency(1)
+			_, _ = q.Execute()
+			got := q.Results().ForQuery(tc.query.Name())
+			if got.Duration != time.Second {
+				t.Errorf("want duration of 1s, got %s", got.Duration)
+			}
+			if got.Found != tc.want.Found || got.ErrorClass != tc.want.ErrorClass || (got.Err != nil) != (tc.want.ErrorClass != "") {
+				t.Errorf("want found=%t errorClass=%q, got found=%t errorClass=%q err=%v", tc.want.Found, tc.want.ErrorClass, got.Found, got.ErrorClass, got.Err)
+			}
+			if fmt.Sprint(got.UnmatchedGVRs) != fmt.Sprint(tc.want.UnmatchedGVRs) || fmt.Sprint(got.UnmatchedAnnotations) != fmt.Sprint(tc.want.UnmatchedAnnotations) {
+				t.Errorf("want unmatched GVRs %v and annotations %v, got %v and %v", tc.want.UnmatchedGVRs, tc.want.UnmatchedAnnotations, got.UnmatchedGVRs, got.UnmatchedAnnotations)
+			}
+			if fmt.Sprint(got.Object) != fmt.Sprint(tc.want.Object) {
+				t.Errorf("want object %v, got %v", tc.want.Object, got.Object)
+			}
+		})
+	}
+}
+
+func TestAPIServiceQueries(t *testing.T) {
+	apiService := func(name, status, reason, message string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "apiregistration.k8s.io/v1",
+			"kind":       "APIService",
+			"metadata":   map[string]interface{}{"name": name},
+			"status": map[string]interface{}{
+				"conditions": []interface{}{
+					map[string]interface{}{"type": "Available", "status": status, "reason": reason, "message": message},
+				},
+			},
+		}}
+	}
+	dynamicClient := dynamicFake.NewSimpleDynamicClientWithCustomListKinds(testScheme, map[schema.GroupVersionResource]string{apiServices: "APIServiceList"},
+		apiService("v1", "True", "Local", "Local APIServices are always available"),
+		apiService("v1beta1.metrics.k8s.io", "False", "FailedDiscoveryCheck", "failing or missing response from https://10.96.0.10:443"),
+		&unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "apiregistration.k8s.io/v1",
+			"kind":       "APIService",
+			"metadata":   map[string]interface{}{"name": "v1alpha1.example.com"},
+		}},
+	)
+	c, err := NewClusterQueryClient(dynamicClient, &fakediscovery.FakeDiscovery{Fake: &k8stesting.Fake{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		description string
+		query       *QueryAPIService
+		want        bool
+		reason      string
+		err         string
+	}{
+		{
+			description: "available local APIService of the core group",
+			query:       APIService("core", schema.GroupVersion{Version: "v1"}),
+			want:        true,
+		},
+		{
+			description: "unavailable aggregated APIService",
+			query:       APIService("metrics", schema.GroupVersion{Group: "metrics.k8s.io", Version: "v1beta1"}),
+			want:        false,
+			reason:      `method=apiservice name=metrics apiService=v1beta1.metrics.k8s.io condition=Available=False reason=FailedDiscoveryCheck message="failing or missing response from https://10.96.0.10:443" status=unavailable presence=true`,
+		},
+		{
+			description: "APIService without conditions",
+			query:       APIService("example", schema.GroupVersion{Group: "example.com", Version: "v1alpha1"}),
+			want:        false,
+			reason:      "method=apiservice name=example apiService=v1alpha1.example.com condition=Available status=unavailable presence=true",
+		},
+		{
+			description: "missing APIService",
+			query:       APIService("missing", schema.GroupVersion{Group: "missing.example.com", Version: "v1"}),
+			want:        false,
+			reason:      "method=apiservice name=missing apiService=v1.missing.example.com status=notFound presence=true",
+		},
+		{
+			description: "version is required",
+			query:       APIService("noVersion", schema.GroupVersion{Group: "metrics.k8s.io"}),
+			err:         `APIService query "noVersion" requires a version`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			got, err := c.Query(tc.query).Execute()
+			if err != nil {
+				if tc.err == "" || !strings.Contains(err.Error(), tc.err) {
+					t.Errorf("want error containing %q, got: %v", tc.err, err)
+				}
+			} else if tc.err != "" {
+				t.Errorf("want error containing %q, got none", tc.err)
+			}
+			if got != tc.want {
+				t.Errorf("got=%t, want=%t, reason: %s", got, tc.want, tc.query.Reason())
+			}
+			if tc.reason != "" && tc.query.Reason() != tc.reason {
+				t.Errorf("reason: got %q, want %q", tc.query.Reason(), tc.reason)
+			}
+		})
+	}
+}
+
+func TestCustomResourceDefinitionQueries(t *testing.T) {
+	crd := func(name string, established string, storedVersions []interface{}, versions ...map[string]interface{}) *unstructured.Unstructured {
+		specVersions := make([]interface{}, 0, len(versions))
+		for _, v := range versions {
+			specVersions = append(specVersions, v)
+		}
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "apiextensions.k8s.io/v1",
+			"kind":       "CustomResourceDefinition",
+			"metadata":   map[string]interface{}{"name": name},
+			"spec": map[string]interface{}{
+				"group":    "cluster.x-k8s.io",
+				"versions": specVersions,
+			},
+			"status": map[string]interface{}{
+				"conditions": []interface{}{
+					map[string]interface{}{"type": "Established", "status": established},
+				},
+				"storedVersions": storedVersions,
+			},
+		}}
+	}
+	dynamicClient := dynamicFake.NewSimpleDynamicClientWithCustomListKinds(testScheme, map[schema.GroupVersionResource]string{customResourceDefinitions: "CustomResourceDefinitionList"},
+		crd("clusters.cluster.x-k8s.io", "True", []interface{}{"v1alpha4", "v1beta1"},
+			map[string]interface{}{"name": "v1alpha4", "served": true, "storage": false, "deprecated": true},
+			map[string]interface{}{"name": "v1beta1", "served": true, "storage": true},
+		),
+		crd("machines.cluster.x-k8s.io", "True", []interface{}{"v1beta1"},
+			map[string]interface{}{"name": "v1alpha3", "served": false, "storage": false},
+			map[string]interface{}{"name": "v1beta1", "served": true, "storage": true},
+		),
+		crd("pending.cluster.x-k8s.io", "False", []interface{}{"v1beta1"},
+			map[string]interface{}{"name": "v1beta1", "served": true, "storage": true},
+		),
+	)
+	c, err := NewClusterQueryClient(dynamicClient, &fakediscovery.FakeDiscovery{Fake: &k8stesting.Fake{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		description string
+		query       *QueryCustomResourceDefinition
+		want        bool
+		reason      string
+		err         string
+	}{
+		{
+			description: "established CRD",
+			query:       CustomResourceDefinition("clusters", "clusters.cluster.x-k8s.io"),
+			want:        true,
+		},
+		{
+			description: "served, storage and deprecated versions",
+			query: CustomResourceDefinition("clusters", "clusters.cluster.x-k8s.io").
+				WithServedVersions("v1alpha4", "v1beta1").
+				WithStorageVersion("v1beta1").
+				WithDeprecatedVersions("v1alpha4").
+				WithNotDeprecatedVersions("v1beta1"),
+			want: true,
+		},
+		{
+			description: "storage version migration is pending",
+			query:       CustomResourceDefinition("clusters", "clusters.cluster.x-k8s.io").WithStorageVersion("v1beta1").WithNoPendingStorageMigration(),
+			want:        false,
+			reason:      "method=crd name=clusters crd=clusters.cluster.x-k8s.io storedVersions=v1alpha4,v1beta1 status=unmatched presence=true",
+		},
+		{
+			description: "storage version migration is done",
+			query:       CustomResourceDefinition("machines", "machines.cluster.x-k8s.io").WithNoPendingStorageMigration(),
+			want:        true,
+		},
+		{
+			description: "unserved, missing and wrongly deprecated versions",
+			query: CustomResourceDefinition("machines", "machines.cluster.x-k8s.io").
+				WithServedVersions("v1alpha3", "v1beta1").
+				WithStorageVersion("v1alpha3").
+				WithDeprecatedVersions("v1beta1").
+				WithNotDeprecatedVersions("v1alpha4"),
+			want:   false,
+			reason: "method=crd name=machines crd=machines.cluster.x-k8s.io unserved=v1alpha3 storageVersion=v1beta1 notDeprecated=v1beta1 missing=v1alpha4 status=unmatched presence=true",
+		},
+		{
+			description: "CRD that is not established",
+			query:       CustomResourceDefinition("pending", "pending.cluster.x-k8s.io"),
+			want:        false,
+			reason:      "method=crd name=pending crd=pending.cluster.x-k8s.io established=False status=unmatched presence=true",
+		},
+		{
+			description: "missing CRD",
+			query:       CustomResourceDefinition("missing", "missings.example.com"),
+			want:        false,
+			reason:      "method=crd name=missing crd=missings.example.com status=notFound presence=true",
+		},
+		{
+			description: "CRD name is required",
+			query:       CustomResourceDefinition("noName", ""),
+			err:         `CustomResourceDefinition query "noName" requires the name of a CustomResourceDefinition`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			got, err := c.Query(tc.query).Execute()
+			if err != nil {
+				if tc.err == "" || !strings.Contains(err.Error(), tc.err) {
+					t.Errorf("want error containing %q, got: %v", tc.err, err)
+				}
+			} else if tc.err != "" {
+				t.Errorf("want error containing %q, got none", tc.err)
+			}
+			if got != tc.want {
+				t.Errorf("got=%t, want=%t, reason: %s", got, tc.want, tc.query.Reason())
+			}
+			if tc.reason != "" && tc.query.Reason() != tc.reason {
+				t.Errorf("reason: got %q, want %q", tc.query.Reason(), tc.reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/version"
+	versioninfo "k8s.io/apimachinery/pkg/version"
+)
+
+// ServerVersion returns a query target that matches the Kubernetes version of the cluster against semver constraints.
+func ServerVersion(name string) *QueryServerVersion {
+	return &QueryServerVersion{
+		name: name,
+	}
+}
+
+// QueryServerVersion matches the Kubernetes version reported by the API server against semver constraints.
+type QueryServerVersion struct {
+	name       string
+	constraint string
+
+	serverVersion string
+}
+
+// Name is the name of the query.
+func (q *QueryServerVersion) Name() string {
+	return q.name
+}
+
+// WithConstraint sets the constraint the server version must satisfy, e.g. ">=1.24, <1.29".
+// Comparisons separated by a comma must all be satisfied, and alternatives are separated by "||", e.g. "1.24.x || >=1.26".
+// Supported operators are =, !=, >, >=, < and <=; a comparison without an operator is an equality.
+// Missing version components are zero, except in equalities where they match any value, so "=1.24" matches 1.24.9.
+// Pre-release and build metadata of the server version, e.g. "+vmware.1", are ignored.
+func (q *QueryServerVersion) WithConstraint(constraint string) *QueryServerVersion {
+	q.constraint = constraint
+	return q
+}
+
+// Run the server version query.
+func (q *QueryServerVersion) Run(config *clusterQueryClientConfig) (bool, error) {
+	return q.RunContext(context.Background(), config)
+}
+
+// RunContext runs the server version query. The server version is fetched without a context.
+func (q *QueryServerVersion) RunContext(ctx context.Context, config *clusterQueryClientConfig) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	q.serverVersion = ""
+
+	constraint, err := parseVersionConstraint(q.constraint)
+	if err != nil {
+		return false, err
+	}
+
+	info, err := config.discovery().ServerVersion()
+	if err != nil {
+		return false, fmt.Errorf("failed to get server version: %w", err)
+	}
+	v, err := serverVersion(info)
+	if err != nil {
+		return false, err
+	}
+	q.serverVersion = v.String()
+
+	return constraint.matches(v), nil
+}
+
+// validate ensures the constraint parses.
+func (q *QueryServerVersion) validate() error {
+	_, err := parseVersionConstraint(q.constraint)
+	return err
+}
+
+// Reason returns the server version that did not satisfy the constraint.
+func (q *QueryServerVersion) Reason() string {
+	return fmt.Sprintf("method=server-version name=%s version=%s constraint=%q status=unmatched presence=true", q.name, q.serverVersion, q.constraint)
+}
+
+// KubernetesVersion returns the Kubernetes version reported by the API server, e.g. 1.24.9 for "v1.24.9+vmware.1".
+func (c *ClusterQueryClient) KubernetesVersion() (*version.Version, error) {
+	info, err := c.config.discovery().ServerVersion()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get server version: %w", err)
+	}
+	return serverVersion(info)
+}
+
+// serverVersion returns the version from the GitVersion of the server, e.g. "v1.24.9+vmware.1", falling back to the
+// major and minor versions, which some providers suffix with "+", e.g. "24+".
+func serverVersion(info *versioninfo.Info) (*version.Version, error) {
+	if v, err := version.ParseGeneric(info.GitVersion); err == nil {
+		return v, nil
+	}
+	v, err := version.ParseGeneric(fmt.Sprintf("%s.%s", strings.TrimSuffix(info.Major, "+"), strings.TrimSuffix(info.Minor, "+")))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse server version %q: %w", info.GitVersion, err)
+	}
+	return v, nil
+}
+
+// versionConstraint is a disjunction of conjunctions of version comparisons.
+type versionConstraint [][]versionComparison
+
+type versionComparison struct {
+	operator string
+	version  *version.Version
+}
+
+// versionOperators are ordered so that two character operators are matched first.
+var versionOperators = []string{">=", "<=", "!=", "==", ">", "<", "="}
+
+// parseVersionConstraint parses a constraint such as ">=1.24, <1.29 || 1.30".
+func parseVersionConstraint(s string) (versionConstraint, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, fmt.Errorf("version constraint must not be empty")
+	}
+
+	var constraint versionConstraint
+	for _, alternative := range strings.Split(s, "||") {
+		var comparisons []versionComparison
+		for _, c := range strings.Split(alternative, ",") {
+			comparison, err := parseVersionComparison(strings.TrimSpace(c))
+			if err != nil {
+				return nil, fmt.Errorf("invalid version constraint %q: %w", s, err)
+			}
+			comparisons = append(comparisons, comparison)
+		}
+		constraint = append(constraint, comparisons)
+	}
+	return constraint, nil
+}
+
+func parseVersionComparison(s string) (versionComparison, error) {
+	operator := "="
+	for _, op := range versionOperators {
+		if strings.HasPrefix(s, op) {
+			operator = op
+			s = strings.TrimSpace(strings.TrimPrefix(s, op))
+			break
+		}
+	}
+	if operator == "==" {
+		operator = "="
+	}
+
+	// "1.24.x" is the same as "=1.24".
+	s = strings.TrimSuffix(strings.TrimSuffix(s, ".x"), ".*")
+	v, err := version.ParseGeneric(s)
+	if err != nil {
+		return versionComparison{}, err
+	}
+	return versionComparison{operator: operator, version: v}, nil
+}
+
+// matches returns true if the version satisfies any of the alternatives of the constraint.
+func (c versionConstraint) matches(v *version.Version) bool {
+	for _, comparisons := range c {
+		matched := true
+		for _, comparison := range comparisons {
+			if !comparison.matches(v) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (c versionComparison) matches(v *version.Version) bool {
+	switch c.operator {
+	case "=":
+		return c.equals(v)
+	case "!=":
+		return !c.equals(v)
+	case ">":
+		return c.version.LessThan(v)
+	case ">=":
+		return v.AtLeast(c.version)
+	case "<":
+		return v.LessThan(c.version)
+	case "<=":
+		return !c.version.LessThan(v)
+	}
+	return false
+}
+
+// equals compares only the components present in the constraint, so that "1.24" equals "1.24.9".
+func (c versionComparison) equals(v *version.Version) bool {
+	want, got := c.version.Components(), v.Components()
+	for i := range want {
+		var component uint
+		if i < len(got) {
+			component = got[i]
+		}
+		if component != want[i] {
+			return false
+		}
+	}
+	return true
+}
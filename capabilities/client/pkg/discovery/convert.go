@@ -0,0 +1,55 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package discovery
+
+import (
+	"encoding/json"
+
+	corev1alpha1 "github.com/vmware-tanzu/tanzu-framework/apis/core/v1alpha1"
+	corev1alpha2 "github.com/vmware-tanzu/tanzu-framework/apis/core/v1alpha2"
+	runv1alpha1 "github.com/vmware-tanzu/tanzu-framework/apis/run/v1alpha1"
+)
+
+// RunCapabilityToCapability converts a deprecated run.tanzu.vmware.com/v1alpha1 Capability to a
+// core.tanzu.vmware.com/v1alpha2 Capability. The API server cannot convert between API groups, so this is for consumers
+// of the run group while they migrate. The run and core v1alpha1 Capabilities have the same schema, and the conversion
+// is the one of the core v1alpha1 Capability, which restores the queries v1alpha1 cannot represent from its
+// conversion data annotation.
+func RunCapabilityToCapability(capability *runv1alpha1.Capability) (*corev1alpha2.Capability, error) {
+	spoke := &corev1alpha1.Capability{}
+	if err := convertSchema(capability, spoke); err != nil {
+		return nil, err
+	}
+	hub := &corev1alpha2.Capability{}
+	if err := spoke.ConvertTo(hub); err != nil {
+		return nil, err
+	}
+	hub.SetGroupVersionKind(corev1alpha2.GroupVersion.WithKind("Capability"))
+	return hub, nil
+}
+
+// CapabilityToRunCapability converts a core.tanzu.vmware.com/v1alpha2 Capability to a deprecated
+// run.tanzu.vmware.com/v1alpha1 Capability, which only has GVR, Object and PartialSchema queries. The v1alpha2 spec is
+// kept in the conversion data annotation, see RunCapabilityToCapability.
+func CapabilityToRunCapability(capability *corev1alpha2.Capability) (*runv1alpha1.Capability, error) {
+	spoke := &corev1alpha1.Capability{}
+	if err := spoke.ConvertFrom(capability.DeepCopy()); err != nil {
+		return nil, err
+	}
+	runCapability := &runv1alpha1.Capability{}
+	if err := convertSchema(spoke, runCapability); err != nil {
+		return nil, err
+	}
+	runCapability.SetGroupVersionKind(runv1alpha1.GroupVersion.WithKind("Capability"))
+	return runCapability, nil
+}
+
+// convertSchema converts between types that have the same JSON schema.
+func convertSchema(from, to interface{}) error {
+	b, err := json.Marshal(from)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, to)
+}
@@ -0,0 +1,279 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package discovery
+
+import (
+	"context"
+	_ "embed" // for the deprecation table
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/client-go/discovery"
+	"sigs.k8s.io/yaml"
+)
+
+// deprecatedAPIsListLimit is the page size used to list the objects of deprecated APIs.
+const deprecatedAPIsListLimit = 500
+
+//go:embed deprecated_apis.yaml
+var deprecatedAPIsYAML []byte
+
+var (
+	deprecatedAPIsOnce sync.Once
+	deprecatedAPIs     []DeprecatedAPI
+)
+
+// DeprecatedAPI is an API version of a resource that is deprecated, and no longer served as of a later Kubernetes
+// version.
+type DeprecatedAPI struct {
+	GroupVersionResource schema.GroupVersionResource
+	Kind                 string
+	// DeprecatedIn is the Kubernetes version that deprecates the API, e.g. "1.26".
+	DeprecatedIn string
+	// RemovedIn is the Kubernetes version that stops serving the API, e.g. "1.29".
+	RemovedIn string
+	// ReplacedBy is the group version to migrate to, e.g. "flowcontrol.apiserver.k8s.io/v1beta3". It is empty if
+	// the API has no replacement.
+	ReplacedBy string
+}
+
+// deprecatedAPIEntry is an entry of the embedded deprecation table.
+type deprecatedAPIEntry struct {
+	Group        string `json:"group"`
+	Version      string `json:"version"`
+	Resource     string `json:"resource"`
+	Kind         string `json:"kind"`
+	DeprecatedIn string `json:"deprecatedIn"`
+	RemovedIn    string `json:"removedIn"`
+	ReplacedBy   string `json:"replacedBy"`
+}
+
+// DeprecatedAPIs returns the deprecated APIs of persisted resources of Kubernetes, from the deprecation table that is
+// embedded in the client.
+func DeprecatedAPIs() []DeprecatedAPI {
+	deprecatedAPIsOnce.Do(func() {
+		var entries []deprecatedAPIEntry
+		// The table is validated by the tests.
+		if err := yaml.UnmarshalStrict(deprecatedAPIsYAML, &entries); err != nil {
+			panic(fmt.Sprintf("invalid deprecated API table: %v", err))
+		}
+		for _, e := range entries {
+			deprecatedAPIs = append(deprecatedAPIs, DeprecatedAPI{
+				GroupVersionResource: schema.GroupVersionResource{Group: e.Group, Version: e.Version, Resource: e.Resource},
+				Kind:                 e.Kind,
+				DeprecatedIn:         e.DeprecatedIn,
+				RemovedIn:            e.RemovedIn,
+				ReplacedBy:           e.ReplacedBy,
+			})
+		}
+	})
+	return append([]DeprecatedAPI(nil), deprecatedAPIs...)
+}
+
+// DeprecatedAPIUsage is a deprecated API that is served by the cluster, with the objects written with it.
+type DeprecatedAPIUsage struct {
+	DeprecatedAPI
+	// Removed is true if the target Kubernetes version no longer serves the API.
+	Removed bool
+	// Objects are the objects last written with the API version, according to their managed fields or their
+	// kubectl last-applied configuration. The API server stores every object in the storage version of the resource,
+	// so these are the objects whose manifests or clients must be migrated.
+	Objects []corev1.ObjectReference
+}
+
+// DeprecatedAPIReport lists the APIs served by a cluster that are deprecated or removed in a target Kubernetes
+// version, sorted by GVR.
+type DeprecatedAPIReport struct {
+	TargetVersion string
+	APIs          []DeprecatedAPIUsage
+}
+
+// InUse returns the APIs of the report that objects were written with.
+func (r *DeprecatedAPIReport) InUse() []DeprecatedAPIUsage {
+	var inUse []DeprecatedAPIUsage
+	for i := range r.APIs {
+		if len(r.APIs[i].Objects) != 0 {
+			inUse = append(inUse, r.APIs[i])
+		}
+	}
+	return inUse
+}
+
+// ScanDeprecatedAPIs lists the objects of the APIs served by the cluster that are deprecated or removed in the target
+// Kubernetes version, e.g. "1.29", to find out what must be migrated before upgrading to it.
+func (c *ClusterQueryClient) ScanDeprecatedAPIs(ctx context.Context, targetVersion string) (*DeprecatedAPIReport, error) {
+	return scanDeprecatedAPIs(ctx, c.config, targetVersion)
+}
+
+func scanDeprecatedAPIs(ctx context.Context, config *clusterQueryClientConfig, targetVersion string) (*DeprecatedAPIReport, error) {
+	target, err := version.ParseGeneric(targetVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target Kubernetes version %q: %w", targetVersion, err)
+	}
+
+	// Groups that fail discovery, e.g. because of an unavailable aggregated API, are not scanned.
+	_, resourceLists, err := config.discovery().ServerGroupsAndResources()
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		return nil, fmt.Errorf("failed to discover server resources: %w", err)
+	}
+	served := make(map[schema.GroupVersionResource]bool)
+	for _, list := range resourceLists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			continue
+		}
+		for _, r := range list.APIResources {
+			served[gv.WithResource(r.Name)] = true
+		}
+	}
+
+	report := &DeprecatedAPIReport{TargetVersion: targetVersion}
+	for _, api := range DeprecatedAPIs() {
+		if !served[api.GroupVersionResource] {
+			continue
+		}
+		deprecatedIn, err := version.ParseGeneric(api.DeprecatedIn)
+		if err != nil {
+			return nil, fmt.Errorf("invalid deprecation version of %s: %w", api.GroupVersionResource, err)
+		}
+		removedIn, err := version.ParseGeneric(api.RemovedIn)
+		if err != nil {
+			return nil, fmt.Errorf("invalid removal version of %s: %w", api.GroupVersionResource, err)
+		}
+		if target.LessThan(deprecatedIn) {
+			continue
+		}
+
+		objects, err := objectsWrittenWith(ctx, config, api.GroupVersionResource, api.Kind)
+		if err != nil {
+			return nil, err
+		}
+		report.APIs = append(report.APIs, DeprecatedAPIUsage{
+			DeprecatedAPI: api,
+			Removed:       !target.LessThan(removedIn),
+			Objects:       objects,
+		})
+	}
+	sort.Slice(report.APIs, func(i, j int) bool {
+		return report.APIs[i].GroupVersionResource.String() < report.APIs[j].GroupVersionResource.String()
+	})
+	return report, nil
+}
+
+// objectsWrittenWith lists the objects of the resource in all namespaces and returns those written with its version.
+func objectsWrittenWith(ctx context.Context, config *clusterQueryClientConfig, gvr schema.GroupVersionResource, kind string) ([]corev1.ObjectReference, error) {
+	apiVersion := gvr.GroupVersion().String()
+	var objects []corev1.ObjectReference
+	opts := metav1.ListOptions{Limit: deprecatedAPIsListLimit}
+	for {
+		list, err := config.dynamicClient.Resource(gvr).List(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", gvr, err)
+		}
+		for i := range list.Items {
+			u := &list.Items[i]
+			if writtenWith(u, apiVersion) {
+				objects = append(objects, corev1.ObjectReference{
+					APIVersion: apiVersion,
+					Kind:       kind,
+					Namespace:  u.GetNamespace(),
+					Name:       u.GetName(),
+				})
+			}
+		}
+		if opts.Continue = list.GetContinue(); opts.Continue == "" {
+			return objects, nil
+		}
+	}
+}
+
+// writtenWith returns true if the managed fields or the kubectl last-applied configuration of the object refer to the
+// API version.
+func writtenWith(u *unstructured.Unstructured, apiVersion string) bool {
+	for _, f := range u.GetManagedFields() {
+		if f.APIVersion == apiVersion {
+			return true
+		}
+	}
+	lastApplied, ok := u.GetAnnotations()[corev1.LastAppliedConfigAnnotation]
+	if !ok {
+		return false
+	}
+	var applied metav1.TypeMeta
+	return json.Unmarshal([]byte(lastApplied), &applied) == nil && applied.APIVersion == apiVersion
+}
+
+// NoDeprecatedAPIsFor returns a query target that succeeds if no objects were written with an API that is deprecated
+// or removed in the target Kubernetes version, e.g. NoDeprecatedAPIsFor("1.29") before an upgrade to 1.29.
+// The query is named "noDeprecatedAPIsFor-<version>". See ClusterQueryClient.ScanDeprecatedAPIs.
+func NoDeprecatedAPIsFor(targetVersion string) *QueryDeprecatedAPIs {
+	return &QueryDeprecatedAPIs{
+		name:          "noDeprecatedAPIsFor-" + targetVersion,
+		targetVersion: targetVersion,
+	}
+}
+
+// QueryDeprecatedAPIs allows for querying the use of deprecated APIs.
+type QueryDeprecatedAPIs struct {
+	name          string
+	targetVersion string
+
+	report *DeprecatedAPIReport
+}
+
+// Name is the name of the query.
+func (q *QueryDeprecatedAPIs) Name() string {
+	return q.name
+}
+
+// Report returns the report of the last run, or nil if the query failed.
+func (q *QueryDeprecatedAPIs) Report() *DeprecatedAPIReport {
+	return q.report
+}
+
+// Run the deprecated APIs query.
+func (q *QueryDeprecatedAPIs) Run(config *clusterQueryClientConfig) (bool, error) {
+	return q.RunContext(context.Background(), config)
+}
+
+// RunContext runs the deprecated APIs query using the context for the API calls.
+func (q *QueryDeprecatedAPIs) RunContext(ctx context.Context, config *clusterQueryClientConfig) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	q.report = nil
+
+	report, err := scanDeprecatedAPIs(ctx, config, q.targetVersion)
+	if err != nil {
+		return false, err
+	}
+	q.report = report
+	return len(report.InUse()) == 0, nil
+}
+
+// inUseGVRs returns the GVRs of the deprecated APIs in use, with the number of objects written with them.
+func (q *QueryDeprecatedAPIs) inUseGVRs() []string {
+	if q.report == nil {
+		return nil
+	}
+	var gvrs []string
+	for _, api := range q.report.InUse() {
+		gvr := api.GroupVersionResource
+		gvrs = append(gvrs, fmt.Sprintf("%s.%s.%s(%d)", gvr.Resource, gvr.Version, gvr.Group, len(api.Objects)))
+	}
+	return gvrs
+}
+
+// Reason returns the deprecated APIs in use, e.g. "inUse=flowschemas.v1beta2.flowcontrol.apiserver.k8s.io(2)".
+func (q *QueryDeprecatedAPIs) Reason() string {
+	return fmt.Sprintf("method=deprecatedAPIs name=%s targetVersion=%s inUse=%s status=unmatched presence=true", q.name, q.targetVersion, strings.Join(q.inUseGVRs(), ","))
+}
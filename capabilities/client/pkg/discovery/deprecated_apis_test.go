@@ -0,0 +1,178 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package discovery
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilversion "k8s.io/apimachinery/pkg/util/version"
+)
+
+func TestDeprecatedAPIsTable(t *testing.T) {
+	seen := make(map[schema.GroupVersionResource]bool)
+	for _, api := range DeprecatedAPIs() {
+		gvr := api.GroupVersionResource
+		if gvr.Version == "" || gvr.Resource == "" || api.Kind == "" {
+			t.Errorf("incomplete deprecated API %+v", api)
+		}
+		if seen[gvr] {
+			t.Errorf("duplicate deprecated API %s", gvr)
+		}
+		seen[gvr] = true
+		deprecatedIn, err := utilversion.ParseGeneric(api.DeprecatedIn)
+		if err != nil {
+			t.Errorf("invalid deprecation version of %s: %v", gvr, err)
+			continue
+		}
+		removedIn, err := utilversion.ParseGeneric(api.RemovedIn)
+		if err != nil {
+			t.Errorf("invalid removal version of %s: %v", gvr, err)
+			continue
+		}
+		if !deprecatedIn.LessThan(removedIn) {
+			t.Errorf("%s is removed in %s before it is deprecated in %s", gvr, api.RemovedIn, api.DeprecatedIn)
+		}
+		if api.ReplacedBy != "" {
+			if _, err := schema.ParseGroupVersion(api.ReplacedBy); err != nil {
+				t.Errorf("invalid replacement of %s: %v", gvr, err)
+			}
+		}
+	}
+}
+
+func TestScanDeprecatedAPIs(t *testing.T) {
+	flowSchemasV1beta2 := schema.GroupVersionResource{Group: "flowcontrol.apiserver.k8s.io", Version: "v1beta2", Resource: "flowschemas"}
+	flowSchemasV1beta3 := schema.GroupVersionResource{Group: "flowcontrol.apiserver.k8s.io", Version: "v1beta3", Resource: "flowschemas"}
+	flowSchema := func(name string, managedBy string, annotations map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": flowSchemasV1beta2.GroupVersion().String(),
+			"kind":       "FlowSchema",
+			"metadata": map[string]interface{}{
+				"name":        name,
+				"annotations": annotations,
+				"managedFields": []interface{}{
+					map[string]interface{}{"manager": "test", "operation": "Update", "apiVersion": managedBy},
+				},
+			},
+		}}
+	}
+	resources := []*metav1.APIResourceList{
+		{
+			GroupVersion: flowSchemasV1beta2.GroupVersion().String(),
+			APIResources: []metav1.APIResource{{Name: "flowschemas", Kind: "FlowSchema"}},
+		},
+		{
+			GroupVersion: flowSchemasV1beta3.GroupVersion().String(),
+			APIResources: []metav1.APIResource{{Name: "flowschemas", Kind: "FlowSchema"}},
+		},
+	}
+	c, _ := newTestClusterQueryClient(t, resources,
+		map[schema.GroupVersionResource]string{flowSchemasV1beta2: "FlowSchemaList", flowSchemasV1beta3: "FlowSchemaList"},
+		[]runtime.Object{
+			flowSchema("written-with-v1beta2", "flowcontrol.apiserver.k8s.io/v1beta2", nil),
+			flowSchema("written-with-v1beta3", "flowcontrol.apiserver.k8s.io/v1beta3", nil),
+			flowSchema("applied-with-v1beta2", "flowcontrol.apiserver.k8s.io/v1beta3", map[string]interface{}{
+				corev1.LastAppliedConfigAnnotation: `{"apiVersion":"flowcontrol.apiserver.k8s.io/v1beta2","kind":"FlowSchema"}`,
+			}),
+		})
+
+	type usage struct {
+		gvr     schema.GroupVersionResource
+		removed bool
+		objects []string
+	}
+	testCases := []struct {
+		description   string
+		targetVersion string
+		want          []usage
+		err           string
+	}{
+		{
+			description:   "before the deprecation",
+			targetVersion: "1.25",
+		},
+		{
+			description:   "deprecated API in use",
+			targetVersion: "1.28",
+			want:          []usage{{gvr: flowSchemasV1beta2, objects: []string{"applied-with-v1beta2", "written-with-v1beta2"}}},
+		},
+		{
+			description:   "removed API in use and deprecated API not in use",
+			targetVersion: "1.29.1",
+			want: []usage{
+				{gvr: flowSchemasV1beta2, removed: true, objects: []string{"applied-with-v1beta2", "written-with-v1beta2"}},
+				{gvr: flowSchemasV1beta3},
+			},
+		},
+		{
+			description:   "invalid target version",
+			targetVersion: "latest",
+			err:           `invalid target Kubernetes version "latest"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			report, err := c.ScanDeprecatedAPIs(context.Background(), tc.targetVersion)
+			if err != nil {
+				if tc.err == "" || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("want error containing %q, got: %v", tc.err, err)
+				}
+				return
+			}
+			if tc.err != "" {
+				t.Fatalf("want error containing %q, got none", tc.err)
+			}
+
+			var got []usage
+			for _, api := range report.APIs {
+				u := usage{gvr: api.GroupVersionResource, removed: api.Removed}
+				for _, o := range api.Objects {
+					if o.Kind != "FlowSchema" || o.APIVersion != api.GroupVersionResource.GroupVersion().String() {
+						t.Errorf("unexpected object reference %+v", o)
+					}
+					u.objects = append(u.objects, o.Name)
+				}
+				sort.Strings(u.objects)
+				got = append(got, u)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+
+	t.Run("query target", func(t *testing.T) {
+		for targetVersion, want := range map[string]bool{"1.25": true, "1.29": false} {
+			query := NoDeprecatedAPIsFor(targetVersion)
+			q := c.Query(query)
+			got, err := q.Execute()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != want {
+				t.Errorf("%s: got=%t, want=%t, reason: %s", targetVersion, got, want, query.Reason())
+			}
+			if want {
+				continue
+			}
+			wantReason := "method=deprecatedAPIs name=noDeprecatedAPIsFor-1.29 targetVersion=1.29 inUse=flowschemas.v1beta2.flowcontrol.apiserver.k8s.io(2) status=unmatched presence=true"
+			if query.Reason() != wantReason {
+				t.Errorf("reason: got %q, want %q", query.Reason(), wantReason)
+			}
+			if r := q.Results().ForQuery(query.Name()); !reflect.DeepEqual(r.UnmatchedGVRs, []string{flowSchemasV1beta2.String()}) {
+				t.Errorf("unmatched GVRs: got %v", r.UnmatchedGVRs)
+			}
+		}
+	})
+}
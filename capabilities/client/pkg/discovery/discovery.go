@@ -4,7 +4,14 @@
 package discovery
 
 import (
+	"context"
 	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
 
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
@@ -12,7 +19,7 @@ import (
 )
 
 // NewClusterQueryClientForConfig returns a new cluster query builder for a REST config.
-func NewClusterQueryClientForConfig(config *rest.Config) (*ClusterQueryClient, error) {
+func NewClusterQueryClientForConfig(config *rest.Config, opts ...ClusterQueryClientOption) (*ClusterQueryClient, error) {
 	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
 	if err != nil {
 		return nil, err
@@ -21,14 +28,25 @@ func NewClusterQueryClientForConfig(config *rest.Config) (*ClusterQueryClient, e
 	if err != nil {
 		return nil, err
 	}
-	return NewClusterQueryClient(dynamicClient, discoveryClient)
+	return NewClusterQueryClient(dynamicClient, discoveryClient, opts...)
 }
 
-// NewClusterQueryClient returns a new cluster query builder
-func NewClusterQueryClient(dynamicClient dynamic.Interface, discoveryClient discovery.DiscoveryInterface) (*ClusterQueryClient, error) {
+// NewClusterQueryClient returns a new cluster query builder.
+// Discovery information, REST mappings and OpenAPI definitions are cached for DefaultDiscoveryCacheTTL and shared by
+// all the queries of the client, unless configured otherwise with WithDiscoveryCacheTTL.
+func NewClusterQueryClient(dynamicClient dynamic.Interface, discoveryClient discovery.DiscoveryInterface, opts ...ClusterQueryClientOption) (*ClusterQueryClient, error) {
 	config := &clusterQueryClientConfig{
-		dynamicClient:      dynamicClient,
-		discoveryClientset: discoveryClient,
+		dynamicClient:        dynamicClient,
+		discoveryClientset:   discoveryClient,
+		cacheTTL:             DefaultDiscoveryCacheTTL,
+		watchRefreshInterval: DefaultWatchRefreshInterval,
+		now:                  time.Now,
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+	if config.cacheTTL > 0 {
+		config.cachedDiscovery = newCachedDiscovery(discoveryClient)
 	}
 
 	return &ClusterQueryClient{
@@ -39,6 +57,21 @@ func NewClusterQueryClient(dynamicClient dynamic.Interface, discoveryClient disc
 type clusterQueryClientConfig struct {
 	dynamicClient      dynamic.Interface
 	discoveryClientset discovery.DiscoveryInterface
+	cacheTTL           time.Duration
+	// watchRefreshInterval is the interval at which Watch refreshes discovery information.
+	watchRefreshInterval time.Duration
+	now                  func() time.Time
+
+	// mu guards the cached state below, which is shared by all the queries of a client.
+	mu              sync.Mutex
+	cachedDiscovery discovery.CachedDiscoveryInterface
+	mapper          meta.RESTMapper
+	definitions     map[string]map[string]interface{}
+	expiry          time.Time
+	// mapperReset is when the REST mapper last dropped the cached discovery information because a kind was not found.
+	mapperReset time.Time
+	// generation is incremented whenever the cached discovery information is dropped.
+	generation uint64
 }
 
 // ClusterQueryClient allows clients to inspect the cluster objects, GVK and schema state of a cluster
@@ -46,6 +79,13 @@ type ClusterQueryClient struct {
 	config *clusterQueryClientConfig
 }
 
+// Invalidate drops the discovery information, REST mappings and OpenAPI definitions cached by the client, so the next
+// query fetches them from the API server. Call it when the APIs served by the cluster are known to have changed, e.g.
+// after installing a CRD.
+func (c *ClusterQueryClient) Invalidate() {
+	c.config.invalidate()
+}
+
 // Query provides a new query object to prepare
 func (c *ClusterQueryClient) Query(targets ...QueryTarget) *ClusterQuery {
 	return &ClusterQuery{
@@ -69,6 +109,7 @@ func (c *ClusterQueryClient) PreparedQuery(targets ...QueryTarget) func() (bool,
 type QueryTarget interface {
 	Name() string
 	Run(config *clusterQueryClientConfig) (bool, error)
+	RunContext(ctx context.Context, config *clusterQueryClientConfig) (bool, error)
 	Reason() string
 }
 
@@ -78,6 +119,25 @@ type QueryResult struct {
 	Found bool
 	// NotFoundReason indicates the reason why Found was false.
 	NotFoundReason string
+	// Err is the error that occurred while running the query, if any.
+	Err error
+	// ErrorClass classifies Err, e.g. ErrorClassForbidden. It is empty if there is no error.
+	ErrorClass ErrorClass
+	// UnmatchedGVRs are the group versions and group version resources that were not found, for GVR queries, or the
+	// deprecated group version resources in use, for deprecated API queries.
+	UnmatchedGVRs []string
+	// UnmatchedAnnotations are the keys of the annotations that were missing, unexpected or had a different value,
+	// for object queries.
+	UnmatchedAnnotations []string
+	// UnmatchedPredicates are the field predicates that did not match, for object queries.
+	UnmatchedPredicates []string
+	// UnmatchedField is the first field of the partial schema that did not match, for partial schema queries.
+	UnmatchedField string
+	// Object is the reference of the object that was looked up, for object, status condition, APIService,
+	// CustomResourceDefinition and data key queries.
+	Object *corev1.ObjectReference
+	// Duration is how long the query took to run.
+	Duration time.Duration
 }
 
 // Results is a map of query names to their corresponding QueryResult.
@@ -89,46 +149,116 @@ func (r Results) ForQuery(queryName string) *QueryResult {
 	return r[queryName]
 }
 
+// defaultConcurrency is the default number of query targets a ClusterQuery runs in parallel.
+const defaultConcurrency = 8
+
 // ClusterQuery provides a means of executing a queries targets to determine results
 type ClusterQuery struct {
-	targets []QueryTarget
-	config  *clusterQueryClientConfig
-	results Results
+	targets     []QueryTarget
+	config      *clusterQueryClientConfig
+	results     Results
+	concurrency int
+}
+
+// WithConcurrency sets the maximum number of query targets that are run in parallel.
+// Values less than one run the targets one after another.
+func (c *ClusterQuery) WithConcurrency(n int) *ClusterQuery {
+	c.concurrency = n
+	return c
 }
 
 // Execute runs all the query targets and returns true only if *all* of them succeed.
 // For granular results of each query, use the Results() method after calling this method.
 // Normally this function is returned by Prepare() and stored as a constant to re-use
 func (c *ClusterQuery) Execute() (bool, error) {
-	// Check for duplicate query names.
-	m := make(map[string]struct{})
-	for _, t := range c.targets {
-		if _, ok := m[t.Name()]; ok {
-			return false, fmt.Errorf("query target names must be unique")
-		}
-		m[t.Name()] = struct{}{}
+	return c.ExecuteContext(context.Background())
+}
+
+// ExecuteContext runs all the query targets in parallel, bounded by the query's concurrency, and returns true only if
+// *all* of them succeed. A failing target does not stop the others: errors are recorded in each target's QueryResult
+// and returned together as an aggregate. Cancelling the context cancels the targets that are still running.
+func (c *ClusterQuery) ExecuteContext(ctx context.Context) (bool, error) {
+	if err := checkUniqueNames(c.targets); err != nil {
+		return false, err
 	}
 
+	concurrency := c.concurrency
+	if concurrency == 0 {
+		concurrency = defaultConcurrency
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]*QueryResult, len(c.targets))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, t := range c.targets {
+		wg.Add(1)
+		go func(i int, t QueryTarget) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				results[i] = &QueryResult{Err: ctx.Err(), ErrorClass: classifyError(ctx.Err())}
+				return
+			}
+			results[i] = runTarget(ctx, c.config, t)
+		}(i, t)
+	}
+	wg.Wait()
+
 	success := true
-	for _, t := range c.targets {
-		ok, err := t.Run(c.config)
-		if err != nil {
-			return false, err
+	var errs []error
+	for i, t := range c.targets {
+		c.results[t.Name()] = results[i]
+		if results[i].Err != nil {
+			errs = append(errs, fmt.Errorf("query %q failed: %w", t.Name(), results[i].Err))
 		}
-		queryResult := &QueryResult{}
-		if !ok {
-			queryResult.Found = false
-			queryResult.NotFoundReason = t.Reason()
-			c.results[t.Name()] = queryResult
+		if !results[i].Found {
 			success = false
-			continue
 		}
-		queryResult.Found = true
-		c.results[t.Name()] = queryResult
+	}
+	if len(errs) != 0 {
+		return false, kerrors.NewAggregate(errs)
 	}
 	return success, nil
 }
 
+// checkUniqueNames returns an error if two query targets have the same name.
+func checkUniqueNames(targets []QueryTarget) error {
+	m := make(map[string]struct{})
+	for _, t := range targets {
+		if _, ok := m[t.Name()]; ok {
+			return fmt.Errorf("query target names must be unique")
+		}
+		m[t.Name()] = struct{}{}
+	}
+	return nil
+}
+
+// runTarget runs a single query target and converts the outcome to a QueryResult.
+func runTarget(ctx context.Context, config *clusterQueryClientConfig, t QueryTarget) *QueryResult {
+	start := config.now()
+	ok, err := t.RunContext(ctx, config)
+	result := &QueryResult{Duration: config.now().Sub(start)}
+	if d, isDetailer := t.(resultDetailer); isDetailer {
+		d.addDetails(result)
+	}
+
+	switch {
+	case err != nil:
+		result.Err = err
+		result.ErrorClass = classifyError(err)
+	case !ok:
+		result.NotFoundReason = t.Reason()
+	default:
+		result.Found = true
+	}
+	return result
+}
+
 // Prepare queries for the discovery API on the resources, GVKs and/or partial schema a cluster has.
 func (c *ClusterQuery) Prepare() func() (bool, error) {
 	return c.Execute
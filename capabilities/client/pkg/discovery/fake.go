@@ -4,11 +4,18 @@
 package discovery
 
 import (
+	"io"
+	"net/http"
+	"strings"
+
 	openapi_v2 "github.com/google/gnostic/openapiv2"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	fakediscovery "k8s.io/client-go/discovery/fake"
 	dynamicFake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	restfake "k8s.io/client-go/rest/fake"
 	k8stesting "k8s.io/client-go/testing"
 )
 
@@ -36,10 +43,64 @@ info:
 paths:
   - '/test/path'
   - '/another/path'
+definitions:
+  io.example.v1.Widget:
+    type: object
+    required:
+      - spec
+    properties:
+      spec:
+        type: object
+        properties:
+          replicas:
+            type: integer
+            format: int32
+          mode:
+            type: string
+            enum:
+              - fast
+              - slow
 `
 	return openapi_v2.ParseDocument([]byte(schema))
 }
 
+// RESTClient serves the OpenAPI v3 discovery index and a single OpenAPI v3 document at apis/example.io/v1.
+func (fws fakeWithSchema) RESTClient() rest.Interface {
+	index := `{"paths":{"apis/example.io/v1":{"serverRelativeURL":"/openapi/v3/apis/example.io/v1?hash=ABC"}}}`
+	doc := `{
+  "openapi": "3.0.0",
+  "info": {"title": "example schema for test", "version": "1.3"},
+  "paths": {},
+  "components": {
+    "schemas": {
+      "io.example.v1.Gadget": {
+        "type": "object",
+        "properties": {
+          "status": {
+            "type": "object",
+            "properties": {"ready": {"type": "boolean"}, "replicas": {"type": "integer", "minimum": 1}}
+          }
+        }
+      }
+    }
+  }
+}`
+	return &restfake.RESTClient{
+		NegotiatedSerializer: scheme.Codecs.WithoutConversion(),
+		Client: restfake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+			body := index
+			if req.URL.Path == "/openapi/v3/apis/example.io/v1" {
+				body = doc
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+				Body:       io.NopCloser(strings.NewReader(body)),
+			}, nil
+		}),
+	}
+}
+
 // NewFakeClusterQueryClientWithSchema returns a fake ClusterQueryClient for use in tests.
 func NewFakeClusterQueryClientWithSchema(_ []*metav1.APIResourceList, scheme *runtime.Scheme, objs []runtime.Object) (*ClusterQueryClient, error) {
 	fakeDynamicClient := dynamicFake.NewSimpleDynamicClient(scheme, objs...)
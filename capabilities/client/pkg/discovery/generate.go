@@ -4,8 +4,12 @@
 package discovery
 
 import (
+	"encoding/json"
 	"fmt"
-	"math/rand"
+	"hash/fnv"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	corev1alpha2 "github.com/vmware-tanzu/tanzu-framework/apis/core/v1alpha2"
 	runv1alpha1 "github.com/vmware-tanzu/tanzu-framework/apis/run/v1alpha1"
@@ -24,11 +28,12 @@ func QueryTargetsToCapabilityResource(queryTargets []QueryTarget) (*runv1alpha1.
 		partialSchemaQueries []runv1alpha1.QueryPartialSchema
 	)
 
+	gvrNames, objectNames, partialSchemaNames := specNames{}, specNames{}, specNames{}
 	for _, qt := range queryTargets {
 		switch query := qt.(type) {
 		case *QueryGVR:
 			q := runv1alpha1.QueryGVR{
-				Name:     fmt.Sprintf("gvr-%d", rand.Int31()), //nolint:gosec
+				Name:     gvrNames.assign(query.name, "gvr", len(gvrQueries)),
 				Group:    query.group,
 				Versions: query.versions,
 				Resource: query.resource.String,
@@ -36,7 +41,7 @@ func QueryTargetsToCapabilityResource(queryTargets []QueryTarget) (*runv1alpha1.
 			gvrQueries = append(gvrQueries, q)
 		case *QueryObject:
 			q := runv1alpha1.QueryObject{
-				Name:               fmt.Sprintf("object-%d", rand.Int31()), //nolint:gosec
+				Name:               objectNames.assign(query.name, "object", len(objectQueries)),
 				ObjectReference:    *query.object,
 				WithAnnotations:    query.annotationsMap(true),
 				WithoutAnnotations: query.annotationsMap(false),
@@ -44,7 +49,7 @@ func QueryTargetsToCapabilityResource(queryTargets []QueryTarget) (*runv1alpha1.
 			objectQueries = append(objectQueries, q)
 		case *QueryPartialSchema:
 			q := runv1alpha1.QueryPartialSchema{
-				Name:          fmt.Sprintf("partialSchema-%d", rand.Int31()), //nolint:gosec
+				Name:          partialSchemaNames.assign(query.name, "partialSchema", len(partialSchemaQueries)),
 				PartialSchema: query.schema,
 			}
 			partialSchemaQueries = append(partialSchemaQueries, q)
@@ -53,16 +58,20 @@ func QueryTargetsToCapabilityResource(queryTargets []QueryTarget) (*runv1alpha1.
 		}
 	}
 
+	query := runv1alpha1.Query{
+		GroupVersionResources: gvrQueries,
+		Objects:               objectQueries,
+		PartialSchemas:        partialSchemaQueries,
+	}
+	name, err := specHashName("query", query)
+	if err != nil {
+		return nil, err
+	}
+	query.Name = name
+
 	capability := &runv1alpha1.Capability{
 		Spec: runv1alpha1.CapabilitySpec{
-			Queries: []runv1alpha1.Query{
-				{
-					Name:                  fmt.Sprintf("query-%d", rand.Int31()), //nolint:gosec
-					GroupVersionResources: gvrQueries,
-					Objects:               objectQueries,
-					PartialSchemas:        partialSchemaQueries,
-				},
-			},
+			Queries: []runv1alpha1.Query{query},
 		},
 	}
 
@@ -70,57 +79,529 @@ func QueryTargetsToCapabilityResource(queryTargets []QueryTarget) (*runv1alpha1.
 }
 
 // QueryTargetsToCapability is a helper function to generate a
-// Capability v1alpha1 resource from a slice of QueryTarget.
-//
-//nolint:dupl
+// Capability v1alpha2 resource from a slice of QueryTarget.
+// AllOf targets are flattened into the query. AnyOf and Not targets become anyOf and not combinations
+// of the query, which can only be made of GVR, Object, PartialSchema, ServerVersion, StatusCondition,
+// Access, APIService, CustomResourceDefinition, Nodes and DataKey targets.
+// Queries are named after their targets, see specNames, and the query is named after a hash of its content, so the
+// same targets always generate the same Capability.
 func QueryTargetsToCapability(queryTargets []QueryTarget) (*corev1alpha2.Capability, error) {
-	var (
-		gvrQueries           []corev1alpha2.QueryGVR
-		objectQueries        []corev1alpha2.QueryObject
-		partialSchemaQueries []corev1alpha2.QueryPartialSchema
-	)
+	query := corev1alpha2.Query{}
+	if err := addQueryTargets(&query, queryTargets); err != nil {
+		return nil, err
+	}
+	name, err := specHashName("query", query)
+	if err != nil {
+		return nil, err
+	}
+	query.Name = name
+
+	capability := &corev1alpha2.Capability{
+		Spec: corev1alpha2.CapabilitySpec{
+			Queries: []corev1alpha2.Query{query},
+		},
+	}
+
+	return capability, nil
+}
 
+// addQueryTargets adds query targets to a Capability query.
+func addQueryTargets(query *corev1alpha2.Query, queryTargets []QueryTarget) error {
+	var leaves []QueryTarget
+	anyOfNames, notNames := specNames{}, specNames{}
+	for _, qt := range flattenAllOf(queryTargets) {
+		switch target := qt.(type) {
+		case *QueryAnyOf:
+			c, err := queryTargetsToCombination(anyOfNames.assign(target.name, "anyOf", len(query.AnyOf)), target.targets)
+			if err != nil {
+				return err
+			}
+			query.AnyOf = append(query.AnyOf, *c)
+		case *QueryNot:
+			c, err := queryTargetsToCombination(notNames.assign(target.name, "not", len(query.Not)), target.targets)
+			if err != nil {
+				return err
+			}
+			query.Not = append(query.Not, *c)
+		default:
+			leaves = append(leaves, qt)
+		}
+	}
+
+	c, err := queryTargetsToCombination("", leaves)
+	if err != nil {
+		return err
+	}
+	query.GroupVersionResources = c.GroupVersionResources
+	query.Objects = c.Objects
+	query.PartialSchemas = c.PartialSchemas
+	query.ServerVersions = c.ServerVersions
+	query.StatusConditions = c.StatusConditions
+	query.AccessChecks = c.AccessChecks
+	query.APIServices = c.APIServices
+	query.CustomResourceDefinitions = c.CustomResourceDefinitions
+	query.Nodes = c.Nodes
+	query.DataKeys = c.DataKeys
+	return nil
+}
+
+// flattenAllOf replaces AllOf targets by their targets, recursively.
+func flattenAllOf(queryTargets []QueryTarget) []QueryTarget {
+	var flattened []QueryTarget
+	for _, qt := range queryTargets {
+		if allOf, ok := qt.(*QueryAllOf); ok {
+			flattened = append(flattened, flattenAllOf(allOf.targets)...)
+			continue
+		}
+		flattened = append(flattened, qt)
+	}
+	return flattened
+}
+
+// specNames assigns the names of the queries of one list in a Capability spec. A query is named after its query
+// target, unless the target has no name or the name is already taken in the list, in which case the name is derived
+// from the kind of query and its position in the list, e.g. "gvr-0".
+type specNames map[string]bool
+
+func (n specNames) assign(name, kind string, index int) string {
+	for suffix := index; name == "" || n[name]; suffix++ {
+		name = fmt.Sprintf("%s-%d", kind, suffix)
+	}
+	n[name] = true
+	return name
+}
+
+// specHashName returns a name derived from a hash of the JSON representation of a spec, e.g. "query-1a2b3c4d".
+func specHashName(prefix string, spec interface{}) (string, error) {
+	b, err := json.Marshal(spec)
+	if err != nil {
+		return "", err
+	}
+	h := fnv.New32a()
+	_, _ = h.Write(b)
+	return fmt.Sprintf("%s-%08x", prefix, h.Sum32()), nil
+}
+
+// queryTargetsToCombination converts GVR, Object, PartialSchema, ServerVersion, StatusCondition, Access, APIService,
+// CustomResourceDefinition, Nodes and DataKey query targets to a Capability query combination.
+func queryTargetsToCombination(name string, queryTargets []QueryTarget) (*corev1alpha2.QueryCombination, error) {
+	c := &corev1alpha2.QueryCombination{Name: name}
+	gvrNames, objectNames, partialSchemaNames := specNames{}, specNames{}, specNames{}
+	serverVersionNames, statusConditionNames, accessNames, apiServiceNames := specNames{}, specNames{}, specNames{}, specNames{}
+	crdNames, nodesNames, dataKeyNames := specNames{}, specNames{}, specNames{}
 	for _, qt := range queryTargets {
 		switch query := qt.(type) {
 		case *QueryGVR:
 			q := corev1alpha2.QueryGVR{
-				Name:     fmt.Sprintf("gvr-%d", rand.Int31()), //nolint:gosec
+				Name:     gvrNames.assign(query.name, "gvr", len(c.GroupVersionResources)),
 				Group:    query.group,
 				Versions: query.versions,
 				Resource: query.resource.String,
 			}
-			gvrQueries = append(gvrQueries, q)
+			c.GroupVersionResources = append(c.GroupVersionResources, q)
 		case *QueryObject:
 			q := corev1alpha2.QueryObject{
-				Name:               fmt.Sprintf("object-%d", rand.Int31()), //nolint:gosec
-				ObjectReference:    *query.object,
-				WithAnnotations:    query.annotationsMap(true),
-				WithoutAnnotations: query.annotationsMap(false),
+				Name:                objectNames.assign(query.name, "object", len(c.Objects)),
+				ObjectReference:     *query.object,
+				WithAnnotations:     query.annotationsMap(true),
+				WithoutAnnotations:  query.annotationsMap(false),
+				WithFieldPredicates: query.fieldPredicates(),
+				FieldSelector:       query.fieldSelector,
 			}
-			objectQueries = append(objectQueries, q)
+			selector, err := parseLabelSelector(query.name, query.labelSelector)
+			if err != nil {
+				return nil, err
+			}
+			q.LabelSelector = selector
+			q.MinCount, q.MaxCount = int32Count(query.minCount), int32Count(query.maxCount)
+			c.Objects = append(c.Objects, q)
 		case *QueryPartialSchema:
 			q := corev1alpha2.QueryPartialSchema{
-				Name:          fmt.Sprintf("partialSchema-%d", rand.Int31()), //nolint:gosec
+				Name:          partialSchemaNames.assign(query.name, "partialSchema", len(c.PartialSchemas)),
 				PartialSchema: query.schema,
+				Definition:    query.definition,
 			}
-			partialSchemaQueries = append(partialSchemaQueries, q)
+			if query.openAPIV3 {
+				q.OpenAPIVersion = corev1alpha2.OpenAPIV3
+				q.OpenAPIV3Paths = query.v3Paths
+			}
+			c.PartialSchemas = append(c.PartialSchemas, q)
+		case *QueryServerVersion:
+			q := corev1alpha2.QueryServerVersion{
+				Name:       serverVersionNames.assign(query.name, "serverVersion", len(c.ServerVersions)),
+				Constraint: query.constraint,
+			}
+			c.ServerVersions = append(c.ServerVersions, q)
+		case *QueryStatusCondition:
+			q := corev1alpha2.QueryStatusCondition{
+				Name:            statusConditionNames.assign(query.name, "statusCondition", len(c.StatusConditions)),
+				ObjectReference: *query.object,
+				Type:            query.conditionType,
+				Status:          query.status,
+			}
+			if query.minDuration > 0 {
+				q.MinDuration = &metav1.Duration{Duration: query.minDuration}
+			}
+			c.StatusConditions = append(c.StatusConditions, q)
+		case *QueryAccess:
+			q := corev1alpha2.QueryAccess{
+				Name:         accessNames.assign(query.name, "access", len(c.AccessChecks)),
+				Verb:         query.verb,
+				Group:        query.gvr.Group,
+				Version:      query.gvr.Version,
+				Resource:     query.gvr.Resource,
+				Subresource:  query.subresource,
+				ResourceName: query.resourceName,
+				Namespace:    query.namespace,
+			}
+			c.AccessChecks = append(c.AccessChecks, q)
+		case *QueryAPIService:
+			q := corev1alpha2.QueryAPIService{
+				Name:    apiServiceNames.assign(query.name, "apiService", len(c.APIServices)),
+				Group:   query.gv.Group,
+				Version: query.gv.Version,
+			}
+			c.APIServices = append(c.APIServices, q)
+		case *QueryCustomResourceDefinition:
+			q := corev1alpha2.QueryCustomResourceDefinition{
+				Name:                      crdNames.assign(query.name, "crd", len(c.CustomResourceDefinitions)),
+				CRDName:                   query.crdName,
+				ServedVersions:            query.servedVersions,
+				StorageVersion:            query.storageVersion,
+				DeprecatedVersions:        query.deprecatedVersions,
+				NotDeprecatedVersions:     query.notDeprecatedVersions,
+				NoPendingStorageMigration: query.noPendingStorageMigration,
+			}
+			c.CustomResourceDefinitions = append(c.CustomResourceDefinitions, q)
+		case *QueryNodes:
+			q := corev1alpha2.QueryNodes{
+				Name:              nodesNames.assign(query.name, "nodes", len(c.Nodes)),
+				Match:             corev1alpha2.NodeMatch(query.match),
+				MinCount:          int32Count(query.minCount),
+				MaxCount:          int32Count(query.maxCount),
+				Architectures:     query.architectures,
+				OperatingSystems:  query.operatingSystems,
+				ContainerRuntimes: query.containerRuntimes,
+				Taints:            nodeTaints(query.taints),
+				WithoutTaints:     nodeTaints(query.withoutTaints),
+			}
+			var err error
+			if q.LabelSelector, err = parseLabelSelector(query.name, query.labelSelector); err != nil {
+				return nil, err
+			}
+			if q.RequiredLabels, err = parseLabelSelector(query.name, query.requiredLabels); err != nil {
+				return nil, err
+			}
+			c.Nodes = append(c.Nodes, q)
+		case *QueryDataKey:
+			q := corev1alpha2.QueryDataKey{
+				Name:       dataKeyNames.assign(query.name, "dataKey", len(c.DataKeys)),
+				Kind:       corev1alpha2.DataKind(query.kind),
+				Namespace:  query.namespace,
+				ObjectName: query.objectName,
+				Key:        query.key,
+				Path:       query.path,
+				Value:      query.value,
+				Regex:      query.regex,
+			}
+			c.DataKeys = append(c.DataKeys, q)
+		case *QueryAllOf, *QueryAnyOf, *QueryNot:
+			return nil, fmt.Errorf("nested %T query target %q cannot be represented in a Capability", qt, qt.Name())
 		default:
 			return nil, fmt.Errorf("unknown QueryTarget type: %T", qt)
 		}
 	}
+	return c, nil
+}
 
-	capability := &corev1alpha2.Capability{
-		Spec: corev1alpha2.CapabilitySpec{
-			Queries: []corev1alpha2.Query{
-				{
-					Name:                  fmt.Sprintf("query-%d", rand.Int31()), //nolint:gosec
-					GroupVersionResources: gvrQueries,
-					Objects:               objectQueries,
-					PartialSchemas:        partialSchemaQueries,
-				},
-			},
-		},
+// parseLabelSelector parses the label selector of a query target, which is nil if the selector is empty.
+func parseLabelSelector(queryName, selector string) (*metav1.LabelSelector, error) {
+	if selector == "" {
+		return nil, nil
+	}
+	s, err := metav1.ParseToLabelSelector(selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid label selector of query target %q: %w", queryName, err)
 	}
+	return s, nil
+}
 
-	return capability, nil
+// formatLabelSelector formats a label selector in spec, which is empty if the selector is nil or selects everything.
+func formatLabelSelector(selector *metav1.LabelSelector) string {
+	if selector == nil || (len(selector.MatchLabels) == 0 && len(selector.MatchExpressions) == 0) {
+		return ""
+	}
+	// An invalid selector is rendered as "<error>", which fails the query when it is run.
+	return metav1.FormatLabelSelector(selector)
+}
+
+// int32Count converts a count constraint of a query target to spec.
+func int32Count(n *int) *int32 {
+	if n == nil {
+		return nil
+	}
+	count := int32(*n)
+	return &count
+}
+
+// nodeTaints converts the taints of a nodes query target to spec.
+func nodeTaints(taints []nodeTaint) []corev1alpha2.NodeTaint {
+	var specTaints []corev1alpha2.NodeTaint
+	for _, t := range taints {
+		specTaints = append(specTaints, corev1alpha2.NodeTaint{Key: t.key, Effect: t.effect})
+	}
+	return specTaints
+}
+
+// CapabilityQueryTargets are the query targets of a query of a Capability, grouped by the kind of query like the
+// results of the query in the status of the Capability. Names are only unique within a group.
+type CapabilityQueryTargets struct {
+	// Name is the name of the query.
+	Name                      string
+	GroupVersionResources     []QueryTarget
+	Objects                   []QueryTarget
+	PartialSchemas            []QueryTarget
+	ServerVersions            []QueryTarget
+	StatusConditions          []QueryTarget
+	AccessChecks              []QueryTarget
+	APIServices               []QueryTarget
+	CustomResourceDefinitions []QueryTarget
+	Nodes                     []QueryTarget
+	DataKeys                  []QueryTarget
+	AnyOf                     []QueryTarget
+	Not                       []QueryTarget
+}
+
+// AllOf returns a query target that succeeds if all the query targets of the query succeed.
+func (t *CapabilityQueryTargets) AllOf() *QueryAllOf {
+	var targets []QueryTarget
+	for _, group := range [][]QueryTarget{t.GroupVersionResources, t.Objects, t.PartialSchemas, t.ServerVersions, t.StatusConditions, t.AccessChecks, t.APIServices, t.CustomResourceDefinitions, t.Nodes, t.DataKeys, t.AnyOf, t.Not} {
+		targets = append(targets, group...)
+	}
+	return AllOf(t.Name, targets...)
+}
+
+// CapabilityToQueryTargets converts the queries of a Capability to query targets, one CapabilityQueryTargets per
+// query in the order of the spec. The Capability controller evaluates Capabilities with the same query targets, so
+// running them in-process gives the same results.
+func CapabilityToQueryTargets(capability *corev1alpha2.Capability) []CapabilityQueryTargets {
+	queries := make([]CapabilityQueryTargets, 0, len(capability.Spec.Queries))
+	for i := range capability.Spec.Queries {
+		queries = append(queries, QueryToQueryTargets(&capability.Spec.Queries[i]))
+	}
+	return queries
+}
+
+// QueryToQueryTargets converts a query of a Capability to query targets.
+func QueryToQueryTargets(query *corev1alpha2.Query) CapabilityQueryTargets {
+	t := CapabilityQueryTargets{
+		Name:                      query.Name,
+		GroupVersionResources:     gvrQueryTargets(query.GroupVersionResources),
+		Objects:                   objectQueryTargets(query.Objects),
+		PartialSchemas:            partialSchemaQueryTargets(query.PartialSchemas),
+		ServerVersions:            serverVersionQueryTargets(query.ServerVersions),
+		StatusConditions:          statusConditionQueryTargets(query.StatusConditions),
+		AccessChecks:              accessQueryTargets(query.AccessChecks),
+		APIServices:               apiServiceQueryTargets(query.APIServices),
+		CustomResourceDefinitions: crdQueryTargets(query.CustomResourceDefinitions),
+		Nodes:                     nodesQueryTargets(query.Nodes),
+		DataKeys:                  dataKeyQueryTargets(query.DataKeys),
+	}
+	for i := range query.AnyOf {
+		t.AnyOf = append(t.AnyOf, AnyOf(query.AnyOf[i].Name, combinationQueryTargets(&query.AnyOf[i])...))
+	}
+	for i := range query.Not {
+		t.Not = append(t.Not, Not(query.Not[i].Name, combinationQueryTargets(&query.Not[i])...))
+	}
+	return t
+}
+
+// gvrQueryTargets converts GVR queries in spec to query targets.
+func gvrQueryTargets(queries []corev1alpha2.QueryGVR) []QueryTarget {
+	queryTargets := make([]QueryTarget, 0, len(queries))
+	for i := range queries {
+		q := queries[i]
+		query := Group(q.Name, q.Group)
+		query.versionsRequired = true
+		// Empty versions in spec mean any version, like omitting WithVersions, rather than matching vacuously.
+		if len(q.Versions) != 0 {
+			query = query.WithVersions(q.Versions...)
+		}
+		// An empty resource in spec means any resource, like omitting WithResource.
+		if q.Resource != "" {
+			query = query.WithResource(q.Resource)
+		}
+		queryTargets = append(queryTargets, query)
+	}
+	return queryTargets
+}
+
+// objectQueryTargets converts Object queries in spec to query targets.
+func objectQueryTargets(queries []corev1alpha2.QueryObject) []QueryTarget {
+	queryTargets := make([]QueryTarget, 0, len(queries))
+	for i := range queries {
+		q := queries[i]
+		query := Object(q.Name, &q.ObjectReference).WithAnnotations(q.WithAnnotations).WithoutAnnotations(q.WithoutAnnotations)
+		for _, p := range q.WithFieldPredicates {
+			query = query.WithFieldPredicate(p.Path, FieldOperator(p.Operator), p.Values...)
+		}
+		query = query.WithLabelSelector(formatLabelSelector(q.LabelSelector))
+		query = query.WithFieldSelector(q.FieldSelector)
+		if q.MinCount != nil {
+			query = query.WithMinCount(int(*q.MinCount))
+		}
+		if q.MaxCount != nil {
+			query = query.WithMaxCount(int(*q.MaxCount))
+		}
+		queryTargets = append(queryTargets, query)
+	}
+	return queryTargets
+}
+
+// partialSchemaQueryTargets converts PartialSchema queries in spec to query targets.
+func partialSchemaQueryTargets(queries []corev1alpha2.QueryPartialSchema) []QueryTarget {
+	queryTargets := make([]QueryTarget, 0, len(queries))
+	for i := range queries {
+		q := queries[i]
+		query := Schema(q.Name, q.PartialSchema).WithDefinition(q.Definition)
+		if q.OpenAPIVersion == corev1alpha2.OpenAPIV3 {
+			query = query.WithOpenAPIV3(q.OpenAPIV3Paths...)
+		}
+		queryTargets = append(queryTargets, query)
+	}
+	return queryTargets
+}
+
+// serverVersionQueryTargets converts ServerVersion queries in spec to query targets.
+func serverVersionQueryTargets(queries []corev1alpha2.QueryServerVersion) []QueryTarget {
+	queryTargets := make([]QueryTarget, 0, len(queries))
+	for i := range queries {
+		queryTargets = append(queryTargets, ServerVersion(queries[i].Name).WithConstraint(queries[i].Constraint))
+	}
+	return queryTargets
+}
+
+// statusConditionQueryTargets converts StatusCondition queries in spec to query targets.
+func statusConditionQueryTargets(queries []corev1alpha2.QueryStatusCondition) []QueryTarget {
+	queryTargets := make([]QueryTarget, 0, len(queries))
+	for i := range queries {
+		q := queries[i]
+		query := StatusCondition(q.Name, &q.ObjectReference, q.Type)
+		if q.Status != "" {
+			query = query.WithStatus(q.Status)
+		}
+		if q.MinDuration != nil {
+			query = query.WithMinDuration(q.MinDuration.Duration)
+		}
+		queryTargets = append(queryTargets, query)
+	}
+	return queryTargets
+}
+
+// accessQueryTargets converts Access queries in spec to query targets.
+func accessQueryTargets(queries []corev1alpha2.QueryAccess) []QueryTarget {
+	queryTargets := make([]QueryTarget, 0, len(queries))
+	for i := range queries {
+		q := queries[i]
+		gvr := schema.GroupVersionResource{Group: q.Group, Version: q.Version, Resource: q.Resource}
+		query := Access(q.Name, q.Verb, gvr, q.Namespace).WithSubresource(q.Subresource).WithResourceName(q.ResourceName)
+		queryTargets = append(queryTargets, query)
+	}
+	return queryTargets
+}
+
+// apiServiceQueryTargets converts APIService queries in spec to query targets.
+func apiServiceQueryTargets(queries []corev1alpha2.QueryAPIService) []QueryTarget {
+	queryTargets := make([]QueryTarget, 0, len(queries))
+	for i := range queries {
+		gv := schema.GroupVersion{Group: queries[i].Group, Version: queries[i].Version}
+		queryTargets = append(queryTargets, APIService(queries[i].Name, gv))
+	}
+	return queryTargets
+}
+
+// crdQueryTargets converts CustomResourceDefinition queries in spec to query targets.
+func crdQueryTargets(queries []corev1alpha2.QueryCustomResourceDefinition) []QueryTarget {
+	queryTargets := make([]QueryTarget, 0, len(queries))
+	for i := range queries {
+		q := queries[i]
+		query := CustomResourceDefinition(q.Name, q.CRDName).
+			WithServedVersions(q.ServedVersions...).
+			WithStorageVersion(q.StorageVersion).
+			WithDeprecatedVersions(q.DeprecatedVersions...).
+			WithNotDeprecatedVersions(q.NotDeprecatedVersions...)
+		if q.NoPendingStorageMigration {
+			query = query.WithNoPendingStorageMigration()
+		}
+		queryTargets = append(queryTargets, query)
+	}
+	return queryTargets
+}
+
+// nodesQueryTargets converts Nodes queries in spec to query targets.
+func nodesQueryTargets(queries []corev1alpha2.QueryNodes) []QueryTarget {
+	queryTargets := make([]QueryTarget, 0, len(queries))
+	for i := range queries {
+		q := queries[i]
+		query := Nodes(q.Name).
+			WithLabelSelector(formatLabelSelector(q.LabelSelector)).
+			WithRequiredLabels(formatLabelSelector(q.RequiredLabels)).
+			WithArchitectures(q.Architectures...).
+			WithOperatingSystems(q.OperatingSystems...).
+			WithContainerRuntimes(q.ContainerRuntimes...)
+		if q.Match != "" {
+			query = query.WithMatch(NodeMatch(q.Match))
+		}
+		if q.MinCount != nil {
+			query = query.WithMinCount(int(*q.MinCount))
+		}
+		if q.MaxCount != nil {
+			query = query.WithMaxCount(int(*q.MaxCount))
+		}
+		for _, t := range q.Taints {
+			query = query.WithTaint(t.Key, t.Effect)
+		}
+		for _, t := range q.WithoutTaints {
+			query = query.WithoutTaint(t.Key, t.Effect)
+		}
+		queryTargets = append(queryTargets, query)
+	}
+	return queryTargets
+}
+
+// dataKeyQueryTargets converts DataKey queries in spec to query targets.
+func dataKeyQueryTargets(queries []corev1alpha2.QueryDataKey) []QueryTarget {
+	queryTargets := make([]QueryTarget, 0, len(queries))
+	for i := range queries {
+		q := queries[i]
+		// The kind is not mapped to ConfigMapKey or SecretKey, so that an unknown kind fails the query when it is run.
+		query := &QueryDataKey{
+			name:       q.Name,
+			kind:       DataKind(q.Kind),
+			namespace:  q.Namespace,
+			objectName: q.ObjectName,
+			key:        q.Key,
+			path:       q.Path,
+			regex:      q.Regex,
+		}
+		if q.Value != nil {
+			query = query.WithValue(*q.Value)
+		}
+		queryTargets = append(queryTargets, query)
+	}
+	return queryTargets
+}
+
+// combinationQueryTargets converts all the queries of a combination in spec to query targets.
+func combinationQueryTargets(c *corev1alpha2.QueryCombination) []QueryTarget {
+	queryTargets := gvrQueryTargets(c.GroupVersionResources)
+	queryTargets = append(queryTargets, objectQueryTargets(c.Objects)...)
+	queryTargets = append(queryTargets, partialSchemaQueryTargets(c.PartialSchemas)...)
+	queryTargets = append(queryTargets, serverVersionQueryTargets(c.ServerVersions)...)
+	queryTargets = append(queryTargets, statusConditionQueryTargets(c.StatusConditions)...)
+	queryTargets = append(queryTargets, accessQueryTargets(c.AccessChecks)...)
+	queryTargets = append(queryTargets, apiServiceQueryTargets(c.APIServices)...)
+	queryTargets = append(queryTargets, crdQueryTargets(c.CustomResourceDefinitions)...)
+	queryTargets = append(queryTargets, nodesQueryTargets(c.Nodes)...)
+	return append(queryTargets, dataKeyQueryTargets(c.DataKeys)...)
 }
@@ -4,11 +4,14 @@
 package discovery
 
 import (
+	"context"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 
 	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	testapigroup "k8s.io/apimachinery/pkg/apis/testapigroup/v1"
 
 	corev1alpha2 "github.com/vmware-tanzu/tanzu-framework/apis/core/v1alpha2"
@@ -25,6 +28,9 @@ func (unk unknownQueryType) Name() string {
 func (unk unknownQueryType) Run(_ *clusterQueryClientConfig) (bool, error) {
 	return false, nil
 }
+func (unk unknownQueryType) RunContext(_ context.Context, _ *clusterQueryClientConfig) (bool, error) {
+	return false, nil
+}
 func (unk unknownQueryType) Reason() string {
 	return ""
 }
@@ -256,3 +262,394 @@ func TestQueryTargetsToCapability(t *testing.T) {
 		})
 	}
 }
+
+func TestQueryTargetsToCapabilityCombinators(t *testing.T) {
+	v1alpha1 := Group("v1alpha1", "run.tanzu.vmware.com").WithVersions("v1alpha1").WithResource("tanzukubernetesclusters")
+	v1alpha3 := Group("v1alpha3", "run.tanzu.vmware.com").WithVersions("v1alpha3").WithResource("tanzukubernetesclusters")
+	nsx := Object("nsx", &corev1.ObjectReference{Kind: "Namespace", Name: "vmware-system-nsx", APIVersion: "v1"})
+	schema := Schema("schema", "properties: {spec: {}}")
+
+	testCases := []struct {
+		description  string
+		queryTargets []QueryTarget
+		wantGVRs     int
+		wantSchemas  int
+		wantAnyOf    []string
+		wantNot      []string
+		err          string
+	}{
+		{
+			description:  "anyOf and not become combinations",
+			queryTargets: []QueryTarget{AnyOf("tkc", v1alpha1, v1alpha3), Not("no-nsx", nsx)},
+			wantAnyOf:    []string{"tkc"},
+			wantNot:      []string{"no-nsx"},
+		},
+		{
+			description:  "allOf is flattened into the query",
+			queryTargets: []QueryTarget{AllOf("all", v1alpha1, AllOf("nested", v1alpha3, schema))},
+			wantGVRs:     2,
+			wantSchemas:  1,
+		},
+		{
+			description:  "nested combinator in anyOf",
+			queryTargets: []QueryTarget{AnyOf("tkc", v1alpha1, Not("no-nsx", nsx))},
+			err:          `nested *discovery.QueryNot query target "no-nsx" cannot be represented in a Capability`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			got, err := QueryTargetsToCapability(tc.queryTargets)
+			if tc.err != "" {
+				if err == nil || err.Error() != tc.err {
+					t.Errorf("want error: %s but got: %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			query := got.Spec.Queries[0]
+			if len(query.GroupVersionResources) != tc.wantGVRs || len(query.PartialSchemas) != tc.wantSchemas {
+				t.Errorf("want %d GVRs and %d partial schemas, got: %+v", tc.wantGVRs, tc.wantSchemas, query)
+			}
+			var anyOf, not []string
+			for _, c := range query.AnyOf {
+				anyOf = append(anyOf, c.Name)
+			}
+			for _, c := range query.Not {
+				not = append(not, c.Name)
+			}
+			if !reflect.DeepEqual(anyOf, tc.wantAnyOf) || !reflect.DeepEqual(not, tc.wantNot) {
+				t.Errorf("want anyOf %v and not %v, got anyOf %v and not %v", tc.wantAnyOf, tc.wantNot, anyOf, not)
+			}
+			if len(tc.wantAnyOf) != 0 && len(query.AnyOf[0].GroupVersionResources) != 2 {
+				t.Errorf("want 2 GVRs in anyOf, got: %+v", query.AnyOf[0])
+			}
+			if len(tc.wantNot) != 0 && len(query.Not[0].Objects) != 1 {
+				t.Errorf("want 1 object in not, got: %+v", query.Not[0])
+			}
+		})
+	}
+}
+
+func TestQueryTargetsToCapabilityNames(t *testing.T) {
+	carps := Group("", testapigroup.SchemeGroupVersion.Group).WithResource("carps")
+	queryTargets := []QueryTarget{
+		Group("carps", testapigroup.SchemeGroupVersion.Group).WithResource("carps"),
+		carps,
+		Group("carps", testapigroup.SchemeGroupVersion.Group).WithVersions("v1"),
+		Schema("", "properties: {spec: {}}"),
+		AnyOf("", carps),
+	}
+
+	got, err := QueryTargetsToCapability(queryTargets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := QueryTargetsToCapability(queryTargets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, again) {
+		t.Errorf("want the same Capability from the same query targets, got %+v and %+v", got, again)
+	}
+
+	query := got.Spec.Queries[0]
+	if !strings.HasPrefix(query.Name, "query-") {
+		t.Errorf("want query name derived from its content, got %q", query.Name)
+	}
+	var gvrNames []string
+	for _, q := range query.GroupVersionResources {
+		gvrNames = append(gvrNames, q.Name)
+	}
+	if want := []string{"carps", "gvr-1", "gvr-2"}; !reflect.DeepEqual(gvrNames, want) {
+		t.Errorf("GVR query names: got %v, want %v", gvrNames, want)
+	}
+	if name := query.PartialSchemas[0].Name; name != "partialSchema-0" {
+		t.Errorf("partial schema query name: got %q, want %q", name, "partialSchema-0")
+	}
+	if name := query.AnyOf[0].Name; name != "anyOf-0" {
+		t.Errorf("anyOf query name: got %q, want %q", name, "anyOf-0")
+	}
+
+	changed, err := QueryTargetsToCapability(queryTargets[1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed.Spec.Queries[0].Name == query.Name {
+		t.Errorf("want a different query name for different query targets, got %q", query.Name)
+	}
+}
+
+func TestCapabilityToQueryTargets(t *testing.T) {
+	missing := carp
+	missing.Name = "missing"
+	queryTargets := []QueryTarget{
+		testGVR,
+		testObject,
+		ServerVersion("kubeVersion").WithConstraint("<2.0"),
+		AnyOf("anyVersion", Group("v2", testapigroup.SchemeGroupVersion.Group).WithVersions("v2"), Group("v1", testapigroup.SchemeGroupVersion.Group).WithVersions("v1")),
+		Not("noMissingCarp", Object("missing", &missing), CustomResourceDefinition("missingCRD", "missings.example.com").WithStorageVersion("v1").WithNoPendingStorageMigration()),
+	}
+	capability, err := QueryTargetsToCapability(queryTargets)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	queries := CapabilityToQueryTargets(capability)
+	if len(queries) != 1 {
+		t.Fatalf("want query targets for 1 query, got %d", len(queries))
+	}
+	got := queries[0]
+	if got.Name != capability.Spec.Queries[0].Name {
+		t.Errorf("query name: got %q, want %q", got.Name, capability.Spec.Queries[0].Name)
+	}
+	for _, group := range []struct {
+		targets []QueryTarget
+		want    []string
+	}{
+		{got.GroupVersionResources, []string{"carpResource"}},
+		{got.Objects, []string{"carpObj"}},
+		{got.ServerVersions, []string{"kubeVersion"}},
+		{got.AnyOf, []string{"anyVersion"}},
+		{got.Not, []string{"noMissingCarp"}},
+	} {
+		var names []string
+		for _, qt := range group.targets {
+			names = append(names, qt.Name())
+		}
+		if !reflect.DeepEqual(names, group.want) {
+			t.Errorf("query target names: got %v, want %v", names, group.want)
+		}
+	}
+
+	// Converting the query targets back gives the same Capability.
+	roundTrip, err := QueryTargetsToCapability(got.AllOf().targets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(roundTrip, capability) {
+		t.Errorf("want the same Capability after a round trip, got %+v, want %+v", roundTrip, capability)
+	}
+
+	c, err := queryClientWithResourcesAndObjects()
+	if err != nil {
+		t.Fatal(err)
+	}
+	allOf := got.AllOf()
+	ok, err := c.Query(allOf).Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Errorf("want the query targets of the Capability to succeed, reason: %s", allOf.Reason())
+	}
+}
+
+func TestCapabilityToQueryTargetsEmptyVersions(t *testing.T) {
+	capability := &corev1alpha2.Capability{
+		Spec: corev1alpha2.CapabilitySpec{
+			Queries: []corev1alpha2.Query{{
+				Name: "fish",
+				GroupVersionResources: []corev1alpha2.QueryGVR{
+					{Name: "carps", Group: testapigroup.SchemeGroupVersion.Group, Versions: []string{}},
+					{Name: "trouts", Group: "fish.example.com", Versions: []string{}},
+				},
+			}},
+		},
+	}
+
+	c, err := queryClientWithResourcesAndObjects()
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := c.Query(CapabilityToQueryTargets(capability)[0].GroupVersionResources...)
+	if _, err := q.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if !q.Results().ForQuery("carps").Found {
+		t.Errorf("want empty versions to match any version of a served group, got %+v", q.Results().ForQuery("carps"))
+	}
+	if q.Results().ForQuery("trouts").Found {
+		t.Errorf("want empty versions not to match a group that is not served, got %+v", q.Results().ForQuery("trouts"))
+	}
+}
+
+func TestNodesQueryTargetsRoundTrip(t *testing.T) {
+	queryTargets := []QueryTarget{
+		Nodes("windowsPool").
+			WithLabelSelector("pool=windows").
+			WithRequiredLabels("!nvidia.com/gpu.present").
+			WithMatch(NodeMatchAny).
+			WithMinCount(2).
+			WithOperatingSystems("windows").
+			WithArchitectures("amd64").
+			WithContainerRuntimes("containerd").
+			WithTaint("os", corev1.TaintEffectNoSchedule).
+			WithoutTaint("node.kubernetes.io/unreachable", ""),
+	}
+	capability, err := QueryTargetsToCapability(queryTargets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodes := capability.Spec.Queries[0].Nodes
+	if len(nodes) != 1 || nodes[0].Match != corev1alpha2.NodeMatchAny || nodes[0].LabelSelector.MatchLabels["pool"] != "windows" {
+		t.Fatalf("unexpected Nodes queries: %+v", nodes)
+	}
+
+	got := CapabilityToQueryTargets(capability)[0]
+	if !reflect.DeepEqual(got.Nodes, queryTargets) {
+		t.Errorf("got %+v, want %+v", got.Nodes[0], queryTargets[0])
+	}
+}
+
+func TestDataKeyQueryTargetsRoundTrip(t *testing.T) {
+	queryTargets := []QueryTarget{
+		ConfigMapKey("tkgType", "tkg-system-public", "tkg-metadata", "metadata.yaml").WithPath("cluster.type").WithValue(""),
+		SecretKey("endpoint", "tkg-system", "credentials", "config").WithPath("endpoint").WithRegex("^https://"),
+	}
+	capability, err := QueryTargetsToCapability(queryTargets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dataKeys := capability.Spec.Queries[0].DataKeys
+	if len(dataKeys) != 2 || dataKeys[0].Kind != corev1alpha2.DataKindConfigMap || dataKeys[0].Value == nil || dataKeys[1].Kind != corev1alpha2.DataKindSecret {
+		t.Fatalf("unexpected DataKey queries: %+v", dataKeys)
+	}
+
+	got := CapabilityToQueryTargets(capability)[0]
+	if !reflect.DeepEqual(got.DataKeys, queryTargets) {
+		t.Errorf("got %+v, want %+v", got.DataKeys, queryTargets)
+	}
+}
+
+func TestValidateCapability(t *testing.T) {
+	deployment := corev1.ObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "kube-system", Name: "coredns"}
+	minCount, maxCount := int32(2), int32(1)
+
+	testCases := []struct {
+		description string
+		query       corev1alpha2.Query
+		wantFields  []string
+	}{
+		{
+			description: "valid queries",
+			query: corev1alpha2.Query{
+				Name:                  "valid",
+				GroupVersionResources: []corev1alpha2.QueryGVR{{Name: "gvr", Group: "apps", Versions: []string{"v1"}, Resource: "deployments"}},
+				Objects:               []corev1alpha2.QueryObject{{Name: "object", ObjectReference: deployment}, {Name: "coreObject", ObjectReference: corev1.ObjectReference{Kind: "Namespace", Name: "default"}}},
+				PartialSchemas:        []corev1alpha2.QueryPartialSchema{{Name: "schema", PartialSchema: "type: object"}},
+				StatusConditions:      []corev1alpha2.QueryStatusCondition{{Name: "available", ObjectReference: deployment, Type: "Available"}},
+			},
+		},
+		{
+			description: "empty GVR version",
+			query: corev1alpha2.Query{
+				Name:                  "gvr",
+				GroupVersionResources: []corev1alpha2.QueryGVR{{Name: "gvr", Group: "apps", Versions: []string{"v1", " "}}},
+			},
+			wantFields: []string{"spec.queries[0].groupVersionResources[0]"},
+		},
+		{
+			description: "GVR resource without versions",
+			query: corev1alpha2.Query{
+				Name: "gvr",
+				GroupVersionResources: []corev1alpha2.QueryGVR{
+					{Name: "noVersions", Group: "apps", Resource: "deployments"},
+					{Name: "emptyVersions", Group: "apps", Versions: []string{}, Resource: "deployments"},
+				},
+			},
+			wantFields: []string{"spec.queries[0].groupVersionResources[0]", "spec.queries[0].groupVersionResources[1]"},
+		},
+		{
+			description: "unparseable partial schema",
+			query: corev1alpha2.Query{
+				Name:           "schema",
+				PartialSchemas: []corev1alpha2.QueryPartialSchema{{Name: "ok", PartialSchema: "type: object"}, {Name: "broken", PartialSchema: "type: [object"}},
+			},
+			wantFields: []string{"spec.queries[0].partialSchemas[1]"},
+		},
+		{
+			description: "object reference without a kind",
+			query: corev1alpha2.Query{
+				Name:             "refs",
+				Objects:          []corev1alpha2.QueryObject{{Name: "object", ObjectReference: corev1.ObjectReference{APIVersion: "v1", Name: "default"}}},
+				StatusConditions: []corev1alpha2.QueryStatusCondition{{Name: "condition", ObjectReference: corev1.ObjectReference{APIVersion: "apps/v1", Name: "coredns"}, Type: "Available"}},
+			},
+			wantFields: []string{"spec.queries[0].objects[0]", "spec.queries[0].statusConditions[0]"},
+		},
+		{
+			description: "invalid count and predicate",
+			query: corev1alpha2.Query{
+				Name: "objects",
+				Objects: []corev1alpha2.QueryObject{
+					{Name: "count", ObjectReference: corev1.ObjectReference{APIVersion: "v1", Kind: "Node"}, MinCount: &minCount, MaxCount: &maxCount},
+					{Name: "predicate", ObjectReference: deployment, WithFieldPredicates: []corev1alpha2.FieldPredicate{{Path: "spec.replicas", Operator: corev1alpha2.FieldPredicateOperator(FieldEquals)}}},
+				},
+			},
+			wantFields: []string{"spec.queries[0].objects[0]", "spec.queries[0].objects[1]"},
+		},
+		{
+			description: "invalid queries in combinations",
+			query: corev1alpha2.Query{
+				Name:  "combinations",
+				AnyOf: []corev1alpha2.QueryCombination{{Name: "anyOf", ServerVersions: []corev1alpha2.QueryServerVersion{{Name: "version", Constraint: "not a constraint"}}}},
+				Not:   []corev1alpha2.QueryCombination{{Name: "not", DataKeys: []corev1alpha2.QueryDataKey{{Name: "key", Kind: corev1alpha2.DataKindConfigMap, Namespace: "default", ObjectName: "config"}}}},
+			},
+			wantFields: []string{"spec.queries[0].anyOf[0].serverVersions[0]", "spec.queries[0].not[0].dataKeys[0]"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			capability := &corev1alpha2.Capability{Spec: corev1alpha2.CapabilitySpec{Queries: []corev1alpha2.Query{tc.query}}}
+			errs := ValidateCapability(capability)
+			var gotFields []string
+			for _, err := range errs {
+				gotFields = append(gotFields, err.Field)
+			}
+			if !reflect.DeepEqual(gotFields, tc.wantFields) {
+				t.Errorf("got errors %v, want errors for fields %v", errs, tc.wantFields)
+			}
+		})
+	}
+}
+
+func TestRunCapabilityConversionRoundTrip(t *testing.T) {
+	queryTargets := []QueryTarget{
+		Group("apps", "apps").WithVersions("v1").WithResource("deployments"),
+		Object("coredns", &corev1.ObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "kube-system", Name: "coredns"}),
+		ServerVersion("version").WithConstraint(">=1.24"),
+	}
+	capability, err := QueryTargetsToCapability(queryTargets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	capability.Name = "cluster"
+
+	runCapability, err := CapabilityToRunCapability(capability)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runCapability.APIVersion != runv1alpha1.GroupVersion.String() || runCapability.Kind != "Capability" {
+		t.Errorf("unexpected type of converted Capability: %v", runCapability.TypeMeta)
+	}
+	query := runCapability.Spec.Queries[0]
+	if len(query.GroupVersionResources) != 1 || len(query.Objects) != 1 || query.Objects[0].ObjectReference.Name != "coredns" {
+		t.Errorf("unexpected run.tanzu.vmware.com query: %+v", query)
+	}
+
+	got, err := RunCapabilityToCapability(runCapability)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.APIVersion != corev1alpha2.GroupVersion.String() || got.Name != "cluster" {
+		t.Errorf("unexpected converted Capability: %v %v", got.TypeMeta, got.ObjectMeta)
+	}
+	// Empty and nil lists and maps are equal, since they are omitted from the conversion data.
+	if !apiequality.Semantic.DeepEqual(got.Spec, capability.Spec) {
+		t.Errorf("got spec %+v, want %+v", got.Spec, capability.Spec)
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package discovery
+
+import (
+	"context"
+	"errors"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+)
+
+// ErrorClass classifies the error of a query, so that callers can act on it without parsing the error message.
+type ErrorClass string
+
+const (
+	// ErrorClassNotFound means the resource or kind of the query is not served by the cluster.
+	ErrorClassNotFound ErrorClass = "NotFound"
+	// ErrorClassForbidden means the client is not allowed to run the query.
+	ErrorClassForbidden ErrorClass = "Forbidden"
+	// ErrorClassUnauthorized means the client could not be authenticated.
+	ErrorClassUnauthorized ErrorClass = "Unauthorized"
+	// ErrorClassTimeout means the query did not complete in time.
+	ErrorClassTimeout ErrorClass = "Timeout"
+	// ErrorClassCanceled means the context of the query was canceled.
+	ErrorClassCanceled ErrorClass = "Canceled"
+	// ErrorClassUnknown is any other error.
+	ErrorClassUnknown ErrorClass = "Unknown"
+)
+
+// classifyError returns the class of an error, which may be wrapped.
+func classifyError(err error) ErrorClass {
+	var noResourceMatch *meta.NoResourceMatchError
+	var noKindMatch *meta.NoKindMatchError
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), apierrors.IsTimeout(err), apierrors.IsServerTimeout(err):
+		return ErrorClassTimeout
+	case errors.Is(err, context.Canceled):
+		return ErrorClassCanceled
+	case apierrors.IsNotFound(err), errors.As(err, &noResourceMatch), errors.As(err, &noKindMatch):
+		return ErrorClassNotFound
+	case apierrors.IsForbidden(err):
+		return ErrorClassForbidden
+	case apierrors.IsUnauthorized(err):
+		return ErrorClassUnauthorized
+	}
+	return ErrorClassUnknown
+}
+
+// resultDetailer is implemented by query targets that report structured details of their last run in the QueryResult.
+type resultDetailer interface {
+	addDetails(r *QueryResult)
+}
+
+func (q *QueryGVR) addDetails(r *QueryResult) {
+	r.UnmatchedGVRs = q.unmatchedGVRs
+}
+
+func (q *QueryObject) addDetails(r *QueryResult) {
+	r.Object = q.object
+	r.UnmatchedAnnotations = q.unmatchedAnnotations
+	r.UnmatchedPredicates = q.unmatchedPredicates
+}
+
+func (q *QueryStatusCondition) addDetails(r *QueryResult) {
+	r.Object = q.object
+}
+
+func (q *QueryAPIService) addDetails(r *QueryResult) {
+	r.Object = q.object()
+}
+
+func (q *QueryCustomResourceDefinition) addDetails(r *QueryResult) {
+	r.Object = q.object()
+}
+
+func (q *QueryDataKey) addDetails(r *QueryResult) {
+	r.Object = q.object()
+}
+
+func (q *QueryDeprecatedAPIs) addDetails(r *QueryResult) {
+	if q.report == nil {
+		return
+	}
+	for _, api := range q.report.InUse() {
+		r.UnmatchedGVRs = append(r.UnmatchedGVRs, api.GroupVersionResource.String())
+	}
+}
+
+func (q *QueryPartialSchema) addDetails(r *QueryResult) {
+	r.UnmatchedField = q.unmatchedField
+}
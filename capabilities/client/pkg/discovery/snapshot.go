@@ -0,0 +1,461 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package discovery
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	openapi_v2 "github.com/google/gnostic/openapiv2"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	versioninfo "k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/discovery"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/dynamic"
+	dynamicFake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	restfake "k8s.io/client-go/rest/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/yaml"
+)
+
+// Files and directories of a cluster snapshot, relative to the snapshot directory.
+const (
+	// SnapshotVersionFile holds the version.Info of the API server as JSON.
+	SnapshotVersionFile = "version.json"
+	// SnapshotResourcesFile holds the []*metav1.APIResourceList served by the API server as JSON.
+	SnapshotResourcesFile = "resources.json"
+	// SnapshotOpenAPIV2File holds the OpenAPI v2 document of the API server as YAML or JSON.
+	SnapshotOpenAPIV2File = "openapi-v2.yaml"
+	// SnapshotOpenAPIV3Dir holds the OpenAPI v3 discovery index, index.json, and a JSON document per path of the
+	// index, e.g. apis/apps/v1.json.
+	SnapshotOpenAPIV3Dir = "openapi-v3"
+	// SnapshotObjectsDir holds YAML or JSON files of objects, with any number of documents per file. Documents may
+	// also be lists of objects, such as the output of kubectl get -o yaml.
+	SnapshotObjectsDir = "objects"
+)
+
+const snapshotOpenAPIV3Index = "index.json"
+
+// NewClusterQueryClientFromSnapshot returns a ClusterQueryClient that answers queries from a cluster snapshot
+// recorded in dir, e.g. by RecordClusterSnapshot or from a support bundle, without access to the cluster.
+// Only resources.json is required; the other files of the snapshot are optional.
+// Access queries are always denied, as a snapshot does not record the permissions of the client.
+func NewClusterQueryClientFromSnapshot(dir string, opts ...ClusterQueryClientOption) (*ClusterQueryClient, error) {
+	resources := []*metav1.APIResourceList{}
+	if err := readSnapshotJSON(filepath.Join(dir, SnapshotResourcesFile), &resources); err != nil {
+		return nil, err
+	}
+
+	fakeDiscoveryClient := &snapshotDiscovery{
+		FakeDiscovery: &fakediscovery.FakeDiscovery{
+			Fake: &k8stesting.Fake{
+				Resources: resources,
+			},
+		},
+		dir: dir,
+	}
+	versionFile := filepath.Join(dir, SnapshotVersionFile)
+	if _, err := os.Stat(versionFile); err == nil {
+		info := &versioninfo.Info{}
+		if err := readSnapshotJSON(versionFile, info); err != nil {
+			return nil, err
+		}
+		fakeDiscoveryClient.FakedServerVersion = info
+	}
+
+	objs, err := readSnapshotObjects(filepath.Join(dir, SnapshotObjectsDir))
+	if err != nil {
+		return nil, err
+	}
+	fakeDynamicClient, err := newSnapshotDynamicClient(resources, objs)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewClusterQueryClient(fakeDynamicClient, fakeDiscoveryClient, opts...)
+}
+
+// snapshotDiscovery is the same as fakediscovery.FakeDiscovery except it serves the OpenAPI documents of a snapshot.
+type snapshotDiscovery struct {
+	*fakediscovery.FakeDiscovery
+	dir string
+}
+
+// OpenAPISchema returns the OpenAPI v2 document of the snapshot, or an empty document if there is none.
+func (s *snapshotDiscovery) OpenAPISchema() (*openapi_v2.Document, error) {
+	b, err := os.ReadFile(filepath.Join(s.dir, SnapshotOpenAPIV2File))
+	if errors.Is(err, os.ErrNotExist) {
+		return &openapi_v2.Document{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return openapi_v2.ParseDocument(b)
+}
+
+// RESTClient serves the OpenAPI v3 discovery index and documents of the snapshot. Other requests are not found.
+func (s *snapshotDiscovery) RESTClient() rest.Interface {
+	return &restfake.RESTClient{
+		NegotiatedSerializer: scheme.Codecs.WithoutConversion(),
+		Client: restfake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+			status, body := http.StatusOK, []byte(nil)
+			file, ok := s.openAPIV3File(req.URL.Path)
+			if ok {
+				var err error
+				if body, err = os.ReadFile(file); errors.Is(err, os.ErrNotExist) {
+					ok = false
+				} else if err != nil {
+					return nil, err
+				}
+			}
+			if !ok {
+				status = http.StatusNotFound
+				body, _ = json.Marshal(apierrors.NewNotFound(schema.GroupResource{}, req.URL.Path).Status())
+			}
+			return &http.Response{
+				StatusCode: status,
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+				Body:       io.NopCloser(bytes.NewReader(body)),
+			}, nil
+		}),
+	}
+}
+
+// openAPIV3File returns the snapshot file for an OpenAPI v3 URL path, e.g. /openapi/v3/apis/apps/v1.
+func (s *snapshotDiscovery) openAPIV3File(urlPath string) (string, bool) {
+	base := filepath.Join(s.dir, SnapshotOpenAPIV3Dir)
+	if urlPath == "/openapi/v3" {
+		return filepath.Join(base, snapshotOpenAPIV3Index), true
+	}
+	path := strings.TrimPrefix(urlPath, "/openapi/v3/")
+	if path == urlPath || path == "" {
+		return "", false
+	}
+	file := filepath.Join(base, filepath.FromSlash(path)+".json")
+	// Paths must not escape the snapshot.
+	if !strings.HasPrefix(file, base+string(filepath.Separator)) {
+		return "", false
+	}
+	return file, true
+}
+
+// newSnapshotDynamicClient returns a fake dynamic client serving the objects. Objects are stored under the resource
+// that serves their kind in the snapshot, so that irregular plurals do not have to be guessed.
+func newSnapshotDynamicClient(resources []*metav1.APIResourceList, objs []*unstructured.Unstructured) (*dynamicFake.FakeDynamicClient, error) {
+	listKinds := make(map[schema.GroupVersionResource]string)
+	kindResources := make(map[schema.GroupVersionKind]schema.GroupVersionResource)
+	for _, list := range resources {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid group version %q in snapshot: %w", list.GroupVersion, err)
+		}
+		for i := range list.APIResources {
+			r := &list.APIResources[i]
+			// Subresources, e.g. deployments/status, have the kind of their parent.
+			if strings.Contains(r.Name, "/") {
+				continue
+			}
+			gvr := gv.WithResource(r.Name)
+			listKinds[gvr] = r.Kind + "List"
+			kindResources[gv.WithKind(r.Kind)] = gvr
+		}
+	}
+
+	client := dynamicFake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds)
+	for _, obj := range objs {
+		gvk := obj.GroupVersionKind()
+		gvr, ok := kindResources[gvk]
+		if !ok {
+			return nil, fmt.Errorf("object %s/%s in snapshot has kind %s, which is not served by any resource in %s", obj.GetNamespace(), obj.GetName(), gvk, SnapshotResourcesFile)
+		}
+		if err := client.Tracker().Create(gvr, obj, obj.GetNamespace()); err != nil {
+			return nil, fmt.Errorf("failed to add object %s/%s of kind %s: %w", obj.GetNamespace(), obj.GetName(), gvk, err)
+		}
+	}
+	return client, nil
+}
+
+// readSnapshotObjects reads the objects of all the files in dir, if it exists.
+func readSnapshotObjects(dir string) ([]*unstructured.Unstructured, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var objs []*unstructured.Unstructured
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		file := filepath.Join(dir, entry.Name())
+		fileObjs, err := readObjects(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read objects from %s: %w", file, err)
+		}
+		objs = append(objs, fileObjs...)
+	}
+	return objs, nil
+}
+
+// readObjects decodes the YAML or JSON documents of a file, expanding lists into their items.
+func readObjects(file string) ([]*unstructured.Unstructured, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var objs []*unstructured.Unstructured
+	decoder := utilyaml.NewYAMLOrJSONDecoder(f, 4096)
+	for {
+		u := &unstructured.Unstructured{}
+		if err := decoder.Decode(&u.Object); err != nil {
+			if errors.Is(err, io.EOF) {
+				return objs, nil
+			}
+			return nil, err
+		}
+		if len(u.Object) == 0 {
+			continue
+		}
+		if !u.IsList() {
+			objs = append(objs, u)
+			continue
+		}
+		if err := u.EachListItem(func(item runtime.Object) error {
+			objs = append(objs, item.(*unstructured.Unstructured))
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func readSnapshotJSON(file string, v interface{}) error {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", file, err)
+	}
+	return nil
+}
+
+// RecordClusterSnapshotForConfig records a snapshot of the cluster of a REST config. See RecordClusterSnapshot.
+func RecordClusterSnapshotForConfig(ctx context.Context, config *rest.Config, dir string, resources ...schema.GroupVersionResource) error {
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return err
+	}
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+	return RecordClusterSnapshot(ctx, dynamicClient, discoveryClient, dir, resources...)
+}
+
+// RecordClusterSnapshot records the server version, API resources and OpenAPI documents of a cluster in dir, which
+// is created if needed, so that NewClusterQueryClientFromSnapshot can answer queries about the cluster later.
+// Only the objects of the given resources are recorded, one file per resource, as returned by the API server, so that
+// e.g. their managed fields can be scanned by ScanDeprecatedAPIs. The values of Secrets are redacted: their keys are
+// kept, but data key queries of Secrets observe the redacted value.
+func RecordClusterSnapshot(ctx context.Context, dynamicClient dynamic.Interface, discoveryClient discovery.DiscoveryInterface, dir string, resources ...schema.GroupVersionResource) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	info, err := discoveryClient.ServerVersion()
+	if err != nil {
+		return fmt.Errorf("failed to get server version: %w", err)
+	}
+	if err := writeSnapshotJSON(filepath.Join(dir, SnapshotVersionFile), info); err != nil {
+		return err
+	}
+
+	// Groups that fail discovery, e.g. because of an unavailable aggregated API, are left out of the snapshot.
+	_, resourceLists, err := discoveryClient.ServerGroupsAndResources()
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		return fmt.Errorf("failed to get server resources: %w", err)
+	}
+	if err := writeSnapshotJSON(filepath.Join(dir, SnapshotResourcesFile), resourceLists); err != nil {
+		return err
+	}
+
+	doc, err := discoveryClient.OpenAPISchema()
+	if err != nil {
+		return fmt.Errorf("failed to get OpenAPI v2 document: %w", err)
+	}
+	b, err := doc.YAMLValue("")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, SnapshotOpenAPIV2File), b, 0o600); err != nil {
+		return err
+	}
+
+	if err := recordOpenAPIV3(ctx, discoveryClient.RESTClient(), filepath.Join(dir, SnapshotOpenAPIV3Dir)); err != nil {
+		return err
+	}
+
+	return recordObjects(ctx, dynamicClient, filepath.Join(dir, SnapshotObjectsDir), resources)
+}
+
+// recordOpenAPIV3 records the OpenAPI v3 discovery index and documents, unless the server does not serve them.
+func recordOpenAPIV3(ctx context.Context, restClient rest.Interface, dir string) error {
+	if restClient == nil {
+		return nil
+	}
+	data, err := restClient.Get().AbsPath("/openapi/v3").Do(ctx).Raw()
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get OpenAPI v3 discovery: %w", err)
+	}
+	index := &openAPIV3Discovery{}
+	if err := json.Unmarshal(data, index); err != nil {
+		return fmt.Errorf("failed to decode OpenAPI v3 discovery: %w", err)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, snapshotOpenAPIV3Index), data, 0o600); err != nil {
+		return err
+	}
+	for path, gv := range index.Paths {
+		doc, err := restClient.Get().RequestURI(gv.ServerRelativeURL).SetHeader("Accept", "application/json").Do(ctx).Raw()
+		if err != nil {
+			return fmt.Errorf("failed to get OpenAPI v3 document for %s: %w", path, err)
+		}
+		file := filepath.Join(dir, filepath.FromSlash(strings.TrimPrefix(path, "/"))+".json")
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(file, doc, 0o600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordObjects writes the objects of each resource to a YAML file named after the resource. The objects are listed
+// in pages, and the values of Secrets are redacted.
+func recordObjects(ctx context.Context, dynamicClient dynamic.Interface, dir string, resources []schema.GroupVersionResource) error {
+	if len(resources) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	for _, gvr := range resources {
+		items, err := listObjects(ctx, dynamicClient.Resource(gvr))
+		if err != nil {
+			return fmt.Errorf("failed to list %s: %w", gvr, err)
+		}
+		sort.Slice(items, func(i, j int) bool {
+			a, b := items[i], items[j]
+			if a.GetNamespace() != b.GetNamespace() {
+				return a.GetNamespace() < b.GetNamespace()
+			}
+			return a.GetName() < b.GetName()
+		})
+
+		var buf bytes.Buffer
+		for i := range items {
+			item := &items[i]
+			if gvr.Group == "" && gvr.Resource == "secrets" {
+				redactSecret(item)
+			}
+			b, err := yaml.Marshal(item.Object)
+			if err != nil {
+				return err
+			}
+			buf.WriteString("---\n")
+			buf.Write(b)
+		}
+
+		name := strings.Join([]string{gvr.Group, gvr.Version, gvr.Resource}, "_")
+		if gvr.Group == "" {
+			name = strings.Join([]string{"core", gvr.Version, gvr.Resource}, "_")
+		}
+		if err := os.WriteFile(filepath.Join(dir, name+".yaml"), buf.Bytes(), 0o600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listObjects lists all the objects of a resource, listPageSize objects at a time.
+func listObjects(ctx context.Context, dr dynamic.ResourceInterface) ([]unstructured.Unstructured, error) {
+	var items []unstructured.Unstructured
+	opts := metav1.ListOptions{Limit: listPageSize}
+	for {
+		list, err := dr.List(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, list.Items...)
+		if opts.Continue = list.GetContinue(); opts.Continue == "" {
+			return items, nil
+		}
+	}
+}
+
+// redactSecret replaces the values of the data and string data of a Secret, and of its kubectl last-applied
+// configuration, so that a snapshot keeps its keys but not its values.
+func redactSecret(u *unstructured.Unstructured) {
+	redactValues(u.Object, base64.StdEncoding.EncodeToString([]byte(redactedValue)), "data")
+	redactValues(u.Object, redactedValue, "stringData")
+	if annotations := u.GetAnnotations(); annotations[corev1.LastAppliedConfigAnnotation] != "" {
+		annotations[corev1.LastAppliedConfigAnnotation] = redactedValue
+		u.SetAnnotations(annotations)
+	}
+}
+
+// redactValues replaces every value of the map at the fields of the object.
+func redactValues(obj map[string]interface{}, value string, fields ...string) {
+	values, found, err := unstructured.NestedMap(obj, fields...)
+	if !found || err != nil {
+		return
+	}
+	for k := range values {
+		values[k] = value
+	}
+	_ = unstructured.SetNestedMap(obj, values, fields...)
+}
+
+func writeSnapshotJSON(file string, v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, b, 0o600)
+}
+
+// Ensure the snapshot discovery client satisfies the interface used by the ClusterQueryClient.
+var _ discovery.DiscoveryInterface = &snapshotDiscovery{}
@@ -0,0 +1,206 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package discovery
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	testapigroup "k8s.io/apimachinery/pkg/apis/testapigroup/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	dynamicFake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestClusterSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	recordDiscovery := fakeWithSchema{
+		&fakediscovery.FakeDiscovery{
+			Fake:               &k8stesting.Fake{Resources: apiResources},
+			FakedServerVersion: &version.Info{Major: "1", Minor: "26", GitVersion: "v1.26.5+vmware.2"},
+		},
+	}
+	carps := testapigroup.SchemeGroupVersion.WithResource("carps")
+	if err := RecordClusterSnapshot(context.Background(), dynamicFake.NewSimpleDynamicClient(testScheme, testObjects...), recordDiscovery, dir, carps); err != nil {
+		t.Fatalf("recording snapshot: %v", err)
+	}
+
+	// Objects may also be added by hand, e.g. from the output of kubectl get -o yaml.
+	list := `apiVersion: v1
+kind: List
+items:
+- apiVersion: testapigroup.apimachinery.k8s.io/v1
+  kind: Carp
+  metadata: {name: added, namespace: testns, labels: {app: fish}}
+`
+	if err := os.WriteFile(filepath.Join(dir, SnapshotObjectsDir, "added.yaml"), []byte(list), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := NewClusterQueryClientFromSnapshot(dir)
+	if err != nil {
+		t.Fatalf("loading snapshot: %v", err)
+	}
+
+	testCases := []struct {
+		description string
+		query       QueryTarget
+		want        bool
+	}{
+		{
+			description: "recorded resource found",
+			query:       testGVR,
+			want:        true,
+		},
+		{
+			description: "recorded object found with annotations",
+			query:       testObject,
+			want:        true,
+		},
+		{
+			description: "object from list found by selector",
+			query:       Object("fish", &corev1.ObjectReference{Kind: "Carp", APIVersion: carp.APIVersion, Namespace: "testns"}).WithLabelSelector("app=fish"),
+			want:        true,
+		},
+		{
+			description: "objects counted",
+			query:       Object("carps", &corev1.ObjectReference{Kind: "Carp", APIVersion: carp.APIVersion}).WithMinCount(2),
+			want:        true,
+		},
+		{
+			description: "recorded server version matched",
+			query:       ServerVersion("kubeVersion").WithConstraint(">=1.26"),
+			want:        true,
+		},
+		{
+			description: "recorded openapi v2 schema found",
+			query:       Schema("widget", `properties: {spec: {properties: {replicas: {type: integer}}}}`).WithDefinition("io.example.v1.Widget"),
+			want:        true,
+		},
+		{
+			description: "recorded openapi v3 schema found",
+			query:       Schema("gadget", `properties: {status: {properties: {ready: {type: boolean}}}}`).WithOpenAPIV3("apis/example.io/v1"),
+			want:        true,
+		},
+		{
+			description: "access is denied",
+			query:       Access("list-carps", "list", carps, "testns"),
+			want:        false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			got, err := c.Query(tc.query).Execute()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("got=%t, want=%t, reason: %s", got, tc.want, tc.query.Reason())
+			}
+		})
+	}
+}
+
+func TestClusterSnapshotErrors(t *testing.T) {
+	testCases := []struct {
+		description string
+		files       map[string]string
+		err         string
+	}{
+		{
+			description: "resources are required",
+			files:       map[string]string{SnapshotVersionFile: `{"major": "1", "minor": "26"}`},
+			err:         "failed to read snapshot",
+		},
+		{
+			description: "objects must be served by a resource",
+			files: map[string]string{
+				SnapshotResourcesFile:                        `[]`,
+				filepath.Join(SnapshotObjectsDir, "ns.yaml"): "apiVersion: v1\nkind: Namespace\nmetadata: {name: test}\n",
+			},
+			err: "which is not served by any resource",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tc.files {
+				file := filepath.Join(dir, name)
+				if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			_, err := NewClusterQueryClientFromSnapshot(dir)
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("want error containing %q, got: %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestRecordObjects(t *testing.T) {
+	t.Run("objects are listed in pages", func(t *testing.T) {
+		dir := t.TempDir()
+		dynamicClient := &pagedDynamicClient{resource: &pagedResource{t: t, pages: map[string][]string{"": {"koi", "goldfish"}, "next": {"grass"}}}}
+		if err := recordObjects(context.Background(), dynamicClient, dir, []schema.GroupVersionResource{testapigroup.SchemeGroupVersion.WithResource("carps")}); err != nil {
+			t.Fatal(err)
+		}
+		objects, err := readSnapshotObjects(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(objects) != 3 {
+			t.Errorf("want the objects of all the pages, got %d objects", len(objects))
+		}
+	})
+
+	t.Run("values of secrets are redacted and managed fields are kept", func(t *testing.T) {
+		dir := t.TempDir()
+		secrets := corev1.SchemeGroupVersion.WithResource("secrets")
+		secret := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"metadata": map[string]interface{}{
+				"name":          "credentials",
+				"namespace":     "default",
+				"annotations":   map[string]interface{}{corev1.LastAppliedConfigAnnotation: `{"stringData":{"password":"hunter2"}}`},
+				"managedFields": []interface{}{map[string]interface{}{"manager": "kubectl", "apiVersion": "v1", "operation": "Update"}},
+			},
+			"data":       map[string]interface{}{"password": base64.StdEncoding.EncodeToString([]byte("hunter2"))},
+			"stringData": map[string]interface{}{"username": "admin"},
+		}}
+		dynamicClient := dynamicFake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{secrets: "SecretList"}, secret)
+		if err := recordObjects(context.Background(), dynamicClient, dir, []schema.GroupVersionResource{secrets}); err != nil {
+			t.Fatal(err)
+		}
+		b, err := os.ReadFile(filepath.Join(dir, "core_v1_secrets.yaml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, value := range []string{"hunter2", base64.StdEncoding.EncodeToString([]byte("hunter2")), "admin"} {
+			if strings.Contains(string(b), value) {
+				t.Errorf("want the value %q to be redacted, got:\n%s", value, b)
+			}
+		}
+		for _, kept := range []string{"password:", "username:", "manager: kubectl"} {
+			if !strings.Contains(string(b), kept) {
+				t.Errorf("want %q to be kept, got:\n%s", kept, b)
+			}
+		}
+	})
+}
@@ -67,28 +67,38 @@ func (dc *DiscoveryClient) IsWorkloadCluster(ctx context.Context) (bool, error)
 
 // clusterTypeFromMetadataConfigMap fetches cluster type from tkg-metadata configmap.
 func clusterTypeFromMetadataConfigMap(ctx context.Context, c client.Client) (string, error) {
+	metadata, err := clusterMetadataFromConfigMap(ctx, c)
+	if err != nil {
+		return "", err
+	}
+
+	switch strings.ToLower(metadata.Cluster.Type) {
+	case clusterTypeManagement:
+		return clusterTypeManagement, nil
+	case clusterTypeWorkload:
+		return clusterTypeWorkload, nil
+	}
+	return "", fmt.Errorf("unknown cluster type: %v", metadata.Cluster.Type)
+}
+
+// clusterMetadataFromConfigMap fetches the cluster metadata from tkg-metadata configmap.
+func clusterMetadataFromConfigMap(ctx context.Context, c client.Client) (*ClusterMetadata, error) {
 	cm := &corev1.ConfigMap{}
 	key := client.ObjectKey{Namespace: metadataConfigMapNamespace, Name: metadataConfigMapName}
 	if err := c.Get(ctx, key, cm); err != nil {
-		return "", err
+		return nil, err
 	}
 
 	data, ok := cm.Data["metadata.yaml"]
 	if !ok {
-		return "", fmt.Errorf("failed to get cluster type: metadata.yaml key not found in configmap %s", key.String())
+		return nil, fmt.Errorf("failed to get cluster metadata: metadata.yaml key not found in configmap %s", key.String())
 	}
 
 	metadata := &ClusterMetadata{}
 	if err := yaml.Unmarshal([]byte(data), metadata); err != nil {
-		return "", fmt.Errorf("failed to get cluster type: %w", err)
-	}
-	switch strings.ToLower(metadata.Cluster.Type) {
-	case clusterTypeManagement:
-		return clusterTypeManagement, nil
-	case clusterTypeWorkload:
-		return clusterTypeWorkload, nil
+		return nil, fmt.Errorf("failed to get cluster metadata: %w", err)
 	}
-	return "", fmt.Errorf("unknown cluster type: %v", metadata.Cluster.Type)
+	return metadata, nil
 }
 
 // HasNSX indicates if a cluster has NSX capabilities.
@@ -29,6 +29,7 @@ func init() {
 type DiscoveryClient struct {
 	k8sClient          client.Client
 	clusterQueryClient *discovery.ClusterQueryClient
+	providers          *ProviderRegistry
 }
 
 // NewDiscoveryClientForConfig returns a DiscoveryClient for a rest.Config.
@@ -21,12 +21,22 @@ const (
 	CloudProviderAzure = CloudProvider("azure")
 	// CloudProviderVsphere is the Vsphere cloud provider.
 	CloudProviderVsphere = CloudProvider("vsphere")
+	// CloudProviderDocker is the cloud provider of the nodes of the Docker infrastructure provider.
+	CloudProviderDocker = CloudProvider("docker")
+	// CloudProviderGCP is the GCP cloud provider.
+	CloudProviderGCP = CloudProvider("gcp")
+	// CloudProviderOCI is the Oracle Cloud Infrastructure cloud provider.
+	CloudProviderOCI = CloudProvider("oci")
+	// CloudProviderNutanix is the Nutanix cloud provider.
+	CloudProviderNutanix = CloudProvider("nutanix")
 )
 
-// HasCloudProvider checks if the cluster is configured with the given cloud provider.
+// HasCloudProvider checks if the cluster is configured with the given cloud provider, according to the provider ID of
+// its nodes. The cloud provider must have a detector in the provider registry of the client, see WithProviderRegistry.
 // Deprecated: This function will be removed in a future release.
 func (dc *DiscoveryClient) HasCloudProvider(ctx context.Context, cloudProvider CloudProvider) (bool, error) {
-	if cloudProvider != CloudProviderAWS && cloudProvider != CloudProviderAzure && cloudProvider != CloudProviderVsphere {
+	detector := dc.providerRegistry().cloudDetector(cloudProvider)
+	if detector == nil {
 		return false, fmt.Errorf("unsupported cloud provider: %v", cloudProvider)
 	}
 
@@ -39,12 +49,10 @@ func (dc *DiscoveryClient) HasCloudProvider(ctx context.Context, cloudProvider C
 		return false, fmt.Errorf("failed to identify cloud provider: node list is empty")
 	}
 
-	node := nodeList.Items[0]
-	providerID := strings.Split(node.Spec.ProviderID, ":")
-	if len(providerID) < 2 {
+	node := &nodeList.Items[0]
+	if !strings.Contains(node.Spec.ProviderID, ":") {
 		return false, fmt.Errorf("unknown cloud provider")
 	}
-	provider := strings.ToLower(providerID[0])
 
-	return string(cloudProvider) == provider, nil
+	return detector.IsCloudProviderNode(node), nil
 }
@@ -39,6 +39,13 @@ func TestHasCloudProvider(t *testing.T) {
 		{"aws", CloudProviderAWS, nodeFor, "", true},
 		{"azure", CloudProviderAzure, nodeFor, "", true},
 		{"vsphere", CloudProviderVsphere, nodeFor, "", true},
+		{"docker", CloudProviderDocker, nodeFor, "", true},
+		{"gcp", CloudProviderGCP, func(CloudProvider) *corev1.Node {
+			return &corev1.Node{Spec: corev1.NodeSpec{ProviderID: "gce://project/us-central1-a/instance"}}
+		}, "", true},
+		{"other cloud provider", CloudProviderAWS, func(CloudProvider) *corev1.Node {
+			return nodeFor(CloudProviderVsphere)
+		}, "", false},
 		{"empty node list", CloudProviderVsphere, func(cloudProvider CloudProvider) *corev1.Node {
 			return nil
 		}, "node list is empty", false},
@@ -6,7 +6,6 @@ package tkg
 import (
 	"context"
 	"fmt"
-	"strings"
 
 	clusterctl "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 )
@@ -21,27 +20,76 @@ const (
 	InfrastructureProviderAzure = InfrastructureProvider("azure")
 	// InfrastructureProviderVsphere is the Vsphere infrastructure provider.
 	InfrastructureProviderVsphere = InfrastructureProvider("vsphere")
+	// InfrastructureProviderDocker is the Docker infrastructure provider (CAPD).
+	InfrastructureProviderDocker = InfrastructureProvider("docker")
+	// InfrastructureProviderGCP is the GCP infrastructure provider.
+	InfrastructureProviderGCP = InfrastructureProvider("gcp")
+	// InfrastructureProviderOCI is the Oracle Cloud Infrastructure provider.
+	InfrastructureProviderOCI = InfrastructureProvider("oci")
+	// InfrastructureProviderNutanix is the Nutanix infrastructure provider.
+	InfrastructureProviderNutanix = InfrastructureProvider("nutanix")
 )
 
-// HasInfrastructureProvider checks the cluster's CAPI infrastructure provider.
+// InstalledInfrastructureProvider is an infrastructure provider installed on the cluster.
+type InstalledInfrastructureProvider struct {
+	Provider InfrastructureProvider `json:"provider"`
+	// Version is the version of the provider in the clusterctl inventory, e.g. "v1.5.3".
+	Version string `json:"version,omitempty"`
+}
+
+// HasInfrastructureProvider checks if the infrastructure provider is installed on the cluster. The provider must have
+// a detector in the provider registry of the client, see WithProviderRegistry.
 // Deprecated: This function will be removed in a future release.
 func (dc *DiscoveryClient) HasInfrastructureProvider(ctx context.Context, infraProvider InfrastructureProvider) (bool, error) {
-	if infraProvider != InfrastructureProviderAWS && infraProvider != InfrastructureProviderAzure && infraProvider != InfrastructureProviderVsphere {
+	detector := dc.providerRegistry().infrastructureDetector(infraProvider)
+	if detector == nil {
 		return false, fmt.Errorf("unsupported infrastructure provider: %v", infraProvider)
 	}
 
-	var providerList clusterctl.ProviderList
-	err := dc.k8sClient.List(ctx, &providerList)
+	inventory, err := dc.infrastructureInventory(ctx)
 	if err != nil {
 		return false, err
 	}
+	if len(inventory) == 0 {
+		return false, fmt.Errorf("could not find infrastructure provider")
+	}
+	_, found, err := detector.InstalledVersion(ctx, dc.k8sClient, inventory)
+	return found, err
+}
+
+// InfrastructureProviders returns the infrastructure providers installed on the cluster, with their versions, sorted
+// by provider. Only providers with a detector in the provider registry of the client are returned.
+func (dc *DiscoveryClient) InfrastructureProviders(ctx context.Context) ([]InstalledInfrastructureProvider, error) {
+	inventory, err := dc.infrastructureInventory(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var installed []InstalledInfrastructureProvider
+	for _, detector := range dc.providerRegistry().Detectors() {
+		version, found, err := detector.InstalledVersion(ctx, dc.k8sClient, inventory)
+		if err != nil {
+			return nil, fmt.Errorf("failed to detect infrastructure provider %s: %w", detector.InfrastructureProvider(), err)
+		}
+		if found {
+			installed = append(installed, InstalledInfrastructureProvider{Provider: detector.InfrastructureProvider(), Version: version})
+		}
+	}
+	return installed, nil
+}
+
+// infrastructureInventory returns the infrastructure providers of the clusterctl inventory.
+func (dc *DiscoveryClient) infrastructureInventory(ctx context.Context) ([]clusterctl.Provider, error) {
+	var providerList clusterctl.ProviderList
+	if err := dc.k8sClient.List(ctx, &providerList); err != nil {
+		return nil, err
+	}
 
+	var inventory []clusterctl.Provider
 	for i := range providerList.Items {
-		if providerList.Items[i].GetProviderType() != clusterctl.InfrastructureProviderType {
-			continue
+		if providerList.Items[i].GetProviderType() == clusterctl.InfrastructureProviderType {
+			inventory = append(inventory, providerList.Items[i])
 		}
-		provider := strings.ToLower(providerList.Items[i].ProviderName)
-		return string(infraProvider) == provider, nil
 	}
-	return false, fmt.Errorf("could not find infrastructure provider")
+	return inventory, nil
 }
@@ -5,6 +5,7 @@ package tkg
 
 import (
 	"context"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -39,6 +40,16 @@ func TestHasInfrastructureProvider(t *testing.T) {
 		{"aws", InfrastructureProviderAWS, providerFor, "", true},
 		{"azure", InfrastructureProviderAzure, providerFor, "", true},
 		{"vsphere", InfrastructureProviderVsphere, providerFor, "", true},
+		{"docker", InfrastructureProviderDocker, providerFor, "", true},
+		{"gcp", InfrastructureProviderGCP, providerFor, "", true},
+		{"oci", InfrastructureProviderOCI, providerFor, "", true},
+		{"nutanix", InfrastructureProviderNutanix, providerFor, "", true},
+		{"other provider", InfrastructureProviderAWS, func(InfrastructureProvider) *clusterctl.Provider {
+			return providerFor(InfrastructureProviderVsphere)
+		}, "", false},
+		{"no provider", InfrastructureProviderAWS, func(InfrastructureProvider) *clusterctl.Provider {
+			return nil
+		}, "could not find infrastructure provider", false},
 		{"unknown", InfrastructureProvider("unknown"), providerFor, "unsupported infrastructure provider", false},
 	}
 
@@ -67,3 +78,76 @@ func TestHasInfrastructureProvider(t *testing.T) {
 		})
 	}
 }
+
+func TestInfrastructureProviders(t *testing.T) {
+	provider := func(name string, providerType clusterctl.ProviderType, version string) *clusterctl.Provider {
+		return &clusterctl.Provider{
+			ObjectMeta:   metav1.ObjectMeta{Name: string(providerType) + "-" + name, Namespace: "capi-system"},
+			ProviderName: name,
+			Type:         string(providerType),
+			Version:      version,
+		}
+	}
+	objs := []runtime.Object{
+		provider("cluster-api", clusterctl.CoreProviderType, "v1.2.8"),
+		provider("vsphere", clusterctl.InfrastructureProviderType, "v1.5.3"),
+		provider("docker", clusterctl.InfrastructureProviderType, "v1.2.8"),
+		provider("byoh", clusterctl.InfrastructureProviderType, "v0.3.1"),
+	}
+
+	testCases := []struct {
+		description string
+		registry    *ProviderRegistry
+		want        []InstalledInfrastructureProvider
+	}{
+		{
+			description: "default registry",
+			want: []InstalledInfrastructureProvider{
+				{Provider: InfrastructureProviderDocker, Version: "v1.2.8"},
+				{Provider: InfrastructureProviderVsphere, Version: "v1.5.3"},
+			},
+		},
+		{
+			description: "registry with a detector of another provider",
+			registry: NewProviderRegistry(
+				&ClusterctlProviderDetector{Provider: InfrastructureProviderVsphere, Cloud: CloudProviderVsphere},
+				&ClusterctlProviderDetector{Provider: InfrastructureProvider("byoh"), Cloud: CloudProvider("byoh")},
+			),
+			want: []InstalledInfrastructureProvider{
+				{Provider: InfrastructureProvider("byoh"), Version: "v0.3.1"},
+				{Provider: InfrastructureProviderVsphere, Version: "v1.5.3"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			dc, err := newFakeDiscoveryClient([]*metav1.APIResourceList{}, Scheme, objs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.registry != nil {
+				dc = dc.WithProviderRegistry(tc.registry)
+			}
+
+			got, err := dc.InfrastructureProviders(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got=%v, want=%v", got, tc.want)
+			}
+
+			// Every installed provider is found, not only the first one in the inventory.
+			for _, p := range tc.want {
+				found, err := dc.HasInfrastructureProvider(context.Background(), p.Provider)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !found {
+					t.Errorf("want infrastructure provider %s to be found", p.Provider)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package tkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/version"
+
+	runv1alpha1 "github.com/vmware-tanzu/tanzu-framework/apis/run/v1alpha1"
+	runv1alpha2 "github.com/vmware-tanzu/tanzu-framework/apis/run/v1alpha2"
+	runv1alpha3 "github.com/vmware-tanzu/tanzu-framework/apis/run/v1alpha3"
+	"github.com/vmware-tanzu/tanzu-framework/capabilities/client/pkg/discovery"
+)
+
+// tanzuRunGroupVersions are the versions of the run.tanzu.vmware.com API that are checked for TKC and TKR resources.
+var tanzuRunGroupVersions = []schema.GroupVersion{runv1alpha1.GroupVersion, runv1alpha2.GroupVersion, runv1alpha3.GroupVersion}
+
+// ClusterProfile describes a TKG cluster in one serialisable struct. Fields that cannot be determined, e.g. the
+// metadata of a cluster without the tkg-metadata ConfigMap, are left empty.
+type ClusterProfile struct {
+	// Name is the name of the cluster in the tkg-metadata ConfigMap.
+	Name string `json:"name,omitempty"`
+	// Type is the type of the cluster, "management" or "workload".
+	Type string `json:"type,omitempty"`
+	// Plan is the plan the cluster was created with, e.g. "dev".
+	Plan string `json:"plan,omitempty"`
+	// KubernetesProvider is the Kubernetes provider of the cluster, e.g. "VMware Tanzu Kubernetes Grid".
+	KubernetesProvider string `json:"kubernetesProvider,omitempty"`
+	// TKGVersion is the TKG version of the cluster.
+	TKGVersion *Version `json:"tkgVersion,omitempty"`
+	// InfrastructureProvider is the infrastructure provider in the tkg-metadata ConfigMap.
+	InfrastructureProvider InfrastructureProvider `json:"infrastructureProvider,omitempty"`
+	// InfrastructureProviders are the infrastructure providers installed on the cluster, which is only the case for
+	// management clusters.
+	InfrastructureProviders []InstalledInfrastructureProvider `json:"infrastructureProviders,omitempty"`
+	// CloudProvider is the cloud provider of the nodes of the cluster.
+	CloudProvider CloudProvider `json:"cloudProvider,omitempty"`
+	// HasNSX is true if the cluster has NSX capabilities.
+	HasNSX bool `json:"hasNSX"`
+	// TanzuKubernetesClusterVersions are the versions of the run.tanzu.vmware.com API that serve
+	// TanzuKubernetesClusters.
+	TanzuKubernetesClusterVersions []string `json:"tanzuKubernetesClusterVersions,omitempty"`
+	// TanzuKubernetesReleaseVersions are the versions of the run.tanzu.vmware.com API that serve
+	// TanzuKubernetesReleases.
+	TanzuKubernetesReleaseVersions []string `json:"tanzuKubernetesReleaseVersions,omitempty"`
+	// KubernetesVersion is the Kubernetes version reported by the API server.
+	KubernetesVersion *Version `json:"kubernetesVersion,omitempty"`
+}
+
+// Version is a semantic version that is serialised as a string, e.g. "1.6.0".
+type Version struct {
+	*version.Version
+}
+
+// MarshalJSON marshals the version as a string.
+func (v Version) MarshalJSON() ([]byte, error) {
+	if v.Version == nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal(v.String())
+}
+
+// UnmarshalJSON parses the version from a string.
+func (v *Version) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := version.ParseSemantic(s)
+	if err != nil {
+		return err
+	}
+	v.Version = parsed
+	return nil
+}
+
+// ClusterProfile returns the profile of the cluster. It replaces the Is* and Has* methods of the DiscoveryClient.
+func (dc *DiscoveryClient) ClusterProfile(ctx context.Context) (*ClusterProfile, error) {
+	profile := &ClusterProfile{}
+
+	metadata, err := clusterMetadataFromConfigMap(ctx, dc.k8sClient)
+	switch {
+	case apierrors.IsNotFound(err):
+	case err != nil:
+		return nil, err
+	default:
+		if err := profile.setMetadata(metadata); err != nil {
+			return nil, err
+		}
+	}
+
+	// Only management clusters have the clusterctl inventory.
+	profile.InfrastructureProviders, err = dc.InfrastructureProviders(ctx)
+	if err != nil && !meta.IsNoMatchError(err) && !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to list infrastructure providers: %w", err)
+	}
+
+	if profile.CloudProvider, err = dc.cloudProvider(ctx); err != nil {
+		return nil, err
+	}
+
+	if profile.HasNSX, err = dc.HasNSX(ctx); err != nil {
+		return nil, fmt.Errorf("failed to check NSX: %w", err)
+	}
+
+	if err := dc.setTanzuRunVersions(profile); err != nil {
+		return nil, err
+	}
+
+	kubernetesVersion, err := dc.clusterQueryClient.KubernetesVersion()
+	if err != nil {
+		return nil, err
+	}
+	profile.KubernetesVersion = &Version{kubernetesVersion}
+
+	return profile, nil
+}
+
+// setMetadata sets the fields of the profile that come from the cluster metadata.
+func (p *ClusterProfile) setMetadata(metadata *ClusterMetadata) error {
+	p.Name = metadata.Cluster.Name
+	p.Type = strings.ToLower(metadata.Cluster.Type)
+	p.Plan = metadata.Cluster.Plan
+	p.KubernetesProvider = metadata.Cluster.KubernetesProvider
+	p.InfrastructureProvider = InfrastructureProvider(strings.ToLower(metadata.Cluster.Infrastructure.Provider))
+	if metadata.Cluster.TkgVersion != "" {
+		v, err := version.ParseSemantic(metadata.Cluster.TkgVersion)
+		if err != nil {
+			return fmt.Errorf("failed to parse TKG version %q: %w", metadata.Cluster.TkgVersion, err)
+		}
+		p.TKGVersion = &Version{v}
+	}
+	return nil
+}
+
+// cloudProvider returns the cloud provider of the first node detected by the provider registry of the client, or an
+// empty string if there are no nodes or no detector matches.
+func (dc *DiscoveryClient) cloudProvider(ctx context.Context) (CloudProvider, error) {
+	nodeList := &corev1.NodeList{}
+	if err := dc.k8sClient.List(ctx, nodeList); err != nil {
+		return "", fmt.Errorf("failed to list nodes: %w", err)
+	}
+	if len(nodeList.Items) == 0 {
+		return "", nil
+	}
+
+	for _, detector := range dc.providerRegistry().Detectors() {
+		if detector.IsCloudProviderNode(&nodeList.Items[0]) {
+			return detector.CloudProvider(), nil
+		}
+	}
+	return "", nil
+}
+
+// setTanzuRunVersions sets the versions of the run.tanzu.vmware.com API that serve TKCs and TKRs in one query.
+func (dc *DiscoveryClient) setTanzuRunVersions(p *ClusterProfile) error {
+	var targets []discovery.QueryTarget
+	for _, gv := range tanzuRunGroupVersions {
+		targets = append(targets,
+			discovery.Group("tkc-"+gv.Version, gv.Group).WithVersions(gv.Version).WithResource("tanzukubernetesclusters"),
+			discovery.Group("tkr-"+gv.Version, gv.Group).WithVersions(gv.Version).WithResource("tanzukubernetesreleases"),
+		)
+	}
+	query := dc.clusterQueryClient.Query(targets...)
+	if _, err := query.Execute(); err != nil {
+		return fmt.Errorf("failed to discover TKC and TKR APIs: %w", err)
+	}
+
+	results := query.Results()
+	for _, gv := range tanzuRunGroupVersions {
+		if results.ForQuery("tkc-" + gv.Version).Found {
+			p.TanzuKubernetesClusterVersions = append(p.TanzuKubernetesClusterVersions, gv.Version)
+		}
+		if results.ForQuery("tkr-" + gv.Version).Found {
+			p.TanzuKubernetesReleaseVersions = append(p.TanzuKubernetesReleaseVersions, gv.Version)
+		}
+	}
+	return nil
+}
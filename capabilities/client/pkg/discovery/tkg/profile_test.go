@@ -0,0 +1,102 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package tkg
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterctl "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+)
+
+func TestClusterProfile(t *testing.T) {
+	tkgCluster := []runtime.Object{
+		metadataConfigMapFor(clusterTypeManagement),
+		&clusterctl.Provider{
+			ObjectMeta:   metav1.ObjectMeta{Name: "infrastructure-vsphere", Namespace: "capv-system"},
+			ProviderName: "vsphere",
+			Type:         string(clusterctl.InfrastructureProviderType),
+			Version:      "v1.5.3",
+		},
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node"},
+			Spec:       corev1.NodeSpec{ProviderID: "vsphere://4212b6d4-b85c-2d4a-b7e5-9f2c2e0f6d1a"},
+		},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespaceNSX}},
+	}
+
+	testCases := []struct {
+		description string
+		resources   []*metav1.APIResourceList
+		objs        []runtime.Object
+		want        string
+		err         string
+	}{
+		{
+			description: "TKG management cluster",
+			resources:   append(append([]*metav1.APIResourceList{}, coreAPIResourceList...), tanzuRunAPIResourceList...),
+			objs:        tkgCluster,
+			want: `{"name":"tkg-cluster-wc-765","type":"management","plan":"dev","kubernetesProvider":"VMware Tanzu Kubernetes Grid",` +
+				`"tkgVersion":"1.2.1","infrastructureProvider":"vsphere","infrastructureProviders":[{"provider":"vsphere","version":"v1.5.3"}],` +
+				`"cloudProvider":"vsphere","hasNSX":true,"tanzuKubernetesClusterVersions":["v1alpha1"],` +
+				`"tanzuKubernetesReleaseVersions":["v1alpha1"],"kubernetesVersion":"0.0.0"}`,
+		},
+		{
+			description: "cluster without TKG",
+			resources:   coreAPIResourceList,
+			want:        `{"hasNSX":false,"kubernetesVersion":"0.0.0"}`,
+		},
+		{
+			description: "invalid TKG version",
+			resources:   coreAPIResourceList,
+			objs: []runtime.Object{&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: metadataConfigMapName, Namespace: metadataConfigMapNamespace},
+				Data:       map[string]string{"metadata.yaml": "cluster:\n  tkgVersion: latest\n"},
+			}},
+			err: `failed to parse TKG version "latest"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			dc, err := newFakeDiscoveryClient(tc.resources, Scheme, tc.objs)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			profile, err := dc.ClusterProfile(context.Background())
+			if err != nil {
+				if tc.err == "" || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("want error containing %q, got: %v", tc.err, err)
+				}
+				return
+			}
+			if tc.err != "" {
+				t.Fatalf("want error containing %q, got none", tc.err)
+			}
+
+			data, err := json.Marshal(profile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tc.want {
+				t.Errorf("got=%s, want=%s", data, tc.want)
+			}
+
+			// The profile can be read back.
+			roundTrip := &ClusterProfile{}
+			if err := json.Unmarshal(data, roundTrip); err != nil {
+				t.Fatal(err)
+			}
+			if again, err := json.Marshal(roundTrip); err != nil || string(again) != tc.want {
+				t.Errorf("got=%s after a round trip, want=%s, err=%v", again, tc.want, err)
+			}
+		})
+	}
+}
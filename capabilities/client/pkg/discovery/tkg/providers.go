@@ -0,0 +1,155 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package tkg
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	clusterctl "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ProviderDetector detects an infrastructure provider installed on a cluster, and the nodes of the cloud provider
+// that goes with it. Callers can detect providers this package does not know about by registering their own detector
+// with a ProviderRegistry.
+type ProviderDetector interface {
+	// InfrastructureProvider is the infrastructure provider detected.
+	InfrastructureProvider() InfrastructureProvider
+	// CloudProvider is the cloud provider of the nodes of the infrastructure provider.
+	CloudProvider() CloudProvider
+	// InstalledVersion returns the version of the infrastructure provider if it is installed, given the
+	// infrastructure providers of the clusterctl inventory of the cluster.
+	InstalledVersion(ctx context.Context, c client.Reader, inventory []clusterctl.Provider) (string, bool, error)
+	// IsCloudProviderNode returns true if the node runs on the cloud provider.
+	IsCloudProviderNode(node *corev1.Node) bool
+}
+
+// ClusterctlProviderDetector detects an infrastructure provider by its entry in the clusterctl inventory, and the
+// nodes of its cloud provider by the scheme of their provider ID, e.g. "aws" in "aws:///us-west-2a/i-0123".
+type ClusterctlProviderDetector struct {
+	// Provider is the infrastructure provider, which is also its name in the clusterctl inventory.
+	Provider InfrastructureProvider
+	// Cloud is the cloud provider of the nodes.
+	Cloud CloudProvider
+	// ProviderIDSchemes are the schemes of the provider IDs of the nodes. They default to the cloud provider.
+	ProviderIDSchemes []string
+}
+
+// InfrastructureProvider is the infrastructure provider detected.
+func (d *ClusterctlProviderDetector) InfrastructureProvider() InfrastructureProvider {
+	return d.Provider
+}
+
+// CloudProvider is the cloud provider of the nodes of the infrastructure provider.
+func (d *ClusterctlProviderDetector) CloudProvider() CloudProvider {
+	return d.Cloud
+}
+
+// InstalledVersion returns the version of the first entry of the clusterctl inventory named after the provider.
+func (d *ClusterctlProviderDetector) InstalledVersion(_ context.Context, _ client.Reader, inventory []clusterctl.Provider) (string, bool, error) {
+	for i := range inventory {
+		if strings.EqualFold(inventory[i].ProviderName, string(d.Provider)) {
+			return inventory[i].Version, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+// IsCloudProviderNode returns true if the scheme of the provider ID of the node is one of the provider ID schemes.
+func (d *ClusterctlProviderDetector) IsCloudProviderNode(node *corev1.Node) bool {
+	scheme := strings.ToLower(strings.SplitN(node.Spec.ProviderID, ":", 2)[0])
+	schemes := d.ProviderIDSchemes
+	if len(schemes) == 0 {
+		schemes = []string{string(d.Cloud)}
+	}
+	for _, s := range schemes {
+		if scheme == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ProviderRegistry is a set of provider detectors, one per infrastructure provider. It is safe for concurrent use.
+type ProviderRegistry struct {
+	mu        sync.RWMutex
+	detectors map[InfrastructureProvider]ProviderDetector
+}
+
+// NewProviderRegistry returns a registry of the provider detectors.
+func NewProviderRegistry(detectors ...ProviderDetector) *ProviderRegistry {
+	r := &ProviderRegistry{detectors: make(map[InfrastructureProvider]ProviderDetector)}
+	for _, d := range detectors {
+		r.Register(d)
+	}
+	return r
+}
+
+// DefaultProviderRegistry is the registry used by DiscoveryClients unless set with WithProviderRegistry. Callers can
+// register detectors of other providers in it.
+var DefaultProviderRegistry = NewProviderRegistry(
+	&ClusterctlProviderDetector{Provider: InfrastructureProviderAWS, Cloud: CloudProviderAWS},
+	&ClusterctlProviderDetector{Provider: InfrastructureProviderAzure, Cloud: CloudProviderAzure},
+	&ClusterctlProviderDetector{Provider: InfrastructureProviderVsphere, Cloud: CloudProviderVsphere},
+	&ClusterctlProviderDetector{Provider: InfrastructureProviderDocker, Cloud: CloudProviderDocker},
+	&ClusterctlProviderDetector{Provider: InfrastructureProviderGCP, Cloud: CloudProviderGCP, ProviderIDSchemes: []string{"gce"}},
+	&ClusterctlProviderDetector{Provider: InfrastructureProviderOCI, Cloud: CloudProviderOCI},
+	&ClusterctlProviderDetector{Provider: InfrastructureProviderNutanix, Cloud: CloudProviderNutanix},
+)
+
+// Register adds the detector to the registry, replacing the detector of the same infrastructure provider.
+func (r *ProviderRegistry) Register(d ProviderDetector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.detectors[d.InfrastructureProvider()] = d
+}
+
+// Detectors returns the detectors of the registry, sorted by infrastructure provider.
+func (r *ProviderRegistry) Detectors() []ProviderDetector {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	detectors := make([]ProviderDetector, 0, len(r.detectors))
+	for _, d := range r.detectors {
+		detectors = append(detectors, d)
+	}
+	sort.Slice(detectors, func(i, j int) bool {
+		return detectors[i].InfrastructureProvider() < detectors[j].InfrastructureProvider()
+	})
+	return detectors
+}
+
+// infrastructureDetector returns the detector of the infrastructure provider, or nil.
+func (r *ProviderRegistry) infrastructureDetector(p InfrastructureProvider) ProviderDetector {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.detectors[p]
+}
+
+// cloudDetector returns the detector of the cloud provider, or nil.
+func (r *ProviderRegistry) cloudDetector(p CloudProvider) ProviderDetector {
+	for _, d := range r.Detectors() {
+		if d.CloudProvider() == p {
+			return d
+		}
+	}
+	return nil
+}
+
+// WithProviderRegistry sets the registry of the provider detectors used to detect infrastructure and cloud providers.
+func (dc *DiscoveryClient) WithProviderRegistry(r *ProviderRegistry) *DiscoveryClient {
+	dc.providers = r
+	return dc
+}
+
+// providerRegistry returns the registry of the provider detectors of the client.
+func (dc *DiscoveryClient) providerRegistry() *ProviderRegistry {
+	if dc.providers == nil {
+		return DefaultProviderRegistry
+	}
+	return dc.providers
+}
@@ -0,0 +1,85 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package discovery
+
+import (
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	corev1alpha2 "github.com/vmware-tanzu/tanzu-framework/apis/core/v1alpha2"
+)
+
+// validator is implemented by the query targets that validate their arguments before they query a cluster.
+type validator interface {
+	validate() error
+}
+
+// ValidateCapability validates the queries of a Capability with the same validation their query targets run before
+// they query a cluster, so that queries that can never be evaluated are rejected when the Capability is created or
+// updated rather than when it is reconciled.
+func ValidateCapability(capability *corev1alpha2.Capability) field.ErrorList {
+	var allErrs field.ErrorList
+	queriesPath := field.NewPath("spec", "queries")
+	for i := range capability.Spec.Queries {
+		query := &capability.Spec.Queries[i]
+		queryPath := queriesPath.Index(i)
+		targets := QueryToQueryTargets(query)
+		allErrs = append(allErrs, validateQueryTargets(queryPath, &targets)...)
+		for j := range query.AnyOf {
+			allErrs = append(allErrs, validateCombination(queryPath.Child("anyOf").Index(j), &query.AnyOf[j])...)
+		}
+		for j := range query.Not {
+			allErrs = append(allErrs, validateCombination(queryPath.Child("not").Index(j), &query.Not[j])...)
+		}
+	}
+	return allErrs
+}
+
+// validateCombination validates the query targets of a query combination.
+func validateCombination(path *field.Path, c *corev1alpha2.QueryCombination) field.ErrorList {
+	query := &corev1alpha2.Query{
+		GroupVersionResources:     c.GroupVersionResources,
+		Objects:                   c.Objects,
+		PartialSchemas:            c.PartialSchemas,
+		ServerVersions:            c.ServerVersions,
+		StatusConditions:          c.StatusConditions,
+		AccessChecks:              c.AccessChecks,
+		APIServices:               c.APIServices,
+		CustomResourceDefinitions: c.CustomResourceDefinitions,
+		Nodes:                     c.Nodes,
+		DataKeys:                  c.DataKeys,
+	}
+	targets := QueryToQueryTargets(query)
+	return validateQueryTargets(path, &targets)
+}
+
+// validateQueryTargets validates the query targets of each kind of query, except AnyOf and Not combinations.
+func validateQueryTargets(path *field.Path, t *CapabilityQueryTargets) field.ErrorList {
+	var allErrs field.ErrorList
+	for _, group := range []struct {
+		name    string
+		targets []QueryTarget
+	}{
+		{"groupVersionResources", t.GroupVersionResources},
+		{"objects", t.Objects},
+		{"partialSchemas", t.PartialSchemas},
+		{"serverVersions", t.ServerVersions},
+		{"statusConditions", t.StatusConditions},
+		{"accessChecks", t.AccessChecks},
+		{"apiServices", t.APIServices},
+		{"customResourceDefinitions", t.CustomResourceDefinitions},
+		{"nodes", t.Nodes},
+		{"dataKeys", t.DataKeys},
+	} {
+		for i, target := range group.targets {
+			v, ok := target.(validator)
+			if !ok {
+				continue
+			}
+			if err := v.validate(); err != nil {
+				allErrs = append(allErrs, field.Invalid(path.Child(group.name).Index(i), target.Name(), err.Error()))
+			}
+		}
+	}
+	return allErrs
+}
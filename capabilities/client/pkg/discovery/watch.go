@@ -0,0 +1,313 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+)
+
+// DefaultWatchRefreshInterval is the default interval at which Watch refreshes discovery information and runs the
+// query targets again.
+const DefaultWatchRefreshInterval = 30 * time.Second
+
+// watchSyncTimeout is how long Watch waits for the informers of newly watched resources to sync before it runs the
+// query targets. The targets that depend on informers that have not synced report an error until they do.
+var watchSyncTimeout = 30 * time.Second
+
+// WithWatchRefreshInterval sets the interval at which Watch drops the cached discovery information and runs the query
+// targets again, so that changes that are not observed by informers, e.g. a CRD being installed, are picked up.
+// An interval of zero or less disables refreshing.
+func WithWatchRefreshInterval(interval time.Duration) ClusterQueryClientOption {
+	return func(c *clusterQueryClientConfig) {
+		c.watchRefreshInterval = interval
+	}
+}
+
+// objectTarget is implemented by query targets whose outcome depends on objects, so that Watch can run them again
+// when the objects change.
+type objectTarget interface {
+	watchedObjects() []watchedObject
+}
+
+// watchedObject is the reference of the objects a query target depends on, with the selectors of those objects, so
+// that only the objects the target queries are watched and cached.
+type watchedObject struct {
+	ref           *corev1.ObjectReference
+	labelSelector string
+	fieldSelector string
+}
+
+// namedObject returns the watched object of a reference, which selects the object by name if the reference has one.
+func namedObject(ref *corev1.ObjectReference) watchedObject {
+	o := watchedObject{ref: ref}
+	if ref.Name != "" {
+		o.fieldSelector = fields.OneTermEqualSelector("metadata.name", ref.Name).String()
+	}
+	return o
+}
+
+// Watch runs the query targets and sends their Results on the returned channel, then runs them again and sends the
+// Results whenever the outcome of a target changes. Targets are run again when the objects they query change, as
+// observed by informers, and every refresh interval, see WithWatchRefreshInterval.
+// The channel is closed when the context is cancelled. The targets must not be run elsewhere while they are watched.
+func (c *ClusterQueryClient) Watch(ctx context.Context, targets ...QueryTarget) (<-chan Results, error) {
+	if err := checkUniqueNames(targets); err != nil {
+		return nil, err
+	}
+
+	w := &queryWatch{
+		client:    c,
+		targets:   targets,
+		trigger:   make(chan struct{}, 1),
+		informers: make(map[watchedResource]cache.SharedIndexInformer),
+		resources: make(map[string][]watchedResource),
+	}
+	ch := make(chan Results)
+	go w.run(ctx, ch)
+	return ch, nil
+}
+
+// watchedResource is a resource watched by an informer, in a namespace or in all namespaces, and the selectors of the
+// watched objects.
+type watchedResource struct {
+	gvr           schema.GroupVersionResource
+	namespace     string
+	labelSelector string
+	fieldSelector string
+}
+
+// listOptions sets the selectors of the lists and watches of the resource.
+func (r watchedResource) listOptions(opts *metav1.ListOptions) {
+	opts.LabelSelector = r.labelSelector
+	opts.FieldSelector = r.fieldSelector
+}
+
+type queryWatch struct {
+	client  *ClusterQueryClient
+	targets []QueryTarget
+	// trigger is signalled by the informers when a watched object changes.
+	trigger   chan struct{}
+	informers map[watchedResource]cache.SharedIndexInformer
+	// resources are the resources watched for each target, by name.
+	resources map[string][]watchedResource
+}
+
+func (w *queryWatch) run(ctx context.Context, ch chan<- Results) {
+	defer close(ch)
+
+	// A nil channel never receives, so the targets are only run again on changes if refreshing is disabled.
+	var refresh <-chan time.Time
+	if interval := w.client.config.watchRefreshInterval; interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		refresh = ticker.C
+	}
+
+	var last Results
+	for {
+		w.startInformers(ctx)
+
+		q := w.client.Query(w.targets...)
+		// Errors are reported in the Results of each target.
+		_, _ = q.ExecuteContext(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		results := q.Results()
+		w.addSyncErrors(ctx, results)
+		if last == nil || !results.equal(last) {
+			select {
+			case ch <- results:
+				last = results
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.trigger:
+		case <-refresh:
+			w.client.Invalidate()
+		}
+	}
+}
+
+// startInformers starts an informer for each resource queried by the targets that is not watched yet, and waits for
+// them to sync, for at most watchSyncTimeout. Resources that are not served by the cluster yet are watched once a
+// refresh discovers them.
+func (w *queryWatch) startInformers(ctx context.Context) {
+	rm, err := w.client.config.restMapper()
+	if err != nil {
+		return
+	}
+
+	var synced []cache.InformerSynced
+	for _, t := range w.targets {
+		ot, ok := t.(objectTarget)
+		if !ok {
+			continue
+		}
+		w.resources[t.Name()] = nil
+		for _, o := range ot.watchedObjects() {
+			gvr, namespaced, err := objectResource(rm, o.ref)
+			if err != nil {
+				continue
+			}
+			r := watchedResource{gvr: gvr, labelSelector: o.labelSelector, fieldSelector: o.fieldSelector}
+			if namespaced {
+				r.namespace = o.ref.Namespace
+			}
+			w.resources[t.Name()] = append(w.resources[t.Name()], r)
+			if _, ok := w.informers[r]; ok {
+				continue
+			}
+
+			informer := dynamicinformer.NewFilteredDynamicInformer(w.client.config.dynamicClient, r.gvr, r.namespace, 0, cache.Indexers{}, r.listOptions).Informer()
+			informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+				AddFunc:    func(interface{}) { w.notify() },
+				UpdateFunc: func(interface{}, interface{}) { w.notify() },
+				DeleteFunc: func(interface{}) { w.notify() },
+			})
+			w.informers[r] = informer
+			go informer.Run(ctx.Done())
+			synced = append(synced, informer.HasSynced)
+		}
+	}
+	if len(synced) == 0 {
+		return
+	}
+
+	syncCtx, cancel := context.WithTimeout(ctx, watchSyncTimeout)
+	defer cancel()
+	if cache.WaitForCacheSync(syncCtx.Done(), synced...) {
+		// The initial list of the new informers does not need another run. Informers that have not synced trigger
+		// a run with their initial list once they do.
+		select {
+		case <-w.trigger:
+		default:
+		}
+	}
+}
+
+// addSyncErrors reports an error in the results of the targets that depend on informers that have not synced, since
+// changes of the objects they query are not observed.
+func (w *queryWatch) addSyncErrors(ctx context.Context, results Results) {
+	for name, resources := range w.resources {
+		result := results.ForQuery(name)
+		if result == nil || result.Err != nil {
+			continue
+		}
+		for _, r := range resources {
+			if w.informers[r].HasSynced() {
+				continue
+			}
+			result.Found = false
+			result.NotFoundReason = ""
+			result.Err = w.syncErr(ctx, r)
+			result.ErrorClass = classifyError(result.Err)
+			break
+		}
+	}
+}
+
+// syncErr returns why the informer of the resource has not synced. The informer does not expose the errors of its
+// lists, so the resource is listed again to find out, e.g., that the client is not allowed to list it.
+func (w *queryWatch) syncErr(ctx context.Context, r watchedResource) error {
+	opts := metav1.ListOptions{Limit: 1}
+	r.listOptions(&opts)
+	if _, err := w.client.config.dynamicClient.Resource(r.gvr).Namespace(r.namespace).List(ctx, opts); err != nil {
+		return fmt.Errorf("unable to watch %s: %w", r.gvr, err)
+	}
+	return fmt.Errorf("unable to watch %s: informer has not synced", r.gvr)
+}
+
+// notify signals the watch loop without blocking; changes observed while the targets run are coalesced into one run.
+func (w *queryWatch) notify() {
+	select {
+	case w.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// equal returns true if the results have the same outcome for every query.
+func (r Results) equal(other Results) bool {
+	if len(r) != len(other) {
+		return false
+	}
+	for name, a := range r {
+		b, ok := other[name]
+		if !ok || a.Found != b.Found || a.NotFoundReason != b.NotFoundReason || errorString(a.Err) != errorString(b.Err) {
+			return false
+		}
+	}
+	return true
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func (q *QueryObject) watchedObjects() []watchedObject {
+	if q.listMode() {
+		return []watchedObject{{ref: q.object, labelSelector: q.labelSelector, fieldSelector: q.listFieldSelector()}}
+	}
+	return []watchedObject{namedObject(q.object)}
+}
+
+func (q *QueryStatusCondition) watchedObjects() []watchedObject {
+	return []watchedObject{namedObject(q.object)}
+}
+
+func (q *QueryAPIService) watchedObjects() []watchedObject {
+	return []watchedObject{namedObject(q.object())}
+}
+
+func (q *QueryCustomResourceDefinition) watchedObjects() []watchedObject {
+	return []watchedObject{namedObject(q.object())}
+}
+
+func (q *QueryDataKey) watchedObjects() []watchedObject {
+	return []watchedObject{namedObject(q.object())}
+}
+
+func (q *QueryNodes) watchedObjects() []watchedObject {
+	return []watchedObject{{ref: &corev1.ObjectReference{APIVersion: nodes.GroupVersion().String(), Kind: "Node"}, labelSelector: q.labelSelector}}
+}
+
+func (q *QueryAllOf) watchedObjects() []watchedObject {
+	return watchedObjectsOf(q.targets)
+}
+
+func (q *QueryAnyOf) watchedObjects() []watchedObject {
+	return watchedObjectsOf(q.targets)
+}
+
+func (q *QueryNot) watchedObjects() []watchedObject {
+	return watchedObjectsOf(q.targets)
+}
+
+// watchedObjectsOf returns the objects queried by any of the targets.
+func watchedObjectsOf(targets []QueryTarget) []watchedObject {
+	var objects []watchedObject
+	for _, t := range targets {
+		if ot, ok := t.(objectTarget); ok {
+			objects = append(objects, ot.watchedObjects()...)
+		}
+	}
+	return objects
+}
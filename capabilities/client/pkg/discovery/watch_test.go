@@ -0,0 +1,139 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package discovery
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	testapigroup "k8s.io/apimachinery/pkg/apis/testapigroup/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestWatch(t *testing.T) {
+	carps := testapigroup.SchemeGroupVersion.WithResource("carps")
+	c, dynamicClient := newTestClusterQueryClient(t, apiResources, map[schema.GroupVersionResource]string{carps: "CarpList"}, nil, WithWatchRefreshInterval(100*time.Millisecond))
+
+	if _, err := c.Watch(context.Background(), testObject, Object("carpObj", &carp)); err == nil || !strings.Contains(err.Error(), "must be unique") {
+		t.Errorf("want error for duplicate names, got: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch, err := c.Watch(ctx, AllOf("carp", testObject), testGVR)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	next := func() Results {
+		select {
+		case results, ok := <-ch:
+			if !ok {
+				t.Fatal("channel closed")
+			}
+			return results
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for results")
+		}
+		return nil
+	}
+
+	results := next()
+	if results.ForQuery("carp").Found || !results.ForQuery("carpResource").Found {
+		t.Errorf("want only the resource to be found, got carp=%+v carpResource=%+v", results.ForQuery("carp"), results.ForQuery("carpResource"))
+	}
+
+	u := toUnstructured(t, testObjects[0])
+	u.SetAPIVersion(carp.APIVersion)
+	u.SetKind(carp.Kind)
+	if _, err := dynamicClient.Resource(carps).Namespace(carp.Namespace).Create(ctx, u, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	results = next()
+	if !results.ForQuery("carp").Found {
+		t.Errorf("want carp to be found after it was created, got %+v", results.ForQuery("carp"))
+	}
+
+	cancel()
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Error("want no results after the context is cancelled")
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("timed out waiting for the channel to close")
+	}
+}
+
+func TestWatchSelectsQueriedObjects(t *testing.T) {
+	carps := testapigroup.SchemeGroupVersion.WithResource("carps")
+	c, dynamicClient := newTestClusterQueryClient(t, apiResources, map[schema.GroupVersionResource]string{carps: "CarpList"}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	list := Object("carps", &corev1.ObjectReference{APIVersion: carp.APIVersion, Kind: carp.Kind, Namespace: carp.Namespace}).WithLabelSelector("app=fish")
+	if _, err := c.Watch(ctx, Object("carpObj", &carp), list); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]bool{"fieldSelector=metadata.name=test14": false, "labelSelector=app=fish": false}
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		for _, a := range dynamicClient.Actions() {
+			l, ok := a.(k8stesting.ListAction)
+			if !ok {
+				continue
+			}
+			r := l.GetListRestrictions()
+			if r.Fields != nil && !r.Fields.Empty() {
+				want["fieldSelector="+r.Fields.String()] = true
+			}
+			if r.Labels != nil && !r.Labels.Empty() {
+				want["labelSelector="+r.Labels.String()] = true
+			}
+		}
+		if want["fieldSelector=metadata.name=test14"] && want["labelSelector=app=fish"] {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Errorf("want the informers to list only the queried objects, got %v", want)
+}
+
+func TestWatchReportsInformersThatDoNotSync(t *testing.T) {
+	defer func(timeout time.Duration) { watchSyncTimeout = timeout }(watchSyncTimeout)
+	watchSyncTimeout = 100 * time.Millisecond
+
+	carps := testapigroup.SchemeGroupVersion.WithResource("carps")
+	c, dynamicClient := newTestClusterQueryClient(t, apiResources, nil, testObjects)
+	dynamicClient.PrependReactor("list", "carps", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(carps.GroupResource(), "", errors.New("denied"))
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch, err := c.Watch(ctx, Object("carpObj", &carp))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case results := <-ch:
+		result := results.ForQuery("carpObj")
+		if result.Found || result.Err == nil || result.ErrorClass != ErrorClassForbidden {
+			t.Errorf("want a forbidden error, got %+v", result)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for results")
+	}
+}
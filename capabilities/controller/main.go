@@ -4,13 +4,19 @@
 package main
 
 import (
+	"crypto/tls"
 	"flag"
+	"fmt"
 	"os"
+	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes"
+	cliflag "k8s.io/component-base/cli/flag"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
@@ -20,11 +26,18 @@ import (
 	corev1alpha2 "github.com/vmware-tanzu/tanzu-framework/apis/core/v1alpha2"
 	"github.com/vmware-tanzu/tanzu-framework/capabilities/controller/pkg/capabilities/core"
 	"github.com/vmware-tanzu/tanzu-framework/util/buildinfo"
+	"github.com/vmware-tanzu/tanzu-framework/util/webhook/certs"
 )
 
 var (
-	scheme   = runtime.NewScheme()
-	setupLog = ctrl.Log.WithName("setup")
+	scheme                              = runtime.NewScheme()
+	setupLog                            = ctrl.Log.WithName("setup")
+	defaultWebhookConfigLabel           = "tanzu.vmware.com/capabilities-webhook-managed-certs=true"
+	defaultWebhookServiceNamespace      = "tkg-system"
+	defaultWebhookServiceName           = "tanzu-capabilities-webhook-service"
+	defaultWebhookSecretNamespace       = "tkg-system"
+	defaultWebhookSecretName            = "tanzu-capabilities-webhook-server-cert" //nolint:gosec
+	defaultWebhookSecretVolumeMountPath = "/tmp/k8s-webhook-server/serving-certs"  //nolint:gosec
 )
 
 func init() {
@@ -34,7 +47,41 @@ func init() {
 	//+kubebuilder:scaffold:scheme
 }
 
+func setCipherSuiteFunc(cipherSuiteString string) (func(cfg *tls.Config), error) {
+	cipherSuites := strings.Split(cipherSuiteString, ",")
+	suites, err := cliflag.TLSCipherSuites(cipherSuites)
+	if err != nil {
+		return nil, err
+	}
+	return func(cfg *tls.Config) {
+		cfg.CipherSuites = suites
+	}, nil
+}
+
+//nolint:funlen
 func main() {
+	var (
+		webhookServerPort            int
+		tlsMinVersion                string
+		tlsCipherSuites              string
+		webhookConfigLabel           string
+		webhookServiceNamespace      string
+		webhookServiceName           string
+		webhookSecretNamespace       string
+		webhookSecretName            string
+		webhookSecretVolumeMountPath string
+	)
+
+	flag.IntVar(&webhookServerPort, "webhook-server-port", 9443, "The port that the webhook server serves at.")
+	flag.StringVar(&tlsMinVersion, "tls-min-version", "1.2", "The minimum TLS version to be used by the webhook server. Recommended values are \"1.2\" and \"1.3\".")
+	flag.StringVar(&tlsCipherSuites, "tls-cipher-suites", "", "Comma-separated list of cipher suites for the server. If omitted, the default Go cipher suites will be used.\n"+fmt.Sprintf("Possible values are %s.", strings.Join(cliflag.TLSCipherPossibleValues(), ", ")))
+	flag.StringVar(&webhookConfigLabel, "webhook-config-label", defaultWebhookConfigLabel, "The label used to select webhook configurations to update the certs for.")
+	flag.StringVar(&webhookServiceNamespace, "webhook-service-namespace", defaultWebhookServiceNamespace, "The namespace in which webhook service is installed.")
+	flag.StringVar(&webhookServiceName, "webhook-service-name", defaultWebhookServiceName, "The name of the webhook service.")
+	flag.StringVar(&webhookSecretNamespace, "webhook-secret-namespace", defaultWebhookSecretNamespace, "The namespace in which webhook secret is installed.")
+	flag.StringVar(&webhookSecretName, "webhook-secret-name", defaultWebhookSecretName, "The name of the webhook secret.")
+	flag.StringVar(&webhookSecretVolumeMountPath, "webhook-secret-volume-mount-path", defaultWebhookSecretVolumeMountPath, "The filesystem path to which the webhook secret is mounted.")
+
 	opts := zap.Options{
 		Development: true,
 	}
@@ -46,24 +93,86 @@ func main() {
 	setupLog.Info("Version", "version", buildinfo.Version, "buildDate", buildinfo.Date, "sha", buildinfo.SHA)
 
 	var err error
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{Scheme: scheme, MetricsBindAddress: "0"})
+	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{Scheme: scheme, MetricsBindAddress: "0", Port: webhookServerPort, CertDir: webhookSecretVolumeMountPath})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
 
+	mgr.GetWebhookServer().TLSMinVersion = tlsMinVersion
+	if tlsCipherSuites != "" {
+		cipherSuitesSetFunc, err := setCipherSuiteFunc(tlsCipherSuites)
+		if err != nil {
+			setupLog.Error(err, "unable to set TLS Cipher suites")
+			os.Exit(1)
+		}
+		mgr.GetWebhookServer().TLSOpts = append(mgr.GetWebhookServer().TLSOpts, cipherSuitesSetFunc)
+	}
+
 	if err = (&core.CapabilityReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("Capability").WithValues("apigroup", "core"),
-		Scheme: mgr.GetScheme(),
-		Host:   mgr.GetConfig().Host,
+		Client:     mgr.GetClient(),
+		Log:        ctrl.Log.WithName("controllers").WithName("Capability").WithValues("apigroup", "core"),
+		Scheme:     mgr.GetScheme(),
+		Clientset:  kubernetes.NewForConfigOrDie(mgr.GetConfig()),
+		RestConfig: mgr.GetConfig(),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Capability", "apigroup", "core")
 		os.Exit(1)
 	}
 
+	if err = (&core.CapabilityValidator{
+		APIReader: mgr.GetAPIReader(),
+		Log:       ctrl.Log.WithName("webhooks").WithName("Capability").WithValues("apigroup", "core"),
+	}).SetupWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "Capability", "apigroup", "core")
+		os.Exit(1)
+	}
+
 	//+kubebuilder:scaffold:builder
 
+	signalHandler := ctrl.SetupSignalHandler()
+
+	ctrlClient, err := client.New(ctrl.GetConfigOrDie(), client.Options{})
+	if err != nil {
+		setupLog.Error(err, "unable to create ctrl client")
+		os.Exit(1)
+	}
+
+	// Start certificate manager
+	setupLog.Info("Starting certificate manager")
+	certManagerOpts := &certs.Options{
+		Client:                        ctrlClient,
+		Logger:                        ctrl.Log.WithName("capabilities-webhook-cert-manager"),
+		CertDir:                       webhookSecretVolumeMountPath,
+		WebhookConfigLabel:            webhookConfigLabel,
+		CRDConversionLabel:            webhookConfigLabel,
+		RotationIntervalAnnotationKey: "tanzu.vmware.com/capabilities-webhook-rotation-interval",
+		NextRotationAnnotationKey:     "tanzu.vmware.com/capabilities-webhook-next-rotation",
+		RotationCountAnnotationKey:    "tanzu.vmware.com/capabilities-webhook-rotation-count",
+		SecretName:                    webhookSecretName,
+		SecretNamespace:               webhookSecretNamespace,
+		ServiceName:                   webhookServiceName,
+		ServiceNamespace:              webhookServiceNamespace,
+	}
+
+	certManager, err := certs.New(certManagerOpts)
+	if err != nil {
+		setupLog.Error(err, "failed to create certificate manager")
+		os.Exit(1)
+	}
+
+	// Start cert manager.
+	if err := certManager.Start(signalHandler); err != nil {
+		setupLog.Error(err, "failed to start certificate manager")
+		os.Exit(1)
+	}
+
+	// Wait for cert dir to be ready.
+	if err := certManager.WaitForCertDirReady(); err != nil {
+		setupLog.Error(err, "certificates not ready")
+		os.Exit(1)
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
@@ -74,7 +183,7 @@ func main() {
 	}
 
 	setupLog.Info("starting manager")
-	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+	if err := mgr.Start(signalHandler); err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
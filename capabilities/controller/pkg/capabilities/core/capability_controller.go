@@ -6,11 +6,21 @@ package core
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	corev1alpha2 "github.com/vmware-tanzu/tanzu-framework/apis/core/v1alpha2"
 	"github.com/vmware-tanzu/tanzu-framework/capabilities/client/pkg/discovery"
@@ -23,11 +33,22 @@ type CapabilityReconciler struct {
 	client.Client
 	Log    logr.Logger
 	Scheme *runtime.Scheme
-	Host   string
+	// Clientset requests the tokens of the service accounts the queries are executed with.
+	Clientset kubernetes.Interface
+	// RestConfig is the config of the manager, whose address and TLS settings are used to query the API server with
+	// the service accounts.
+	RestConfig *rest.Config
+
+	serviceAccounts *config.ServiceAccountClients
+	dependencies    *dependencyWatches
+	servedResources *servedResources
 }
 
 //+kubebuilder:rbac:groups=run.tanzu.vmware.com,resources=capabilities,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=run.tanzu.vmware.com,resources=capabilities/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get;list;watch
+//+kubebuilder:rbac:groups=apiregistration.k8s.io,resources=apiservices,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=serviceaccounts/token,verbs=create
 
 // Reconcile reconciles a Capability spec by executing specified queries.
 func (r *CapabilityReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -36,114 +57,220 @@ func (r *CapabilityReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 
 	log := r.Log.WithValues("capability", req.NamespacedName)
 	log.Info("Starting reconcile")
+	r.servedResources.reconcileStarted()
 
 	capability := &corev1alpha2.Capability{}
 	if err := r.Get(ctxCancel, req.NamespacedName, capability); err != nil {
+		if apierrors.IsNotFound(err) {
+			r.dependencies.stop(req.NamespacedName)
+			r.pruneServiceAccounts(ctxCancel)
+		}
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
-
-	var serviceAccountName, namespaceName string
-	// use the default service account when the serviceAccountName is not provided as part of the spec
-	if len(capability.Spec.ServiceAccountName) > 0 {
-		serviceAccountName = capability.Spec.ServiceAccountName
-		namespaceName = req.Namespace
-	} else {
-		serviceAccountName = constants.ServiceAccountWithDefaultPermissions
-		namespaceName = constants.CapabilitiesControllerNamespace
+	// The service account may have changed with the spec.
+	if capability.Generation != capability.Status.ObservedGeneration {
+		r.pruneServiceAccounts(ctxCancel)
 	}
-	cfg, err := config.GetConfigForServiceAccount(ctx, r.Client, namespaceName, serviceAccountName, r.Host)
+
+	serviceAccount := serviceAccountOf(capability)
+	cfg, clusterQueryClient, err := r.serviceAccounts.Get(ctxCancel, serviceAccount.Namespace, serviceAccount.Name)
 	if err != nil {
-		return ctrl.Result{}, fmt.Errorf("unable to get config for ClusterQueryClient creation: %w", err)
+		return ctrl.Result{}, r.updateFailedStatus(ctxCancel, capability, fmt.Errorf("unable to get ClusterQueryClient for service account: %w", err))
 	}
-	clusterQueryClient, err := discovery.NewClusterQueryClientForConfig(cfg)
-	if err != nil {
-		return ctrl.Result{}, fmt.Errorf("unable to create ClusterQueryClient: %w", err)
+	// Results would go stale if the watch could not be started, so the reconcile is retried.
+	if err := r.dependencies.ensure(capability, serviceAccount.Name, cfg); err != nil {
+		return ctrl.Result{}, r.updateFailedStatus(ctxCancel, capability, fmt.Errorf("unable to watch the dependencies of the queries: %w", err))
 	}
 
-	capability.Status.Results = make([]corev1alpha2.Result, len(capability.Spec.Queries))
+	capability.Status.Results = r.executeQueries(ctxCancel, log, clusterQueryClient, discovery.CapabilityToQueryTargets(capability))
 
-	for i, query := range capability.Spec.Queries {
-		l := log.WithValues("query", query.Name)
+	setEvaluatedStatus(capability, metav1.Now())
 
-		capability.Status.Results[i].Name = query.Name
-		// Query GVRs.
-		capability.Status.Results[i].GroupVersionResources = r.queryGVRs(l, clusterQueryClient, query.GroupVersionResources)
-		// Query Objects.
-		capability.Status.Results[i].Objects = r.queryObjects(l, clusterQueryClient, query.Objects)
-		// Query PartialSchemas.
-		capability.Status.Results[i].PartialSchemas = r.queryPartialSchemas(l, clusterQueryClient, query.PartialSchemas)
+	log.Info("Successfully reconciled")
+	return ctrl.Result{RequeueAfter: reevaluationInterval(capability)}, r.Status().Update(ctxCancel, capability)
+}
+
+// serviceAccountOf returns the service account the queries of the Capability are executed with. The default service
+// account is used when the serviceAccountName is not provided as part of the spec.
+func serviceAccountOf(capability *corev1alpha2.Capability) types.NamespacedName {
+	if len(capability.Spec.ServiceAccountName) > 0 {
+		return types.NamespacedName{Namespace: capability.Namespace, Name: capability.Spec.ServiceAccountName}
 	}
+	return types.NamespacedName{Namespace: constants.CapabilitiesControllerNamespace, Name: constants.ServiceAccountWithDefaultPermissions}
+}
 
-	log.Info("Successfully reconciled")
-	return ctrl.Result{}, r.Status().Update(ctxCancel, capability)
+// pruneServiceAccounts drops the clients of the service accounts that no Capability uses any more.
+func (r *CapabilityReconciler) pruneServiceAccounts(ctx context.Context) {
+	capabilities := &corev1alpha2.CapabilityList{}
+	if err := r.List(ctx, capabilities); err != nil {
+		r.Log.Error(err, "Failed to list Capabilities")
+		return
+	}
+	used := make(map[types.NamespacedName]struct{}, len(capabilities.Items))
+	for i := range capabilities.Items {
+		used[serviceAccountOf(&capabilities.Items[i])] = struct{}{}
+	}
+	r.serviceAccounts.Retain(used)
 }
 
-// queryGVRs executes GVR queries and returns results.
-func (r *CapabilityReconciler) queryGVRs(log logr.Logger, clusterQueryClient *discovery.ClusterQueryClient, queries []corev1alpha2.QueryGVR) []corev1alpha2.QueryResult {
-	return r.executeQueries(log.WithValues("queryType", "GVR"), clusterQueryClient, func() map[string]discovery.QueryTarget {
-		queryTargets := make(map[string]discovery.QueryTarget)
-		for i := range queries {
-			q := queries[i]
-			query := discovery.Group(q.Name, q.Group).WithVersions(q.Versions...).WithResource(q.Resource)
-			queryTargets[q.Name] = query
-		}
-		return queryTargets
-	})
+// updateFailedStatus sets the conditions of the Capability when its queries could not be evaluated, and returns the
+// error so that the reconcile is retried.
+func (r *CapabilityReconciler) updateFailedStatus(ctx context.Context, capability *corev1alpha2.Capability, err error) error {
+	setFailedStatus(capability, metav1.Now(), err)
+	if updateErr := r.Status().Update(ctx, capability); updateErr != nil {
+		r.Log.Error(updateErr, "Failed to update the status of Capability", "capability", client.ObjectKeyFromObject(capability))
+	}
+	return err
 }
 
-// queryObjects executes Object queries and returns results.
-func (r *CapabilityReconciler) queryObjects(log logr.Logger, clusterQueryClient *discovery.ClusterQueryClient, queries []corev1alpha2.QueryObject) []corev1alpha2.QueryResult {
-	return r.executeQueries(log.WithValues("queryType", "Object"), clusterQueryClient, func() map[string]discovery.QueryTarget {
-		queryTargets := make(map[string]discovery.QueryTarget)
-		for i := range queries {
-			q := queries[i]
-			query := discovery.Object(q.Name, &q.ObjectReference).WithAnnotations(q.WithAnnotations).WithoutAnnotations(q.WithoutAnnotations)
-			queryTargets[q.Name] = query
-		}
-		return queryTargets
-	})
+// reevaluationInterval returns the interval at which the queries of the Capability are evaluated again, if any, which
+// is at least constants.MinReevaluationInterval.
+func reevaluationInterval(capability *corev1alpha2.Capability) time.Duration {
+	if capability.Spec.ReevaluationInterval == nil || capability.Spec.ReevaluationInterval.Duration <= 0 {
+		return 0
+	}
+	if capability.Spec.ReevaluationInterval.Duration < constants.MinReevaluationInterval {
+		return constants.MinReevaluationInterval
+	}
+	return capability.Spec.ReevaluationInterval.Duration
 }
 
-// queryPartialSchemas executes PartialSchema queries and returns results.
-func (r *CapabilityReconciler) queryPartialSchemas(log logr.Logger, clusterQueryClient *discovery.ClusterQueryClient, queries []corev1alpha2.QueryPartialSchema) []corev1alpha2.QueryResult {
-	return r.executeQueries(log.WithValues("queryType", "PartialSchema"), clusterQueryClient, func() map[string]discovery.QueryTarget {
-		queryTargets := make(map[string]discovery.QueryTarget)
-		for i := range queries {
-			q := queries[i]
-			query := discovery.Schema(q.Name, q.PartialSchema)
-			queryTargets[q.Name] = query
-		}
-		return queryTargets
-	})
+// queryKind is the query targets of a kind of query of a Capability, with the results in status they are stored in.
+type queryKind struct {
+	name    string
+	targets []discovery.QueryTarget
+	results *[]corev1alpha2.QueryResult
 }
 
-// executeQueries executes queries using the discovery client and stores results.
-func (r *CapabilityReconciler) executeQueries(log logr.Logger, clusterQueryClient *discovery.ClusterQueryClient, specToQueryTargetFn func() map[string]discovery.QueryTarget) []corev1alpha2.QueryResult {
-	var results []corev1alpha2.QueryResult
-	queryTargetsMap := specToQueryTargetFn()
-	for name, queryTarget := range queryTargetsMap {
-		result := corev1alpha2.QueryResult{Name: name}
-		c := clusterQueryClient.Query(queryTarget)
-		found, err := c.Execute()
-		if err != nil {
-			result.Error = true
-			result.ErrorDetail = err.Error()
+// queryKinds returns the kinds of query of the query targets, with their results in the result of the query.
+func queryKinds(queryTargets *discovery.CapabilityQueryTargets, result *corev1alpha2.Result) []queryKind {
+	return []queryKind{
+		{"GVR", queryTargets.GroupVersionResources, &result.GroupVersionResources},
+		{"Object", queryTargets.Objects, &result.Objects},
+		{"PartialSchema", queryTargets.PartialSchemas, &result.PartialSchemas},
+		{"ServerVersion", queryTargets.ServerVersions, &result.ServerVersions},
+		{"StatusCondition", queryTargets.StatusConditions, &result.StatusConditions},
+		{"Access", queryTargets.AccessChecks, &result.AccessChecks},
+		{"APIService", queryTargets.APIServices, &result.APIServices},
+		{"CustomResourceDefinition", queryTargets.CustomResourceDefinitions, &result.CustomResourceDefinitions},
+		{"Nodes", queryTargets.Nodes, &result.Nodes},
+		{"DataKey", queryTargets.DataKeys, &result.DataKeys},
+		{"AnyOf", queryTargets.AnyOf, &result.AnyOf},
+		{"Not", queryTargets.Not, &result.Not},
+	}
+}
+
+// executeQueries executes the query targets of all the queries in a single ClusterQuery, so that they run in parallel
+// bounded by its concurrency, and returns the results in the order of the spec. Target names are only unique within a
+// kind of query, so the targets are run under names made unique by the index of their query, their kind and their
+// index, and the results in status keep the names of the spec.
+func (r *CapabilityReconciler) executeQueries(ctx context.Context, log logr.Logger, clusterQueryClient *discovery.ClusterQueryClient, queries []discovery.CapabilityQueryTargets) []corev1alpha2.Result {
+	results := make([]corev1alpha2.Result, len(queries))
+	kinds := make([][]queryKind, len(queries))
+	var targets []discovery.QueryTarget
+	for i := range queries {
+		results[i].Name = queries[i].Name
+		kinds[i] = queryKinds(&queries[i], &results[i])
+		for _, kind := range kinds[i] {
+			for j, t := range kind.targets {
+				targets = append(targets, discovery.Renamed(targetName(i, kind.name, j), t))
+			}
 		}
-		result.Found = found
-		if !found {
-			if qr := c.Results().ForQuery(name); qr != nil {
-				result.NotFoundReason = qr.NotFoundReason
+	}
+	if len(targets) == 0 {
+		return results
+	}
+
+	c := clusterQueryClient.Query(targets...)
+	_, err := c.ExecuteContext(ctx)
+	if err != nil {
+		log.Error(err, "Failed to execute queries")
+	}
+
+	for i := range kinds {
+		for _, kind := range kinds[i] {
+			if len(kind.targets) == 0 {
+				continue
+			}
+			*kind.results = make([]corev1alpha2.QueryResult, 0, len(kind.targets))
+			for j, t := range kind.targets {
+				*kind.results = append(*kind.results, queryResult(t.Name(), c.Results().ForQuery(targetName(i, kind.name, j)), err))
 			}
 		}
-		results = append(results, result)
 	}
-	log.Info("Executed queries", "num", len(queryTargetsMap))
+	log.Info("Executed queries", "num", len(targets))
 	return results
 }
 
+// targetName returns the name a query target is run under, which is unique among the targets of a Capability.
+func targetName(query int, kind string, target int) string {
+	return fmt.Sprintf("%d/%s/%d", query, kind, target)
+}
+
+// queryResult converts the result of a query target to its result in status. err is the error of the ClusterQuery.
+func queryResult(name string, qr *discovery.QueryResult, err error) corev1alpha2.QueryResult {
+	result := corev1alpha2.QueryResult{Name: name}
+	switch {
+	case qr == nil:
+		// The queries failed before this one was run.
+		result.Error = err != nil
+		if err != nil {
+			result.ErrorDetail = err.Error()
+		}
+	case qr.Err != nil:
+		result.Error = true
+		result.ErrorDetail = qr.Err.Error()
+		result.ErrorClass = corev1alpha2.QueryErrorClass(qr.ErrorClass)
+	default:
+		result.Found = qr.Found
+		result.NotFoundReason = qr.NotFoundReason
+	}
+	if qr != nil {
+		addResultDetails(&result, qr)
+	}
+	return result
+}
+
+// addResultDetails copies the structured details of a query result to the result in status.
+func addResultDetails(result *corev1alpha2.QueryResult, qr *discovery.QueryResult) {
+	result.UnmatchedGVRs = qr.UnmatchedGVRs
+	result.UnmatchedAnnotations = qr.UnmatchedAnnotations
+	result.UnmatchedPredicates = qr.UnmatchedPredicates
+	result.UnmatchedField = qr.UnmatchedField
+	if qr.Object != nil {
+		result.ObjectReference = qr.Object.DeepCopy()
+	}
+	result.Duration = &metav1.Duration{Duration: qr.Duration}
+}
+
 // SetupWithManager sets up the controller with the Manager.
+// Capabilities are also reconciled when the objects their queries depend on change, and when CustomResourceDefinitions
+// or APIServices change the group versions served by the cluster. Those are watched as unstructured objects, since
+// their served versions and availability are not in their metadata.
 func (r *CapabilityReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.serviceAccounts = config.NewServiceAccountClients(r.Clientset, r.RestConfig, constants.ServiceAccountTokenExpiration)
+	r.dependencies = newDependencyWatches(r.Log.WithName("dependencies"))
+	r.servedResources = newServedResources(mgr.GetClient(), r.Log.WithName("servedResources"), r.resetServedResources)
+	if err := mgr.Add(r.servedResources); err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&corev1alpha2.Capability{}).
+		// Results include the duration of each query, so every status update changes the Capability; only spec
+		// changes are reconciled to avoid reconciling the status updates.
+		For(&corev1alpha2.Capability{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&source.Channel{Source: r.dependencies.events}, &handler.EnqueueRequestForObject{}).
+		Watches(&source.Channel{Source: r.servedResources.events}, &handler.EnqueueRequestForObject{}).
+		Watches(&source.Kind{Type: unstructuredObject("apiextensions.k8s.io/v1", "CustomResourceDefinition")},
+			r.servedResources, builder.WithPredicates(servedGroupVersionsChanged)).
+		Watches(&source.Kind{Type: unstructuredObject("apiregistration.k8s.io/v1", "APIService")},
+			r.servedResources, builder.WithPredicates(servedGroupVersionsChanged)).
 		Complete(r)
 }
+
+// resetServedResources restarts the dependency watches, so that they watch resources that are newly served, and
+// drops the discovery information cached for the service accounts.
+func (r *CapabilityReconciler) resetServedResources() {
+	r.dependencies.stopAll()
+	r.serviceAccounts.Invalidate()
+}
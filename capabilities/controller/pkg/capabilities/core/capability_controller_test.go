@@ -0,0 +1,146 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	corev1alpha2 "github.com/vmware-tanzu/tanzu-framework/apis/core/v1alpha2"
+	"github.com/vmware-tanzu/tanzu-framework/capabilities/controller/pkg/config"
+	"github.com/vmware-tanzu/tanzu-framework/capabilities/controller/pkg/constants"
+)
+
+// newTestReconciler returns a CapabilityReconciler for the objects, which gets a token for every service account.
+func newTestReconciler(t *testing.T, objects ...*corev1alpha2.Capability) *CapabilityReconciler {
+	scheme := runtime.NewScheme()
+	if err := corev1alpha2.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	builder := fake.NewClientBuilder().WithScheme(scheme)
+	for _, o := range objects {
+		builder = builder.WithObjects(o)
+	}
+	c := builder.Build()
+
+	clientset := kubefake.NewSimpleClientset()
+	clientset.PrependReactor("create", "serviceaccounts", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		tokenRequest := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenRequest).DeepCopy()
+		tokenRequest.Status = authenticationv1.TokenRequestStatus{Token: "token", ExpirationTimestamp: metav1.NewTime(time.Now().Add(time.Hour))}
+		return true, tokenRequest, nil
+	})
+
+	r := &CapabilityReconciler{Client: c, Log: logr.Discard(), Scheme: scheme}
+	r.serviceAccounts = config.NewServiceAccountClients(clientset, &rest.Config{Host: "127.0.0.1:1"}, time.Hour)
+	r.dependencies = newDependencyWatches(r.Log)
+	r.servedResources = newServedResources(c, r.Log, func() {})
+	return r
+}
+
+func TestReconcileRequeuesAfterReevaluationInterval(t *testing.T) {
+	testCases := []struct {
+		description          string
+		reevaluationInterval *metav1.Duration
+		want                 time.Duration
+	}{
+		{
+			description: "no reevaluation interval",
+			want:        0,
+		},
+		{
+			description:          "reevaluation interval",
+			reevaluationInterval: &metav1.Duration{Duration: time.Minute},
+			want:                 time.Minute,
+		},
+		{
+			description:          "reevaluation interval shorter than the minimum",
+			reevaluationInterval: &metav1.Duration{Duration: time.Second},
+			want:                 constants.MinReevaluationInterval,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			key := types.NamespacedName{Namespace: "default", Name: "capability"}
+			capability := &corev1alpha2.Capability{
+				ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name},
+				Spec:       corev1alpha2.CapabilitySpec{ReevaluationInterval: tc.reevaluationInterval},
+			}
+			r := newTestReconciler(t, capability)
+
+			result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.RequeueAfter != tc.want {
+				t.Errorf("RequeueAfter: got %s, want %s", result.RequeueAfter, tc.want)
+			}
+
+			got := &corev1alpha2.Capability{}
+			if err := r.Get(context.Background(), key, got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Status.LastEvaluationTime == nil {
+				t.Error("want the evaluation time to be set in status")
+			}
+		})
+	}
+}
+
+func TestReconcileKeepsTargetNamesOfEachKind(t *testing.T) {
+	key := types.NamespacedName{Namespace: "default", Name: "capability"}
+	query := func(name string) corev1alpha2.Query {
+		return corev1alpha2.Query{
+			Name:                  name,
+			GroupVersionResources: []corev1alpha2.QueryGVR{{Name: "target", Group: "apps", Versions: []string{"v1"}}},
+			Objects:               []corev1alpha2.QueryObject{{Name: "target", ObjectReference: corev1.ObjectReference{Kind: "Namespace", Name: "default"}}},
+		}
+	}
+	capability := &corev1alpha2.Capability{
+		ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name},
+		Spec:       corev1alpha2.CapabilitySpec{Queries: []corev1alpha2.Query{query("first"), query("second")}},
+	}
+	r := newTestReconciler(t, capability)
+
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatal(err)
+	}
+
+	got := &corev1alpha2.Capability{}
+	if err := r.Get(context.Background(), key, got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Status.Results) != 2 {
+		t.Fatalf("want the results of 2 queries, got %+v", got.Status.Results)
+	}
+	for i, name := range []string{"first", "second"} {
+		result := got.Status.Results[i]
+		if result.Name != name {
+			t.Errorf("want the result of query %q, got %q", name, result.Name)
+		}
+		// Nothing listens on the address of the API server, so every target fails on its own.
+		for _, r := range append(result.GroupVersionResources, result.Objects...) {
+			if r.Name != "target" || !r.Error || strings.Contains(r.ErrorDetail, "unique") {
+				t.Errorf("query %q: want an error of the target itself, got %+v", name, r)
+			}
+		}
+		if len(result.GroupVersionResources) != 1 || len(result.Objects) != 1 {
+			t.Errorf("query %q: want one GVR and one object result, got %+v", name, result)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	corev1alpha2 "github.com/vmware-tanzu/tanzu-framework/apis/core/v1alpha2"
+)
+
+// maxConditionMessageNames is the maximum number of queries listed in the message of a condition.
+const maxConditionMessageNames = 10
+
+// queryResults returns the results of all the kinds of queries of a query.
+func queryResults(result *corev1alpha2.Result) []corev1alpha2.QueryResult {
+	var results []corev1alpha2.QueryResult
+	for _, group := range [][]corev1alpha2.QueryResult{result.GroupVersionResources, result.Objects, result.PartialSchemas, result.ServerVersions, result.StatusConditions, result.AccessChecks, result.APIServices, result.CustomResourceDefinitions, result.Nodes, result.DataKeys, result.AnyOf, result.Not} {
+		results = append(results, group...)
+	}
+	return results
+}
+
+// satisfied returns true if the query has results, and all of them were found without errors. A query without results
+// is not satisfied, like an AllOf query target without targets.
+func satisfied(result *corev1alpha2.Result) bool {
+	results := queryResults(result)
+	for i := range results {
+		if !results[i].Found || results[i].Error {
+			return false
+		}
+	}
+	return len(results) != 0
+}
+
+// setEvaluatedStatus sets the satisfied rollup of each result, and the conditions, observed generation and evaluation
+// time of the status after the queries of the Capability were evaluated.
+func setEvaluatedStatus(capability *corev1alpha2.Capability, now metav1.Time) {
+	var unsatisfied, failed []string
+	for i := range capability.Status.Results {
+		result := &capability.Status.Results[i]
+		result.Satisfied = satisfied(result)
+		if !result.Satisfied {
+			unsatisfied = append(unsatisfied, result.Name)
+		}
+		results := queryResults(result)
+		for j := range results {
+			if results[j].Error {
+				failed = append(failed, fmt.Sprintf("%s/%s", result.Name, results[j].Name))
+			}
+		}
+	}
+
+	ready := metav1.Condition{
+		Type:    corev1alpha2.CapabilityReadyCondition,
+		Status:  metav1.ConditionTrue,
+		Reason:  corev1alpha2.CapabilityQueriesSatisfiedReason,
+		Message: "all queries are satisfied",
+	}
+	if len(unsatisfied) != 0 {
+		ready.Status = metav1.ConditionFalse
+		ready.Reason = corev1alpha2.CapabilityQueriesUnsatisfiedReason
+		ready.Message = "unsatisfied queries: " + joinNames(unsatisfied)
+	}
+	degraded := metav1.Condition{
+		Type:    corev1alpha2.CapabilityDegradedCondition,
+		Status:  metav1.ConditionFalse,
+		Reason:  corev1alpha2.CapabilityQueriesEvaluatedReason,
+		Message: "all queries were evaluated",
+	}
+	if len(failed) != 0 {
+		degraded.Status = metav1.ConditionTrue
+		degraded.Reason = corev1alpha2.CapabilityQueryErrorsReason
+		degraded.Message = "queries failed to be evaluated: " + joinNames(failed)
+	}
+	setConditions(capability, now, ready, degraded)
+}
+
+// setFailedStatus sets the conditions of the status when the queries of the Capability could not be evaluated. The
+// results of the previous evaluation are kept.
+func setFailedStatus(capability *corev1alpha2.Capability, now metav1.Time, err error) {
+	setConditions(capability, now,
+		metav1.Condition{
+			Type:    corev1alpha2.CapabilityReadyCondition,
+			Status:  metav1.ConditionFalse,
+			Reason:  corev1alpha2.CapabilityEvaluationFailedReason,
+			Message: err.Error(),
+		},
+		metav1.Condition{
+			Type:    corev1alpha2.CapabilityDegradedCondition,
+			Status:  metav1.ConditionTrue,
+			Reason:  corev1alpha2.CapabilityEvaluationFailedReason,
+			Message: err.Error(),
+		},
+	)
+}
+
+// setConditions sets the conditions, observed generation and evaluation time of the status.
+func setConditions(capability *corev1alpha2.Capability, now metav1.Time, conditions ...metav1.Condition) {
+	capability.Status.ObservedGeneration = capability.Generation
+	capability.Status.LastEvaluationTime = &now
+	for _, c := range conditions {
+		c.ObservedGeneration = capability.Generation
+		c.LastTransitionTime = now
+		apimeta.SetStatusCondition(&capability.Status.Conditions, c)
+	}
+}
+
+// joinNames joins the names for the message of a condition, listing at most maxConditionMessageNames of them.
+func joinNames(names []string) string {
+	if len(names) > maxConditionMessageNames {
+		return fmt.Sprintf("%s and %d more", strings.Join(names[:maxConditionMessageNames], ", "), len(names)-maxConditionMessageNames)
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,116 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	corev1alpha2 "github.com/vmware-tanzu/tanzu-framework/apis/core/v1alpha2"
+)
+
+func TestSetEvaluatedStatus(t *testing.T) {
+	found := corev1alpha2.QueryResult{Name: "found", Found: true}
+	notFound := corev1alpha2.QueryResult{Name: "notFound"}
+	failed := corev1alpha2.QueryResult{Name: "failed", Error: true, ErrorDetail: "forbidden"}
+
+	testCases := []struct {
+		description   string
+		results       []corev1alpha2.Result
+		wantSatisfied []bool
+		wantReady     metav1.ConditionStatus
+		wantDegraded  metav1.ConditionStatus
+		readyMessage  string
+	}{
+		{
+			description: "all queries satisfied",
+			results: []corev1alpha2.Result{
+				{Name: "a", GroupVersionResources: []corev1alpha2.QueryResult{found}},
+				{Name: "b", Objects: []corev1alpha2.QueryResult{found}, Not: []corev1alpha2.QueryResult{found}},
+			},
+			wantSatisfied: []bool{true, true},
+			wantReady:     metav1.ConditionTrue,
+			wantDegraded:  metav1.ConditionFalse,
+			readyMessage:  "all queries are satisfied",
+		},
+		{
+			description: "query not found",
+			results: []corev1alpha2.Result{
+				{Name: "a", GroupVersionResources: []corev1alpha2.QueryResult{found}},
+				{Name: "b", Nodes: []corev1alpha2.QueryResult{found, notFound}},
+			},
+			wantSatisfied: []bool{true, false},
+			wantReady:     metav1.ConditionFalse,
+			wantDegraded:  metav1.ConditionFalse,
+			readyMessage:  "unsatisfied queries: b",
+		},
+		{
+			description: "query failed",
+			results: []corev1alpha2.Result{
+				{Name: "a", DataKeys: []corev1alpha2.QueryResult{failed}},
+			},
+			wantSatisfied: []bool{false},
+			wantReady:     metav1.ConditionFalse,
+			wantDegraded:  metav1.ConditionTrue,
+			readyMessage:  "unsatisfied queries: a",
+		},
+		{
+			description:   "query without results",
+			results:       []corev1alpha2.Result{{Name: "empty"}},
+			wantSatisfied: []bool{false},
+			wantReady:     metav1.ConditionFalse,
+			wantDegraded:  metav1.ConditionFalse,
+			readyMessage:  "unsatisfied queries: empty",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			capability := &corev1alpha2.Capability{
+				ObjectMeta: metav1.ObjectMeta{Generation: 3},
+				Status:     corev1alpha2.CapabilityStatus{Results: tc.results},
+			}
+			now := metav1.Now()
+			setEvaluatedStatus(capability, now)
+
+			for i, want := range tc.wantSatisfied {
+				if got := capability.Status.Results[i].Satisfied; got != want {
+					t.Errorf("result %s: satisfied=%t, want %t", capability.Status.Results[i].Name, got, want)
+				}
+			}
+			if capability.Status.ObservedGeneration != 3 || capability.Status.LastEvaluationTime == nil || !capability.Status.LastEvaluationTime.Equal(&now) {
+				t.Errorf("unexpected observedGeneration %d or lastEvaluationTime %v", capability.Status.ObservedGeneration, capability.Status.LastEvaluationTime)
+			}
+			ready := apimeta.FindStatusCondition(capability.Status.Conditions, corev1alpha2.CapabilityReadyCondition)
+			if ready == nil || ready.Status != tc.wantReady || ready.Message != tc.readyMessage || ready.ObservedGeneration != 3 {
+				t.Errorf("unexpected Ready condition: %+v", ready)
+			}
+			degraded := apimeta.FindStatusCondition(capability.Status.Conditions, corev1alpha2.CapabilityDegradedCondition)
+			if degraded == nil || degraded.Status != tc.wantDegraded {
+				t.Errorf("unexpected Degraded condition: %+v", degraded)
+			}
+		})
+	}
+}
+
+func TestSetFailedStatusKeepsTransitionTime(t *testing.T) {
+	capability := &corev1alpha2.Capability{
+		Status: corev1alpha2.CapabilityStatus{Results: []corev1alpha2.Result{{Name: "a", Objects: []corev1alpha2.QueryResult{{Name: "x"}}}}},
+	}
+	first := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	setFailedStatus(capability, first, errors.New("no service account"))
+	setFailedStatus(capability, metav1.Now(), errors.New("no service account"))
+
+	ready := apimeta.FindStatusCondition(capability.Status.Conditions, corev1alpha2.CapabilityReadyCondition)
+	if ready == nil || ready.Reason != corev1alpha2.CapabilityEvaluationFailedReason || !ready.LastTransitionTime.Equal(&first) {
+		t.Errorf("unexpected Ready condition: %+v", ready)
+	}
+	if len(capability.Status.Results) != 1 {
+		t.Errorf("results of the previous evaluation were not kept: %+v", capability.Status.Results)
+	}
+}
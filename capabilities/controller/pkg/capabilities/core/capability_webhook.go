@@ -0,0 +1,97 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	corev1alpha2 "github.com/vmware-tanzu/tanzu-framework/apis/core/v1alpha2"
+	"github.com/vmware-tanzu/tanzu-framework/capabilities/client/pkg/discovery"
+)
+
+// CapabilityValidator validates Capabilities when they are created or updated, so that queries that can never be
+// evaluated are rejected by the API server instead of failing when the Capability is reconciled.
+type CapabilityValidator struct {
+	// APIReader reads service accounts from the API server. The cached client of the manager would watch all the
+	// service accounts of the cluster, and miss the ones created just before the Capability.
+	APIReader client.Reader
+	Log       logr.Logger
+}
+
+//+kubebuilder:rbac:groups="",resources=serviceaccounts,verbs=get
+
+//+kubebuilder:webhook:verbs=create;update,path=/validate-core-tanzu-vmware-com-v1alpha2-capability,mutating=false,failurePolicy=fail,groups=core.tanzu.vmware.com,resources=capabilities,versions=v1alpha2,name=vcapability.kb.io
+
+var _ admission.CustomValidator = &CapabilityValidator{}
+
+// SetupWebhookWithManager adds the webhook to the manager. The conversion webhook between the versions of Capability
+// is also served, since v1alpha1 is convertible to the v1alpha2 hub when both are in the scheme of the manager.
+func (v *CapabilityValidator) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&corev1alpha2.Capability{}).
+		WithValidator(v).
+		Complete()
+}
+
+// ValidateCreate implements admission.CustomValidator.
+func (v *CapabilityValidator) ValidateCreate(ctx context.Context, obj runtime.Object) error {
+	return v.validate(ctx, obj, true)
+}
+
+// ValidateUpdate implements admission.CustomValidator. The service account is only checked when it changes, so that a
+// Capability whose service account was deleted can still be updated, e.g. to remove its finalizers.
+func (v *CapabilityValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
+	oldCapability, ok := oldObj.(*corev1alpha2.Capability)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected a Capability but got a %T", oldObj))
+	}
+	newCapability, ok := newObj.(*corev1alpha2.Capability)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected a Capability but got a %T", newObj))
+	}
+	return v.validate(ctx, newCapability, oldCapability.Spec.ServiceAccountName != newCapability.Spec.ServiceAccountName)
+}
+
+// ValidateDelete implements admission.CustomValidator.
+func (v *CapabilityValidator) ValidateDelete(_ context.Context, _ runtime.Object) error {
+	return nil
+}
+
+// validate validates the queries of the Capability and, if checkServiceAccount is set, ensures its service account
+// exists in its namespace.
+func (v *CapabilityValidator) validate(ctx context.Context, obj runtime.Object, checkServiceAccount bool) error {
+	capability, ok := obj.(*corev1alpha2.Capability)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected a Capability but got a %T", obj))
+	}
+	v.Log.V(1).Info("Validating Capability", "capability", client.ObjectKeyFromObject(capability))
+
+	allErrs := discovery.ValidateCapability(capability)
+
+	// The service account with default permissions is used when the name is empty, see CapabilityReconciler.
+	if saName := capability.Spec.ServiceAccountName; checkServiceAccount && saName != "" {
+		sa := &corev1.ServiceAccount{}
+		if err := v.APIReader.Get(ctx, client.ObjectKey{Namespace: capability.Namespace, Name: saName}, sa); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return apierrors.NewInternalError(fmt.Errorf("unable to get service account %s/%s: %w", capability.Namespace, saName, err))
+			}
+			allErrs = append(allErrs, field.NotFound(field.NewPath("spec", "serviceAccountName"), saName))
+		}
+	}
+
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(corev1alpha2.GroupVersion.WithKind("Capability").GroupKind(), capability.Name, allErrs)
+}
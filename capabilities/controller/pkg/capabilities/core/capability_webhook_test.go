@@ -0,0 +1,128 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/conversion"
+
+	corev1alpha1 "github.com/vmware-tanzu/tanzu-framework/apis/core/v1alpha1"
+	corev1alpha2 "github.com/vmware-tanzu/tanzu-framework/apis/core/v1alpha2"
+)
+
+func TestCapabilityValidator(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = corev1alpha2.AddToScheme(scheme)
+	sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "capabilities"}}
+	validator := &CapabilityValidator{
+		APIReader: fake.NewClientBuilder().WithScheme(scheme).WithObjects(sa).Build(),
+		Log:       logr.Discard(),
+	}
+	validQuery := corev1alpha2.Query{
+		Name:                  "valid",
+		GroupVersionResources: []corev1alpha2.QueryGVR{{Name: "apps", Group: "apps", Versions: []string{"v1"}}},
+	}
+
+	testCases := []struct {
+		description string
+		// update validates an update from a Capability with oldServiceAccountName instead of a create.
+		update                bool
+		oldServiceAccountName string
+		spec                  corev1alpha2.CapabilitySpec
+		wantCauses            int
+	}{
+		{
+			description: "existing service account",
+			spec:        corev1alpha2.CapabilitySpec{ServiceAccountName: "capabilities", Queries: []corev1alpha2.Query{validQuery}},
+		},
+		{
+			description: "default service account",
+			spec:        corev1alpha2.CapabilitySpec{Queries: []corev1alpha2.Query{validQuery}},
+		},
+		{
+			description: "missing service account",
+			spec:        corev1alpha2.CapabilitySpec{ServiceAccountName: "missing", Queries: []corev1alpha2.Query{validQuery}},
+			wantCauses:  1,
+		},
+		{
+			description: "invalid queries",
+			spec: corev1alpha2.CapabilitySpec{Queries: []corev1alpha2.Query{{
+				Name:           "invalid",
+				PartialSchemas: []corev1alpha2.QueryPartialSchema{{Name: "schema", PartialSchema: "type: [object"}},
+				Objects:        []corev1alpha2.QueryObject{{Name: "object", ObjectReference: corev1.ObjectReference{APIVersion: "v1", Name: "default"}}},
+			}}},
+			wantCauses: 2,
+		},
+		{
+			description: "GVR resource without versions",
+			spec: corev1alpha2.CapabilitySpec{Queries: []corev1alpha2.Query{{
+				Name:                  "gvr",
+				GroupVersionResources: []corev1alpha2.QueryGVR{{Name: "deployments", Group: "apps", Resource: "deployments"}},
+			}}},
+			wantCauses: 1,
+		},
+		{
+			description:           "update with the same missing service account",
+			update:                true,
+			oldServiceAccountName: "missing",
+			spec:                  corev1alpha2.CapabilitySpec{ServiceAccountName: "missing", Queries: []corev1alpha2.Query{validQuery}},
+		},
+		{
+			description:           "update to a missing service account",
+			update:                true,
+			oldServiceAccountName: "capabilities",
+			spec:                  corev1alpha2.CapabilitySpec{ServiceAccountName: "missing", Queries: []corev1alpha2.Query{validQuery}},
+			wantCauses:            1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			capability := &corev1alpha2.Capability{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "capability"},
+				Spec:       tc.spec,
+			}
+			var err error
+			if !tc.update {
+				err = validator.ValidateCreate(context.Background(), capability)
+			} else {
+				oldCapability := capability.DeepCopy()
+				oldCapability.Spec.ServiceAccountName = tc.oldServiceAccountName
+				err = validator.ValidateUpdate(context.Background(), oldCapability, capability)
+			}
+			if tc.wantCauses == 0 {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			statusErr, ok := err.(*apierrors.StatusError)
+			if !ok || !apierrors.IsInvalid(err) {
+				t.Fatalf("expected an Invalid error, got %v", err)
+			}
+			if causes := statusErr.Status().Details.Causes; len(causes) != tc.wantCauses {
+				t.Errorf("got causes %+v, want %d causes", causes, tc.wantCauses)
+			}
+		})
+	}
+}
+
+func TestCapabilityIsConvertible(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1alpha1.AddToScheme(scheme)
+	_ = corev1alpha2.AddToScheme(scheme)
+	ok, err := conversion.IsConvertible(scheme, &corev1alpha2.Capability{})
+	if err != nil || !ok {
+		t.Errorf("expected Capability to be convertible, got %t, %v", ok, err)
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+import (
+	"context"
+	"sync"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	corev1alpha2 "github.com/vmware-tanzu/tanzu-framework/apis/core/v1alpha2"
+	"github.com/vmware-tanzu/tanzu-framework/capabilities/client/pkg/discovery"
+)
+
+// dependencyWatches watches the objects queried by each Capability, with the credentials the Capability is evaluated
+// with, and sends an event for the Capability whenever the outcome of its queries changes.
+type dependencyWatches struct {
+	log    logr.Logger
+	events chan event.GenericEvent
+
+	mu      sync.Mutex
+	watches map[types.NamespacedName]*dependencyWatch
+}
+
+// dependencyWatch is the watch of the objects queried by a generation of a Capability.
+type dependencyWatch struct {
+	generation         int64
+	serviceAccountName string
+	cancel             context.CancelFunc
+}
+
+func newDependencyWatches(log logr.Logger) *dependencyWatches {
+	return &dependencyWatches{
+		log:     log,
+		events:  make(chan event.GenericEvent),
+		watches: make(map[types.NamespacedName]*dependencyWatch),
+	}
+}
+
+// ensure starts watching the objects queried by the Capability, unless they are already watched for its generation and
+// service account. The first outcome of the watch also sends an event, so that changes between the reconcile that
+// started the watch and the start of its informers are not missed.
+func (w *dependencyWatches) ensure(capability *corev1alpha2.Capability, serviceAccountName string, cfg *rest.Config) error {
+	key := types.NamespacedName{Namespace: capability.Namespace, Name: capability.Name}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if existing, ok := w.watches[key]; ok {
+		if existing.generation == capability.Generation && existing.serviceAccountName == serviceAccountName {
+			return nil
+		}
+		existing.cancel()
+		delete(w.watches, key)
+	}
+
+	var targets []discovery.QueryTarget
+	for _, queryTargets := range discovery.CapabilityToQueryTargets(capability) {
+		targets = append(targets, queryTargets.AllOf())
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+
+	// Discovery is not refreshed: changes of the group versions served by the cluster restart all the watches, see
+	// servedResources.
+	clusterQueryClient, err := discovery.NewClusterQueryClientForConfig(cfg, discovery.WithWatchRefreshInterval(0))
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	results, err := clusterQueryClient.Watch(ctx, targets...)
+	if err != nil {
+		cancel()
+		return err
+	}
+	w.watches[key] = &dependencyWatch{
+		generation:         capability.Generation,
+		serviceAccountName: serviceAccountName,
+		cancel:             cancel,
+	}
+
+	object := &corev1alpha2.Capability{}
+	object.Namespace, object.Name = capability.Namespace, capability.Name
+	go func() {
+		for range results {
+			w.log.V(1).Info("Dependencies of Capability changed", "capability", key)
+			select {
+			case w.events <- event.GenericEvent{Object: object}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return nil
+}
+
+// stop stops watching the objects queried by the Capability.
+func (w *dependencyWatches) stop(key types.NamespacedName) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if existing, ok := w.watches[key]; ok {
+		existing.cancel()
+		delete(w.watches, key)
+	}
+}
+
+// stopAll stops all the watches, which are started again by the next reconcile of their Capability.
+func (w *dependencyWatches) stopAll() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for key, existing := range w.watches {
+		existing.cancel()
+		delete(w.watches, key)
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+
+	corev1alpha2 "github.com/vmware-tanzu/tanzu-framework/apis/core/v1alpha2"
+)
+
+func TestDependencyWatches(t *testing.T) {
+	// Nothing listens on the address, so the queries fail, which is an outcome the watch reports.
+	cfg := &rest.Config{Host: "127.0.0.1:1"}
+	key := types.NamespacedName{Namespace: "default", Name: "capability"}
+	capability := &corev1alpha2.Capability{
+		ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name, Generation: 1},
+		Spec: corev1alpha2.CapabilitySpec{
+			Queries: []corev1alpha2.Query{{
+				Name:                  "carps",
+				GroupVersionResources: []corev1alpha2.QueryGVR{{Name: "carps", Group: "example.com"}},
+			}},
+		},
+	}
+	w := newDependencyWatches(logr.Discard())
+	defer w.stopAll()
+
+	if err := w.ensure(capability, "foo", cfg); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case e := <-w.events:
+		if e.Object.GetNamespace() != key.Namespace || e.Object.GetName() != key.Name {
+			t.Errorf("want an event for %s, got %s/%s", key, e.Object.GetNamespace(), e.Object.GetName())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the first outcome of the watch")
+	}
+	watch := w.watches[key]
+
+	if err := w.ensure(capability, "foo", cfg); err != nil {
+		t.Fatal(err)
+	}
+	if w.watches[key] != watch {
+		t.Error("want the watch to be kept for the same generation and service account")
+	}
+
+	for _, update := range []struct {
+		description        string
+		generation         int64
+		serviceAccountName string
+	}{
+		{"generation changed", 2, "foo"},
+		{"service account changed", 2, "bar"},
+	} {
+		capability.Generation = update.generation
+		if err := w.ensure(capability, update.serviceAccountName, cfg); err != nil {
+			t.Fatal(err)
+		}
+		if w.watches[key] == watch {
+			t.Errorf("%s: want the watch to be restarted", update.description)
+		}
+		watch = w.watches[key]
+	}
+
+	w.stop(key)
+	if _, ok := w.watches[key]; ok {
+		t.Error("want the watch to be stopped")
+	}
+
+	if err := w.ensure(&corev1alpha2.Capability{ObjectMeta: capability.ObjectMeta}, "foo", cfg); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := w.watches[key]; ok {
+		t.Error("want no watch for a Capability without queries")
+	}
+
+	if err := w.ensure(capability, "foo", cfg); err != nil {
+		t.Fatal(err)
+	}
+	w.stopAll()
+	if len(w.watches) != 0 {
+		t.Errorf("want all the watches to be stopped, got %d", len(w.watches))
+	}
+}
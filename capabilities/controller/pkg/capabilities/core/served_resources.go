@@ -0,0 +1,229 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+import (
+	"context"
+	"reflect"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	corev1alpha2 "github.com/vmware-tanzu/tanzu-framework/apis/core/v1alpha2"
+	"github.com/vmware-tanzu/tanzu-framework/capabilities/controller/pkg/constants"
+)
+
+// servedResourcesDebounceInterval is how long the group versions served by the cluster have to stay unchanged before
+// the state that depends on them is rebuilt, so that e.g. installing a set of CRDs rebuilds it once.
+const servedResourcesDebounceInterval = 2 * time.Second
+
+// servedResources rebuilds the state that depends on the group versions served by the cluster when they change, and
+// sends an event for every Capability so that its queries are evaluated again. It is the event handler of
+// CustomResourceDefinitions and APIServices, filtered with servedGroupVersionsChanged, and a manager.Runnable which
+// coalesces the changes into a single rebuild.
+type servedResources struct {
+	client   client.Reader
+	log      logr.Logger
+	interval time.Duration
+	// rebuild drops the state that depends on the group versions served by the cluster.
+	rebuild func()
+	trigger chan struct{}
+	events  chan event.GenericEvent
+
+	// reconciling is set by the first reconcile. Nothing depends on the served group versions before, so the changes
+	// observed until then, e.g. the initial list of CustomResourceDefinitions and APIServices, are ignored.
+	reconciling int32
+}
+
+var _ handler.EventHandler = &servedResources{}
+
+func newServedResources(c client.Reader, log logr.Logger, rebuild func()) *servedResources {
+	return &servedResources{
+		client:   c,
+		log:      log,
+		interval: servedResourcesDebounceInterval,
+		rebuild:  rebuild,
+		trigger:  make(chan struct{}, 1),
+		events:   make(chan event.GenericEvent),
+	}
+}
+
+// reconcileStarted records that Capabilities are being reconciled, so that later changes rebuild their state.
+func (s *servedResources) reconcileStarted() {
+	atomic.StoreInt32(&s.reconciling, 1)
+}
+
+// changed signals the rebuild loop without blocking.
+func (s *servedResources) changed() {
+	if atomic.LoadInt32(&s.reconciling) == 0 {
+		return
+	}
+	select {
+	case s.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Create implements handler.EventHandler.
+func (s *servedResources) Create(event.CreateEvent, workqueue.RateLimitingInterface) {
+	s.changed()
+}
+
+// Update implements handler.EventHandler.
+func (s *servedResources) Update(event.UpdateEvent, workqueue.RateLimitingInterface) {
+	s.changed()
+}
+
+// Delete implements handler.EventHandler.
+func (s *servedResources) Delete(event.DeleteEvent, workqueue.RateLimitingInterface) {
+	s.changed()
+}
+
+// Generic implements handler.EventHandler.
+func (s *servedResources) Generic(event.GenericEvent, workqueue.RateLimitingInterface) {
+	s.changed()
+}
+
+// Start implements manager.Runnable. It rebuilds the state once no change was observed for the interval, then sends an
+// event for every Capability.
+func (s *servedResources) Start(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.trigger:
+		}
+		if !s.wait(ctx) {
+			return nil
+		}
+		s.rebuild()
+		s.enqueueAll(ctx)
+	}
+}
+
+// wait waits until no change was observed for the interval. It returns false if the context is done first.
+func (s *servedResources) wait(ctx context.Context) bool {
+	timer := time.NewTimer(s.interval)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-s.trigger:
+			if !timer.Stop() {
+				<-timer.C
+			}
+			timer.Reset(s.interval)
+		case <-timer.C:
+			return true
+		}
+	}
+}
+
+// enqueueAll sends an event for every Capability.
+func (s *servedResources) enqueueAll(ctx context.Context) {
+	ctxCancel, cancel := context.WithTimeout(ctx, constants.ContextTimeout)
+	defer cancel()
+
+	capabilities := &corev1alpha2.CapabilityList{}
+	if err := s.client.List(ctxCancel, capabilities); err != nil {
+		s.log.Error(err, "Failed to list Capabilities")
+		return
+	}
+	s.log.Info("Served group versions changed", "capabilities", len(capabilities.Items))
+	for i := range capabilities.Items {
+		object := &corev1alpha2.Capability{}
+		object.Namespace, object.Name = capabilities.Items[i].Namespace, capabilities.Items[i].Name
+		select {
+		case s.events <- event.GenericEvent{Object: object}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// servedGroupVersionsChanged filters the events of CustomResourceDefinitions and APIServices that change the group
+// versions served by the cluster, including a CustomResourceDefinition becoming established. Other changes, e.g. of the
+// labels of a CustomResourceDefinition or of an APIService, are ignored.
+var servedGroupVersionsChanged = predicate.Funcs{
+	CreateFunc: func(e event.CreateEvent) bool {
+		return len(servedGroupVersions(e.Object)) != 0
+	},
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		return !reflect.DeepEqual(servedGroupVersions(e.ObjectOld), servedGroupVersions(e.ObjectNew))
+	},
+	DeleteFunc: func(e event.DeleteEvent) bool {
+		return len(servedGroupVersions(e.Object)) != 0
+	},
+	GenericFunc: func(event.GenericEvent) bool {
+		return false
+	},
+}
+
+// servedGroupVersions returns the group versions served by a CustomResourceDefinition when it is established, or by an
+// APIService when it is available.
+func servedGroupVersions(obj client.Object) []string {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil
+	}
+
+	var groupVersions []string
+	switch u.GetKind() {
+	case "CustomResourceDefinition":
+		// The versions of a CustomResourceDefinition are only served once it is established.
+		if !hasTrueCondition(u, "Established") {
+			return nil
+		}
+		group, _, _ := unstructured.NestedString(u.Object, "spec", "group")
+		versions, _, _ := unstructured.NestedSlice(u.Object, "spec", "versions")
+		for _, v := range versions {
+			version, ok := v.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if served, _, _ := unstructured.NestedBool(version, "served"); !served {
+				continue
+			}
+			name, _, _ := unstructured.NestedString(version, "name")
+			groupVersions = append(groupVersions, schema.GroupVersion{Group: group, Version: name}.String())
+		}
+	case "APIService":
+		if !hasTrueCondition(u, "Available") {
+			return nil
+		}
+		group, _, _ := unstructured.NestedString(u.Object, "spec", "group")
+		version, _, _ := unstructured.NestedString(u.Object, "spec", "version")
+		groupVersions = append(groupVersions, schema.GroupVersion{Group: group, Version: version}.String())
+	}
+	return groupVersions
+}
+
+// hasTrueCondition returns whether the status of the object has the condition with status True.
+func hasTrueCondition(u *unstructured.Unstructured, conditionType string) bool {
+	conditions, _, _ := unstructured.NestedSlice(u.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if ok && condition["type"] == conditionType && condition["status"] == "True" {
+			return true
+		}
+	}
+	return false
+}
+
+// unstructuredObject returns an object of the kind, to watch the objects of kinds that are not in the scheme.
+func unstructuredObject(apiVersion, kind string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion(apiVersion)
+	u.SetKind(kind)
+	return u
+}
@@ -0,0 +1,170 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	corev1alpha2 "github.com/vmware-tanzu/tanzu-framework/apis/core/v1alpha2"
+)
+
+func customResourceDefinition(served ...bool) *unstructured.Unstructured {
+	crd := unstructuredObject("apiextensions.k8s.io/v1", "CustomResourceDefinition")
+	crd.SetName("carps.example.com")
+	var versions []interface{}
+	for i, s := range served {
+		versions = append(versions, map[string]interface{}{"name": []string{"v1", "v2"}[i], "served": s})
+	}
+	crd.Object["spec"] = map[string]interface{}{"group": "example.com", "versions": versions}
+	crd.Object["status"] = map[string]interface{}{
+		"conditions": []interface{}{map[string]interface{}{"type": "Established", "status": "True"}},
+	}
+	return crd
+}
+
+func notEstablished(crd *unstructured.Unstructured) *unstructured.Unstructured {
+	crd.Object["status"] = map[string]interface{}{
+		"conditions": []interface{}{map[string]interface{}{"type": "Established", "status": "False"}},
+	}
+	return crd
+}
+
+func apiService(available string) *unstructured.Unstructured {
+	apiService := unstructuredObject("apiregistration.k8s.io/v1", "APIService")
+	apiService.SetName("v1beta1.metrics.k8s.io")
+	apiService.Object["spec"] = map[string]interface{}{"group": "metrics.k8s.io", "version": "v1beta1"}
+	apiService.Object["status"] = map[string]interface{}{
+		"conditions": []interface{}{map[string]interface{}{"type": "Available", "status": available}},
+	}
+	return apiService
+}
+
+func TestServedGroupVersionsChanged(t *testing.T) {
+	labeled := customResourceDefinition(true)
+	labeled.SetLabels(map[string]string{"foo": "bar"})
+
+	testCases := []struct {
+		description string
+		got         bool
+		want        bool
+	}{
+		{
+			description: "CRD with a served version created",
+			got:         servedGroupVersionsChanged.Create(event.CreateEvent{Object: customResourceDefinition(true)}),
+			want:        true,
+		},
+		{
+			description: "CRD that is not established yet created",
+			got:         servedGroupVersionsChanged.Create(event.CreateEvent{Object: notEstablished(customResourceDefinition(true))}),
+			want:        false,
+		},
+		{
+			description: "CRD established after it was created",
+			got:         servedGroupVersionsChanged.Update(event.UpdateEvent{ObjectOld: notEstablished(customResourceDefinition(true)), ObjectNew: customResourceDefinition(true)}),
+			want:        true,
+		},
+		{
+			description: "CRD without served versions created",
+			got:         servedGroupVersionsChanged.Create(event.CreateEvent{Object: customResourceDefinition(false)}),
+			want:        false,
+		},
+		{
+			description: "CRD version served",
+			got:         servedGroupVersionsChanged.Update(event.UpdateEvent{ObjectOld: customResourceDefinition(true, false), ObjectNew: customResourceDefinition(true, true)}),
+			want:        true,
+		},
+		{
+			description: "CRD labels changed",
+			got:         servedGroupVersionsChanged.Update(event.UpdateEvent{ObjectOld: customResourceDefinition(true), ObjectNew: labeled}),
+			want:        false,
+		},
+		{
+			description: "CRD with a served version deleted",
+			got:         servedGroupVersionsChanged.Delete(event.DeleteEvent{Object: customResourceDefinition(true)}),
+			want:        true,
+		},
+		{
+			description: "APIService became available",
+			got:         servedGroupVersionsChanged.Update(event.UpdateEvent{ObjectOld: apiService("False"), ObjectNew: apiService("True")}),
+			want:        true,
+		},
+		{
+			description: "APIService became unavailable",
+			got:         servedGroupVersionsChanged.Update(event.UpdateEvent{ObjectOld: apiService("True"), ObjectNew: apiService("Unknown")}),
+			want:        true,
+		},
+		{
+			description: "APIService still unavailable",
+			got:         servedGroupVersionsChanged.Update(event.UpdateEvent{ObjectOld: apiService("False"), ObjectNew: apiService("Unknown")}),
+			want:        false,
+		},
+		{
+			description: "unavailable APIService created",
+			got:         servedGroupVersionsChanged.Create(event.CreateEvent{Object: apiService("False")}),
+			want:        false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			if tc.got != tc.want {
+				t.Errorf("got %t, want %t", tc.got, tc.want)
+			}
+		})
+	}
+}
+
+func TestServedResourcesRebuildsOnce(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := corev1alpha2.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1alpha2.Capability{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "a"}},
+		&corev1alpha2.Capability{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "b"}},
+	).Build()
+
+	var rebuilds int32
+	s := newServedResources(c, logr.Discard(), func() { atomic.AddInt32(&rebuilds, 1) })
+	s.interval = 50 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = s.Start(ctx) }()
+
+	// Changes observed before the first reconcile do not rebuild anything.
+	s.Create(event.CreateEvent{}, nil)
+	time.Sleep(2 * s.interval)
+	if got := atomic.LoadInt32(&rebuilds); got != 0 {
+		t.Fatalf("want no rebuild before the first reconcile, got %d", got)
+	}
+
+	s.reconcileStarted()
+	for i := 0; i < 3; i++ {
+		s.Update(event.UpdateEvent{}, nil)
+		time.Sleep(s.interval / 5)
+	}
+	var got []types.NamespacedName
+	for len(got) < 2 {
+		select {
+		case e := <-s.events:
+			got = append(got, types.NamespacedName{Namespace: e.Object.GetNamespace(), Name: e.Object.GetName()})
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for the events of the Capabilities, got %v", got)
+		}
+	}
+	if got := atomic.LoadInt32(&rebuilds); got != 1 {
+		t.Errorf("want the changes to rebuild once, got %d rebuilds", got)
+	}
+}
@@ -6,60 +6,198 @@ package config
 import (
 	"context"
 	"fmt"
+	"sync"
+	"time"
 
-	corev1 "k8s.io/api/core/v1"
+	"golang.org/x/oauth2"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
-	"sigs.k8s.io/controller-runtime/pkg/client"
+	"k8s.io/client-go/transport"
+
+	"github.com/vmware-tanzu/tanzu-framework/capabilities/client/pkg/discovery"
+	"github.com/vmware-tanzu/tanzu-framework/capabilities/controller/pkg/constants"
+	"github.com/vmware-tanzu/tanzu-framework/util/kubeclient"
 )
 
-// GetConfigForServiceAccount returns a *rest.Config which uses the service account for talking to a Kubernetes API server.
-func GetConfigForServiceAccount(ctx context.Context, coreClient client.Client, nsName, saName, host string) (*rest.Config, error) {
-	serviceAccount := &corev1.ServiceAccount{}
-	if err := coreClient.Get(ctx, client.ObjectKey{
-		Namespace: nsName,
-		Name:      saName,
-	}, serviceAccount); err != nil {
-		return nil, fmt.Errorf("couldn't get service account: %w", err)
+// tokenRefreshFraction is the fraction of the lifetime of a token after which it is requested again, so that a new
+// token is used well before the current one expires.
+const tokenRefreshFraction = 0.8
+
+// ServiceAccountClients caches, per service account, a *rest.Config authenticated with short-lived tokens requested
+// with the TokenRequest API, and a ClusterQueryClient built from it. Tokens are requested again before they expire, so
+// that the cached clients, and the watches started with their config, keep working without requesting a token for
+// every reconcile.
+type ServiceAccountClients struct {
+	clientset  kubernetes.Interface
+	config     *rest.Config
+	expiration time.Duration
+	now        func() time.Time
+
+	mu      sync.Mutex
+	clients map[types.NamespacedName]*serviceAccountClient
+}
+
+// serviceAccountClient is the config and the ClusterQueryClient of a service account. They are created once the first
+// token of the service account is issued.
+type serviceAccountClient struct {
+	tokens *tokenSource
+
+	mu                 sync.Mutex
+	config             *rest.Config
+	clusterQueryClient *discovery.ClusterQueryClient
+}
+
+// NewServiceAccountClients returns clients that talk to the API server of the config with the credentials of service
+// accounts, using tokens which expire after the expiration. Only the address and the TLS settings of the config are
+// used, not its credentials.
+func NewServiceAccountClients(clientset kubernetes.Interface, config *rest.Config, expiration time.Duration) *ServiceAccountClients {
+	return &ServiceAccountClients{
+		clientset:  clientset,
+		config:     rest.AnonymousClientConfig(config),
+		expiration: expiration,
+		now:        time.Now,
+		clients:    make(map[types.NamespacedName]*serviceAccountClient),
 	}
+}
+
+// Get returns the *rest.Config and the ClusterQueryClient of the service account. It returns an error when no token
+// can be requested for the service account, e.g. because it does not exist, in which case its clients are dropped.
+// Only the service account is locked while its token is requested, so that the clients of other service accounts are
+// not held up by a slow API server.
+func (c *ServiceAccountClients) Get(ctx context.Context, nsName, saName string) (*rest.Config, *discovery.ClusterQueryClient, error) {
+	key := types.NamespacedName{Namespace: nsName, Name: saName}
+
+	c.mu.Lock()
+	client, ok := c.clients[key]
+	if !ok {
+		client = &serviceAccountClient{tokens: &tokenSource{clientset: c.clientset, key: key, expiration: c.expiration, now: c.now}}
+		c.clients[key] = client
+	}
+	c.mu.Unlock()
+
+	// The token is requested again if it is due, so that a service account that was deleted is reported.
+	if err := client.tokens.refresh(ctx); err != nil {
+		if !ok || apierrors.IsNotFound(err) {
+			c.drop(key, client)
+		}
+		return nil, nil, err
+	}
+	return client.get(c.config)
+}
 
-	for _, secretRef := range serviceAccount.Secrets {
-		secret := &corev1.Secret{}
-		if err := coreClient.Get(ctx, client.ObjectKey{
-			Namespace: nsName,
-			Name:      secretRef.Name,
-		}, secret); err != nil {
-			return nil, fmt.Errorf("couldn't get service account secret: %w", err)
+// Retain drops the clients of the service accounts that are not in the keys, e.g. because no Capability uses them any
+// more.
+func (c *ServiceAccountClients) Retain(keys map[types.NamespacedName]struct{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.clients {
+		if _, ok := keys[key]; !ok {
+			delete(c.clients, key)
 		}
+	}
+}
+
+// drop drops the clients of the service account, unless they were replaced since.
+func (c *ServiceAccountClients) drop(key types.NamespacedName, client *serviceAccountClient) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.clients[key] == client {
+		delete(c.clients, key)
+	}
+}
+
+// Invalidate drops the discovery information cached by the ClusterQueryClients, e.g. when the resources served by the
+// cluster change.
+func (c *ServiceAccountClients) Invalidate() {
+	c.mu.Lock()
+	clients := make([]*serviceAccountClient, 0, len(c.clients))
+	for _, client := range c.clients {
+		clients = append(clients, client)
+	}
+	c.mu.Unlock()
 
-		if secret.Type != corev1.SecretTypeServiceAccountToken {
-			continue
+	for _, client := range clients {
+		client.mu.Lock()
+		if client.clusterQueryClient != nil {
+			client.clusterQueryClient.Invalidate()
 		}
+		client.mu.Unlock()
+	}
+}
 
-		return buildConfig(secret, host)
+// get returns the config and the ClusterQueryClient of the service account, which are created from the config on first
+// use.
+func (s *serviceAccountClient) get(config *rest.Config) (*rest.Config, *discovery.ClusterQueryClient, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.clusterQueryClient == nil {
+		cfg := rest.CopyConfig(config)
+		cfg.WrapTransport = transport.ResettableTokenSourceWrapTransport(s.tokens)
+		clusterQueryClient, err := discovery.NewClusterQueryClientForConfig(cfg)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to create ClusterQueryClient: %w", err)
+		}
+		s.config, s.clusterQueryClient = cfg, clusterQueryClient
 	}
+	return s.config, s.clusterQueryClient, nil
+}
+
+// tokenSource is a transport.ResettableTokenSource of the tokens of a service account, which requests a token when the
+// current one is past the refresh time, or was rejected by the API server.
+type tokenSource struct {
+	clientset  kubernetes.Interface
+	key        types.NamespacedName
+	expiration time.Duration
+	now        func() time.Time
 
-	return nil, fmt.Errorf("expected to find one service account token secret, but found none")
+	mu        sync.Mutex
+	token     *oauth2.Token
+	issued    time.Time
+	refreshAt time.Time
 }
 
-// buildConfig builds a *rest.Config from the service account secret
-func buildConfig(secret *corev1.Secret, host string) (*rest.Config, error) {
-	caBytes, found := secret.Data[corev1.ServiceAccountRootCAKey]
-	if !found {
-		return nil, fmt.Errorf("couldn't find service account token ca")
+var _ transport.ResettableTokenSource = &tokenSource{}
+
+// Token implements oauth2.TokenSource.
+func (s *tokenSource) Token() (*oauth2.Token, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), constants.ContextTimeout)
+	defer cancel()
+	if err := s.refresh(ctx); err != nil {
+		return nil, err
 	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.token, nil
+}
 
-	tokenBytes, found := secret.Data[corev1.ServiceAccountTokenKey]
-	if !found {
-		return nil, fmt.Errorf("couldn't find service account token value")
+// ResetTokenOlderThan implements transport.ResettableTokenSource. It is called when a request is unauthorized, e.g.
+// because the service account was deleted and created again, which invalidates its tokens.
+func (s *tokenSource) ResetTokenOlderThan(t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.issued.Before(t) {
+		s.token = nil
 	}
+}
 
-	tlsClientConfig := rest.TLSClientConfig{
-		CAData: caBytes,
+// refresh requests a token unless the current one is not due for refresh yet.
+func (s *tokenSource) refresh(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	if s.token != nil && now.Before(s.refreshAt) {
+		return nil
 	}
 
-	return &rest.Config{
-		Host:            host,
-		TLSClientConfig: tlsClientConfig,
-		BearerToken:     string(tokenBytes),
-	}, nil
+	status, err := kubeclient.GetTokenForServiceAccount(ctx, s.clientset, s.key.Namespace, s.key.Name, s.expiration)
+	if err != nil {
+		return fmt.Errorf("couldn't get token for service account %s: %w", s.key, err)
+	}
+	expiry := status.ExpirationTimestamp.Time
+	s.token = &oauth2.Token{AccessToken: status.Token, TokenType: "Bearer", Expiry: expiry}
+	s.issued = now
+	s.refreshAt = now.Add(time.Duration(float64(expiry.Sub(now)) * tokenRefreshFraction))
+	return nil
 }
@@ -9,4 +9,8 @@ const (
 	ContextTimeout                       = 60 * time.Second
 	ServiceAccountWithDefaultPermissions = "tanzu-capabilities-manager-default-sa"
 	CapabilitiesControllerNamespace      = "tkg-system"
+	// MinReevaluationInterval is the minimum interval at which the queries of a Capability are evaluated again.
+	MinReevaluationInterval = 10 * time.Second
+	// ServiceAccountTokenExpiration is the expiration of the tokens requested for the service accounts of Capabilities.
+	ServiceAccountTokenExpiration = time.Hour
 )
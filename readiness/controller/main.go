@@ -147,6 +147,7 @@ func main() {
 		Log:                        ctrl.Log.WithName("controllers").WithName("ReadinessProvider").WithValues("apigroup", "core"),
 		Scheme:                     mgr.GetScheme(),
 		ResourceExistenceCondition: conditions.NewResourceExistenceConditionFunc(),
+		ResourceStatusCondition:    conditions.NewResourceStatusConditionFunc(),
 		RestConfig:                 restConfig,
 		DefaultQueryClient:         clusterQueryClient,
 	}).SetupWithManager(mgr); err != nil {
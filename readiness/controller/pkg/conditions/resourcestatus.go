@@ -0,0 +1,55 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package conditions
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	corev1alpha2 "github.com/vmware-tanzu/tanzu-framework/apis/core/v1alpha2"
+	capabilitiesdiscovery "github.com/vmware-tanzu/tanzu-framework/capabilities/client/pkg/discovery"
+)
+
+// NewResourceStatusConditionFunc returns a function for evaluating a ResourceStatusCondition
+func NewResourceStatusConditionFunc() func(context.Context, *capabilitiesdiscovery.ClusterQueryClient, *corev1alpha2.ResourceStatusCondition, string) (corev1alpha2.ReadinessConditionState, string) {
+	return func(ctx context.Context, queryClient *capabilitiesdiscovery.ClusterQueryClient, c *corev1alpha2.ResourceStatusCondition, conditionName string) (corev1alpha2.ReadinessConditionState, string) {
+		if c == nil {
+			return corev1alpha2.ConditionFailureState, "resourceStatusCondition is not defined"
+		}
+
+		resourceToFind := corev1.ObjectReference{
+			Kind:       c.Kind,
+			Name:       c.Name,
+			APIVersion: c.APIVersion,
+		}
+		if c.Namespace != nil {
+			resourceToFind.Namespace = *(c.Namespace)
+		}
+
+		queryStatusCondition := capabilitiesdiscovery.StatusCondition(conditionName, &resourceToFind, c.Type)
+		if c.Status != "" {
+			queryStatusCondition = queryStatusCondition.WithStatus(c.Status)
+		}
+		if c.MinDuration != nil {
+			queryStatusCondition = queryStatusCondition.WithMinDuration(c.MinDuration.Duration)
+		}
+
+		ok, err := queryClient.Query(queryStatusCondition).ExecuteContext(ctx)
+		if err != nil {
+			return corev1alpha2.ConditionFailureState, err.Error()
+		}
+
+		if !ok {
+			// The condition has the expected status, but not for long enough yet. The message does not include the
+			// remaining duration, so that it does not change every time the condition is evaluated.
+			if queryStatusCondition.RemainingDuration() > 0 {
+				return corev1alpha2.ConditionInProgressState, fmt.Sprintf("waiting for condition %s to keep its status for %s", c.Type, c.MinDuration.Duration)
+			}
+			return corev1alpha2.ConditionFailureState, queryStatusCondition.Reason()
+		}
+		return corev1alpha2.ConditionSuccessState, "condition satisfied"
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2023 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package conditions
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	corev1alpha2 "github.com/vmware-tanzu/tanzu-framework/apis/core/v1alpha2"
+	capabilitiesdiscovery "github.com/vmware-tanzu/tanzu-framework/capabilities/client/pkg/discovery"
+)
+
+var _ = Describe("Resource status condition", func() {
+	var statusQueryClient *capabilitiesdiscovery.ClusterQueryClient
+
+	deployment := func(name string, status metav1.ConditionStatus, lastTransitionTime time.Time) runtime.Object {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]interface{}{"name": name, "namespace": defaultNamespace},
+			"status": map[string]interface{}{
+				"conditions": []interface{}{
+					map[string]interface{}{
+						"type":               "Available",
+						"status":             string(status),
+						"lastTransitionTime": lastTransitionTime.UTC().Format(time.RFC3339),
+					},
+				},
+			},
+		}}
+	}
+	condition := func(name string) *corev1alpha2.ResourceStatusCondition {
+		namespace := defaultNamespace
+		return &corev1alpha2.ResourceStatusCondition{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+			Namespace:  &namespace,
+			Name:       name,
+			Type:       "Available",
+		}
+	}
+
+	BeforeEach(func() {
+		resources := []*metav1.APIResourceList{{
+			GroupVersion: "apps/v1",
+			APIResources: []metav1.APIResource{{Name: "deployments", Kind: "Deployment", Namespaced: true}},
+		}}
+		var err error
+		statusQueryClient, err = capabilitiesdiscovery.NewFakeClusterQueryClient(resources, runtime.NewScheme(), []runtime.Object{
+			deployment("available", metav1.ConditionTrue, time.Now().Add(-time.Hour)),
+			deployment("unavailable", metav1.ConditionFalse, time.Now().Add(-time.Hour)),
+			deployment("recently-available", metav1.ConditionTrue, time.Now()),
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should succeed when the condition has the expected status", func() {
+		state, _ := NewResourceStatusConditionFunc()(context.TODO(), statusQueryClient, condition("available"), "availableCondition")
+
+		Expect(state).To(Equal(corev1alpha2.ConditionSuccessState))
+	})
+
+	It("should fail when the condition does not have the expected status", func() {
+		state, message := NewResourceStatusConditionFunc()(context.TODO(), statusQueryClient, condition("unavailable"), "unavailableCondition")
+
+		Expect(state).To(Equal(corev1alpha2.ConditionFailureState))
+		Expect(message).To(ContainSubstring(`observed="False"`))
+	})
+
+	It("should fail when the resource does not exist", func() {
+		state, _ := NewResourceStatusConditionFunc()(context.TODO(), statusQueryClient, condition("missing"), "missingCondition")
+
+		Expect(state).To(Equal(corev1alpha2.ConditionFailureState))
+	})
+
+	It("should be in progress until the condition has the expected status for the minimum duration", func() {
+		c := condition("recently-available")
+		c.MinDuration = &metav1.Duration{Duration: 10 * time.Minute}
+
+		state, message := NewResourceStatusConditionFunc()(context.TODO(), statusQueryClient, c, "recentlyAvailableCondition")
+		Expect(state).To(Equal(corev1alpha2.ConditionInProgressState))
+
+		// The message must not change while the condition keeps its status, so that status is not rewritten.
+		time.Sleep(time.Second)
+		_, nextMessage := NewResourceStatusConditionFunc()(context.TODO(), statusQueryClient, c, "recentlyAvailableCondition")
+		Expect(nextMessage).To(Equal(message))
+	})
+
+	It("should fail when resourceStatusCondition is undefined", func() {
+		state, _ := NewResourceStatusConditionFunc()(context.TODO(), statusQueryClient, nil, "undefinedCondition")
+
+		Expect(state).To(Equal(corev1alpha2.ConditionFailureState))
+	})
+})
@@ -32,6 +32,7 @@ type ReadinessProviderReconciler struct {
 	Log                        logr.Logger
 	Scheme                     *runtime.Scheme
 	ResourceExistenceCondition func(context.Context, *capabilitiesdiscovery.ClusterQueryClient, *corev1alpha2.ResourceExistenceCondition, string) (corev1alpha2.ReadinessConditionState, string)
+	ResourceStatusCondition    func(context.Context, *capabilitiesdiscovery.ClusterQueryClient, *corev1alpha2.ResourceStatusCondition, string) (corev1alpha2.ReadinessConditionState, string)
 	RestConfig                 *rest.Config
 	DefaultQueryClient         *capabilitiesdiscovery.ClusterQueryClient
 }
@@ -84,7 +85,11 @@ func (r *ReadinessProviderReconciler) Reconcile(ctx context.Context, req ctrl.Re
 		readinessProvider.Status.Conditions[i].Name = condition.Name
 		var state corev1alpha2.ReadinessConditionState
 		var message string
-		state, message = r.ResourceExistenceCondition(ctxCancel, clusterQueryClient, condition.ResourceExistenceCondition, condition.Name)
+		if condition.ResourceStatusCondition != nil {
+			state, message = r.ResourceStatusCondition(ctxCancel, clusterQueryClient, condition.ResourceStatusCondition, condition.Name)
+		} else {
+			state, message = r.ResourceExistenceCondition(ctxCancel, clusterQueryClient, condition.ResourceExistenceCondition, condition.Name)
+		}
 		readinessProvider.Status.Conditions[i].State = state
 		readinessProvider.Status.Conditions[i].Message = message
 	}
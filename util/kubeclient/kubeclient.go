@@ -6,6 +6,7 @@ package kubeclient
 import (
 	"context"
 	"fmt"
+	"time"
 
 	authenticationv1 "k8s.io/api/authentication/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -15,14 +16,30 @@ import (
 
 // GetConfigForServiceAccount returns a *rest.Config which uses the service account for talking to a Kubernetes API server.
 func GetConfigForServiceAccount(ctx context.Context, clientset kubernetes.Interface, inClusterConfig *rest.Config, nsName, saName string) (*rest.Config, error) {
-	treq, err := clientset.CoreV1().ServiceAccounts(nsName).CreateToken(ctx, saName, &authenticationv1.TokenRequest{}, metav1.CreateOptions{})
+	status, err := GetTokenForServiceAccount(ctx, clientset, nsName, saName, 0)
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve token from service account. %s", err.Error())
+		return nil, err
 	}
 
 	return &rest.Config{
-		BearerToken:     treq.Status.Token,
+		BearerToken:     status.Token,
 		Host:            inClusterConfig.Host,
 		TLSClientConfig: inClusterConfig.TLSClientConfig,
 	}, nil
 }
+
+// GetTokenForServiceAccount requests a token for the service account with the TokenRequest API. The token is valid for
+// the expiration, or for the default expiration of the API server when it is zero; the status of the TokenRequest tells
+// when it actually expires.
+func GetTokenForServiceAccount(ctx context.Context, clientset kubernetes.Interface, nsName, saName string, expiration time.Duration) (*authenticationv1.TokenRequestStatus, error) {
+	tokenRequest := &authenticationv1.TokenRequest{}
+	if expiration > 0 {
+		expirationSeconds := int64(expiration / time.Second)
+		tokenRequest.Spec.ExpirationSeconds = &expirationSeconds
+	}
+	treq, err := clientset.CoreV1().ServiceAccounts(nsName).CreateToken(ctx, saName, tokenRequest, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve token from service account. %w", err)
+	}
+	return &treq.Status, nil
+}
@@ -5,6 +5,7 @@ package certs
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"os"
 	"path"
@@ -15,6 +16,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/client-go/util/retry"
 	"knative.dev/pkg/webhook/certificates/resources"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -214,6 +216,53 @@ func (cm *CertificateManager) updateWebhookConfigs(ctx context.Context, caCertDa
 		}
 	}
 
+	return cm.updateCRDConversionWebhooks(ctx, caCertData)
+}
+
+// updateCRDConversionWebhooks updates the caBundle of the conversion webhook of the custom resource definitions
+// selected by the label selector. Custom resource definitions are handled as unstructured objects, so that the client
+// does not need the apiextensions types in its scheme.
+func (cm *CertificateManager) updateCRDConversionWebhooks(ctx context.Context, caCertData []byte) error {
+	if cm.opts.CRDConversionLabel == "" {
+		return nil
+	}
+	cm.opts.Logger.Info("Updating custom resource definition conversion webhooks with CA bundle")
+
+	labelSelector, err := metav1.ParseToLabelSelector(cm.opts.CRDConversionLabel)
+	if err != nil {
+		return fmt.Errorf("failed to parse custom resource definition label: %w", err)
+	}
+	crdList := &unstructured.UnstructuredList{}
+	crdList.SetAPIVersion("apiextensions.k8s.io/v1")
+	crdList.SetKind("CustomResourceDefinitionList")
+	if err := cm.opts.Client.List(ctx, crdList, client.MatchingLabels(labelSelector.MatchLabels)); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to list custom resource definitions: %w", err)
+	}
+
+	for i := range crdList.Items {
+		crd := &crdList.Items[i]
+		if strategy, _, _ := unstructured.NestedString(crd.Object, "spec", "conversion", "strategy"); strategy != "Webhook" {
+			continue
+		}
+
+		// The custom resource definition is read again on conflicts, e.g. with the API server updating its status.
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			if err := unstructured.SetNestedField(crd.Object, base64.StdEncoding.EncodeToString(caCertData), "spec", "conversion", "webhook", "clientConfig", "caBundle"); err != nil {
+				return fmt.Errorf("failed to set the CA bundle: %w", err)
+			}
+			err := cm.opts.Client.Update(ctx, crd)
+			if apierrors.IsConflict(err) {
+				if getErr := cm.opts.Client.Get(ctx, client.ObjectKeyFromObject(crd), crd); getErr != nil {
+					return getErr
+				}
+			}
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update custom resource definition %s: %w", crd.GetName(), err)
+		}
+	}
+
 	return nil
 }
 
@@ -25,6 +25,10 @@ type Options struct {
 	// which the certificate authority data is written.
 	WebhookConfigLabel string
 
+	// CRDConversionLabel is the label used to select the custom resource definitions to whose conversion webhook the
+	// certificate authority data is written. Custom resource definitions are not updated when it is empty.
+	CRDConversionLabel string
+
 	// SecretName is the name of the secret that contains the webhook server's certificate data.
 	SecretName string
 